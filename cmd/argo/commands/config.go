@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
+	"github.com/argoproj/argo/workflow/common"
+	"github.com/argoproj/argo/workflow/config"
+)
+
+func NewConfigCommand() *cobra.Command {
+	var command = &cobra.Command{
+		Use:   "config",
+		Short: "inspect the workflow controller configuration",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+	command.AddCommand(NewConfigGetCommand())
+	return command
+}
+
+func NewConfigGetCommand() *cobra.Command {
+	var (
+		controllerNamespace string
+		configMap           string
+		output              string
+	)
+	var command = &cobra.Command{
+		Use:   "get",
+		Short: "display the controller configuration in effect for the workflows of a namespace",
+		Run: func(cmd *cobra.Command, args []string) {
+			namespace, _, err := clientConfig.Namespace()
+			if err != nil {
+				log.Fatal(err)
+			}
+			kubeClient := initKubeClient()
+
+			cm, err := kubeClient.CoreV1().ConfigMaps(controllerNamespace).Get(configMap, metav1.GetOptions{})
+			if err != nil {
+				log.Fatal(err)
+			}
+			var controllerConfig config.WorkflowControllerConfig
+			err = yaml.Unmarshal([]byte(cm.Data[common.WorkflowControllerConfigMapKey]), &controllerConfig)
+			if err != nil {
+				log.Fatalf("ConfigMap '%s' has an invalid config: %v", configMap, err)
+			}
+
+			labelSelector := labels.SelectorFromSet(labels.Set{common.LabelKeyConfigMapType: common.LabelValueConfigMapTypeParameter})
+			cmList, err := kubeClient.CoreV1().ConfigMaps(namespace).List(metav1.ListOptions{LabelSelector: labelSelector.String()})
+			if err != nil {
+				log.Fatal(err)
+			}
+			var nsConfig *config.NamespaceConfig
+			switch len(cmList.Items) {
+			case 0:
+			case 1:
+				nsConfig, err = config.UnmarshalNamespaceConfig(&cmList.Items[0])
+				if err != nil {
+					log.Fatal(err)
+				}
+			default:
+				log.Fatalf("Namespace %s has %d configmaps of type %s, expected at most one", namespace, len(cmList.Items), common.LabelValueConfigMapTypeParameter)
+			}
+
+			effective := controllerConfig.ForNamespace(nsConfig)
+			switch output {
+			case "json":
+				outBytes, _ := json.MarshalIndent(effective, "", "    ")
+				fmt.Println(string(outBytes))
+			case "yaml", "":
+				outBytes, _ := yaml.Marshal(effective)
+				fmt.Print(string(outBytes))
+			default:
+				log.Fatalf("Unknown output format: %s", output)
+			}
+		},
+	}
+	command.Flags().StringVar(&controllerNamespace, "controller-namespace", "argo", "Namespace of the workflow controller")
+	command.Flags().StringVar(&configMap, "configmap", "workflow-controller-configmap", "Name of the configmap of the workflow controller")
+	command.Flags().StringVarP(&output, "output", "o", "", "Output format. One of: json|yaml")
+	return command
+}
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/argoproj/argo/errors"
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/util/archive"
+	artifact "github.com/argoproj/argo/workflow/artifacts"
+	"github.com/argoproj/argo/workflow/common"
+	"github.com/argoproj/argo/workflow/util"
+)
+
+// NewCpCommand returns a new instance of an `argo cp` command
+func NewCpCommand() *cobra.Command {
+	var (
+		nodeID       string
+		artifactName string
+	)
+	var command = &cobra.Command{
+		Use:   "cp WORKFLOW DEST",
+		Short: "copy the output artifacts of a workflow to a local directory",
+		Long: `Copy the output artifacts of a workflow to a local directory. Each artifact is downloaded to
+DEST/NODE_ID/ARTIFACT_NAME, and unpacked if it was archived as a tarball. The credentials of the
+artifact repository are read from the secrets referenced by the artifacts.`,
+		Example: `# Copy all output artifacts of a workflow:
+  argo cp my-wf ./out
+
+# Copy a single output artifact of a node:
+  argo cp my-wf ./out --node-id my-wf-1234 --artifact-name model`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 2 {
+				cmd.HelpFunc()(cmd, args)
+				os.Exit(1)
+			}
+			kubeClient := initKubeClient()
+			wfClient := InitWorkflowClient()
+			wf, err := wfClient.Get(args[0], metav1.GetOptions{})
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = util.DecompressWorkflow(wf)
+			if err != nil {
+				log.Fatal(err)
+			}
+			copied, err := copyArtifacts(kubeClient, wf, args[1], nodeID, artifactName)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if copied == 0 {
+				log.Fatalf("No output artifacts found in workflow %s", wf.ObjectMeta.Name)
+			}
+		},
+	}
+	command.Flags().StringVar(&nodeID, "node-id", "", "only copy the output artifacts of the node with this ID")
+	command.Flags().StringVar(&artifactName, "artifact-name", "", "only copy the output artifacts with this name")
+	return command
+}
+
+// copyArtifacts copies the output artifacts of a workflow to DEST/NODE_ID/ARTIFACT_NAME, optionally only
+// those of a node or with a name, and returns how many were copied. If an artifact fails to copy, the
+// artifacts already copied are removed, so that a failed copy does not leave a partial download behind.
+func copyArtifacts(kubeClient kubernetes.Interface, wf *wfv1.Workflow, dest string, nodeID string, artifactName string) (int, error) {
+	nodeIDs := make([]string, 0, len(wf.Status.Nodes))
+	for id := range wf.Status.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+	var copied []string
+	for _, id := range nodeIDs {
+		node := wf.Status.Nodes[id]
+		if node.Outputs == nil || (nodeID != "" && node.ID != nodeID) {
+			continue
+		}
+		for _, art := range node.Outputs.Artifacts {
+			if artifactName != "" && art.Name != artifactName {
+				continue
+			}
+			if !art.HasLocation() {
+				log.Warnf("Skipping artifact '%s' of node %s which has no location", art.Name, node.ID)
+				continue
+			}
+			destPath := filepath.Join(dest, node.ID, art.Name)
+			err := copyArtifact(kubeClient, wf.ObjectMeta.Namespace, art, destPath)
+			if err != nil {
+				for _, path := range copied {
+					_ = os.RemoveAll(path)
+				}
+				return 0, errors.InternalWrapErrorf(err, "Failed to copy artifact '%s' of node %s: %v", art.Name, node.ID, err)
+			}
+			fmt.Printf("Artifact '%s' of node %s copied to %s\n", art.Name, node.ID, destPath)
+			copied = append(copied, destPath)
+		}
+	}
+	return len(copied), nil
+}
+
+// copyArtifact downloads an artifact to the destination path with the artifact driver of its location,
+// unpacking it if it is a tarball
+func copyArtifact(kubeClient kubernetes.Interface, namespace string, art wfv1.Artifact, destPath string) error {
+	if _, err := os.Stat(destPath); err == nil {
+		return errors.Errorf(errors.CodeBadRequest, "destination %s already exists", destPath)
+	}
+	driver, err := artifact.NewDriver(art, &common.KubeResourceInterface{KubeClient: kubeClient, Namespace: namespace})
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(destPath), os.ModePerm)
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
+	tempPath := destPath + ".tmp"
+	defer func() { _ = os.RemoveAll(tempPath) }()
+	err = driver.Load(&art, tempPath)
+	if err != nil {
+		return err
+	}
+	if archive.IsTarGz(tempPath) {
+		return archive.UntarGz(tempPath, destPath)
+	}
+	err = os.Rename(tempPath, destPath)
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
+	return nil
+}
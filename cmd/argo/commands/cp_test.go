@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/util/archive"
+)
+
+// newCpTestWorkflow returns a workflow whose nodes have the output artifacts
+func newCpTestWorkflow(artifacts map[string][]wfv1.Artifact) *wfv1.Workflow {
+	wf := &wfv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-wf", Namespace: "default"},
+		Status:     wfv1.WorkflowStatus{Nodes: map[string]wfv1.NodeStatus{}},
+	}
+	for id, arts := range artifacts {
+		wf.Status.Nodes[id] = wfv1.NodeStatus{ID: id, Name: id, Outputs: &wfv1.Outputs{Artifacts: arts}}
+	}
+	return wf
+}
+
+func rawArtifact(name, data string) wfv1.Artifact {
+	return wfv1.Artifact{Name: name, ArtifactLocation: wfv1.ArtifactLocation{Raw: &wfv1.RawArtifact{Data: data}}}
+}
+
+// TestCopyArtifacts verifies artifacts are copied to DEST/NODE_ID/ARTIFACT_NAME and tarballs are unpacked
+func TestCopyArtifacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "argo-cp")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	src := filepath.Join(dir, "src")
+	assert.NoError(t, os.MkdirAll(src, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "model.bin"), []byte("weights"), 0644))
+	var tgz bytes.Buffer
+	assert.NoError(t, archive.TarGzToWriter(src, &tgz))
+
+	wf := newCpTestWorkflow(map[string][]wfv1.Artifact{
+		"my-wf-1": {rawArtifact("text", "hello"), {Name: "nowhere"}},
+		"my-wf-2": {rawArtifact("model", tgz.String())},
+	})
+	kubeClient := fake.NewSimpleClientset()
+	out := filepath.Join(dir, "out")
+
+	copied, err := copyArtifacts(kubeClient, wf, out, "", "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, copied)
+		data, err := ioutil.ReadFile(filepath.Join(out, "my-wf-1", "text"))
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+		data, err = ioutil.ReadFile(filepath.Join(out, "my-wf-2", "model", "model.bin"))
+		assert.NoError(t, err)
+		assert.Equal(t, "weights", string(data))
+		_, err = os.Stat(filepath.Join(out, "my-wf-2", "model.tmp"))
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	copied, err = copyArtifacts(kubeClient, wf, filepath.Join(dir, "node"), "my-wf-1", "text")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, copied)
+
+	copied, err = copyArtifacts(kubeClient, wf, filepath.Join(dir, "none"), "", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, copied)
+}
+
+// TestCopyArtifactsFailure verifies the artifacts already copied are removed when an artifact fails to copy
+func TestCopyArtifactsFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "argo-cp")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	s3 := wfv1.Artifact{Name: "s3", ArtifactLocation: wfv1.ArtifactLocation{S3: &wfv1.S3Artifact{
+		S3Bucket: wfv1.S3Bucket{
+			Bucket:          "bucket",
+			AccessKeySecret: apiv1.SecretKeySelector{LocalObjectReference: apiv1.LocalObjectReference{Name: "missing"}, Key: "accesskey"},
+			SecretKeySecret: apiv1.SecretKeySelector{LocalObjectReference: apiv1.LocalObjectReference{Name: "missing"}, Key: "secretkey"},
+		},
+		Key: "key",
+	}}}
+	wf := newCpTestWorkflow(map[string][]wfv1.Artifact{
+		"my-wf-1": {rawArtifact("text", "hello")},
+		"my-wf-2": {s3},
+	})
+	out := filepath.Join(dir, "out")
+
+	_, err = copyArtifacts(fake.NewSimpleClientset(), wf, out, "", "")
+	assert.Error(t, err)
+	_, err = os.Stat(filepath.Join(out, "my-wf-1", "text"))
+	assert.True(t, os.IsNotExist(err))
+}
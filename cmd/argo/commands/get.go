@@ -49,7 +49,7 @@ func NewGetCommand() *cobra.Command {
 		},
 	}
 
-	command.Flags().StringVarP(&getArgs.output, "output", "o", "", "Output format. One of: json|yaml|wide")
+	command.Flags().StringVarP(&getArgs.output, "output", "o", "", "Output format. One of: json|yaml|wide|dot|mermaid")
 	command.Flags().BoolVar(&noColor, "no-color", false, "Disable colorized output")
 	command.Flags().StringVar(&getArgs.status, "status", "", "Filter by status (Pending, Running, Succeeded, Skipped, Failed, Error)")
 	return command
@@ -69,6 +69,10 @@ func printWorkflow(wf *wfv1.Workflow, output, status string) {
 	case "yaml":
 		outBytes, _ := yaml.Marshal(wf)
 		fmt.Print(string(outBytes))
+	case "dot":
+		newWorkflowGraph(wf).printDot(os.Stdout)
+	case "mermaid":
+		newWorkflowGraph(wf).printMermaid(os.Stdout)
 	case "wide", "":
 		printWorkflowHelper(wf, getArgs)
 	default:
@@ -214,11 +218,11 @@ func isBoundaryNode(node wfv1.NodeType) bool {
 }
 
 func isNonBoundaryParentNode(node wfv1.NodeType) bool {
-	return (node == wfv1.NodeTypeStepGroup) || (node == wfv1.NodeTypeRetry)
+	return (node == wfv1.NodeTypeStepGroup) || (node == wfv1.NodeTypeRetry) || (node == wfv1.NodeTypeMap)
 }
 
 func isExecutionNode(node wfv1.NodeType) bool {
-	return (node == wfv1.NodeTypePod) || (node == wfv1.NodeTypeSkipped) || (node == wfv1.NodeTypeSuspend)
+	return (node == wfv1.NodeTypePod) || (node == wfv1.NodeTypeSkipped) || (node == wfv1.NodeTypeSuspend) || (node == wfv1.NodeTypeHTTP)
 }
 
 func insertSorted(wf *wfv1.Workflow, sortedArray []renderNode, item renderNode) []renderNode {
@@ -313,6 +317,10 @@ func convertToRenderTrees(wf *wfv1.Workflow) map[string]renderNode {
 			nonBoundaryParentMap[id] = &n
 
 			for _, child := range status.Children {
+				if status.Type == wfv1.NodeTypeMap && !strings.HasPrefix(wf.Status.Nodes[child].Name, status.Name+"(") {
+					// Map nodes are also parents of the nodes which follow them, which are not rendered beneath them
+					continue
+				}
 				nonBoundaryParentChildrenMap[child] = &n
 			}
 		}
@@ -0,0 +1,337 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+)
+
+// graph is a directed graph of the nodes of a workflow, or of the steps and tasks of its templates,
+// which is printed in the Graphviz dot or Mermaid format
+type graph struct {
+	label     string
+	nodes     []graphNode
+	subgraphs []*graph
+	edges     []graphEdge
+}
+
+type graphNode struct {
+	id    string
+	label string
+	shape graphShape
+	phase wfv1.NodePhase
+}
+
+type graphEdge struct {
+	from   string
+	to     string
+	dashed bool
+}
+
+// graphShape is the shape a node is drawn with
+type graphShape int
+
+const (
+	shapeBox graphShape = iota
+	shapeRounded
+	shapeCircle
+)
+
+// phaseColors are the fill colors of the nodes of each phase
+var phaseColors = map[wfv1.NodePhase]string{
+	wfv1.NodePending:   "#f4e07b",
+	wfv1.NodeRunning:   "#8fc9f3",
+	wfv1.NodeSucceeded: "#a3e0a3",
+	wfv1.NodeSkipped:   "#dddddd",
+	wfv1.NodeFailed:    "#f29c9c",
+	wfv1.NodeError:     "#f29c9c",
+}
+
+// newWorkflowGraph returns the graph of the nodes of a workflow, with an edge from every node to its children.
+// The onExit node is linked to the outbound nodes of the workflow with dashed edges.
+func newWorkflowGraph(wf *wfv1.Workflow) *graph {
+	g := &graph{label: wf.ObjectMeta.Name}
+	ids := make([]string, 0, len(wf.Status.Nodes))
+	for id := range wf.Status.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		node := wf.Status.Nodes[id]
+		n := graphNode{id: id, label: node.DisplayName, shape: shapeBox, phase: node.Phase}
+		switch node.Type {
+		case wfv1.NodeTypeSteps, wfv1.NodeTypeDAG, wfv1.NodeTypeRetry, wfv1.NodeTypeMap:
+			n.shape = shapeRounded
+		case wfv1.NodeTypeStepGroup, wfv1.NodeTypeTaskGroup:
+			n.shape = shapeCircle
+		}
+		if node.TemplateRef != nil {
+			n.label = fmt.Sprintf("%s\n(%s/%s)", n.label, node.TemplateRef.Name, node.TemplateRef.Template)
+		} else if node.TemplateName != "" && node.TemplateName != node.DisplayName {
+			n.label = fmt.Sprintf("%s\n(%s)", n.label, node.TemplateName)
+		}
+		g.nodes = append(g.nodes, n)
+		for _, child := range node.Children {
+			g.edges = append(g.edges, graphEdge{from: id, to: child})
+		}
+	}
+	onExitID := wf.NodeID(wf.ObjectMeta.Name + "." + onExitSuffix)
+	if _, ok := wf.Status.Nodes[onExitID]; ok {
+		if root, ok := wf.Status.Nodes[wf.ObjectMeta.Name]; ok {
+			outbound := root.OutboundNodes
+			if len(outbound) == 0 {
+				outbound = []string{root.ID}
+			}
+			for _, id := range outbound {
+				g.edges = append(g.edges, graphEdge{from: id, to: onExitID, dashed: true})
+			}
+		}
+	}
+	return g
+}
+
+// templateGraphBuilder builds the static graph of the steps and tasks of the templates of a workflow
+type templateGraphBuilder struct {
+	wf     *wfv1.Workflow
+	nextID int
+}
+
+// newTemplateGraph returns the graph of the templates of a workflow, as run from its entrypoint. Steps and
+// tasks invoking steps or DAG templates are expanded into subgraphs, and the onExit template is linked to
+// the last steps of the entrypoint with dashed edges. Templates referenced with a templateRef are not resolved.
+func newTemplateGraph(wf *wfv1.Workflow) *graph {
+	b := &templateGraphBuilder{wf: wf}
+	g := &graph{label: wf.ObjectMeta.Name}
+	if wf.ObjectMeta.Name == "" {
+		g.label = wf.ObjectMeta.GenerateName
+	}
+	_, exits := b.addInvocation(g, wf.Spec.Entrypoint, wf.Spec.Entrypoint, nil, "", nil)
+	if wf.Spec.OnExit != "" {
+		entries, _ := b.addInvocation(g, onExitSuffix, wf.Spec.OnExit, nil, "", nil)
+		for _, from := range exits {
+			for _, to := range entries {
+				g.edges = append(g.edges, graphEdge{from: from, to: to, dashed: true})
+			}
+		}
+	}
+	return g
+}
+
+// addInvocation adds a step or task invoking a template to the graph, expanding it into a subgraph if the
+// template is a steps or DAG template. It returns the IDs of the nodes the invocation starts and ends with.
+func (b *templateGraphBuilder) addInvocation(g *graph, name string, templateName string, templateRef *wfv1.TemplateRef, when string, stack []string) ([]string, []string) {
+	label := name
+	if templateRef != nil {
+		label = fmt.Sprintf("%s\n(%s/%s)", name, templateRef.Name, templateRef.Template)
+	} else if templateName != name {
+		label = fmt.Sprintf("%s\n(%s)", name, templateName)
+	}
+	if when != "" {
+		label = fmt.Sprintf("%s\nwhen: %s", label, when)
+	}
+	var tmpl *wfv1.Template
+	if templateRef == nil {
+		tmpl = b.wf.GetTemplateByName(templateName)
+	}
+	recursive := false
+	for _, parent := range stack {
+		if parent == templateName {
+			recursive = true
+		}
+	}
+	if tmpl != nil && !recursive && (tmpl.Steps != nil || tmpl.DAG != nil) {
+		sub := &graph{label: label}
+		var entries, exits []string
+		stack = append(stack, templateName)
+		if tmpl.Steps != nil {
+			entries, exits = b.addSteps(sub, tmpl, stack)
+		} else {
+			entries, exits = b.addTasks(sub, tmpl, stack)
+		}
+		if len(entries) > 0 {
+			g.subgraphs = append(g.subgraphs, sub)
+			return entries, exits
+		}
+	}
+	n := graphNode{id: fmt.Sprintf("n%d", b.nextID), label: label, shape: shapeBox}
+	b.nextID++
+	if tmpl == nil || !tmpl.IsLeaf() {
+		n.shape = shapeRounded
+	}
+	g.nodes = append(g.nodes, n)
+	return []string{n.id}, []string{n.id}
+}
+
+// addSteps adds the steps of a steps template to the graph, linking every step to the steps of the next group
+func (b *templateGraphBuilder) addSteps(g *graph, tmpl *wfv1.Template, stack []string) ([]string, []string) {
+	var entries, previous []string
+	for _, group := range tmpl.Steps {
+		var current []string
+		for _, step := range group {
+			stepEntries, stepExits := b.addInvocation(g, step.Name, step.Template, step.TemplateRef, step.When, stack)
+			for _, from := range previous {
+				for _, to := range stepEntries {
+					g.edges = append(g.edges, graphEdge{from: from, to: to})
+				}
+			}
+			if previous == nil {
+				entries = append(entries, stepEntries...)
+			}
+			current = append(current, stepExits...)
+		}
+		if len(current) > 0 {
+			previous = current
+		}
+	}
+	return entries, previous
+}
+
+// addTasks adds the tasks of a DAG template to the graph, linking every task to the tasks depending on it
+func (b *templateGraphBuilder) addTasks(g *graph, tmpl *wfv1.Template, stack []string) ([]string, []string) {
+	taskEntries := make(map[string][]string)
+	taskExits := make(map[string][]string)
+	for _, task := range tmpl.DAG.Tasks {
+		taskEntries[task.Name], taskExits[task.Name] = b.addInvocation(g, task.Name, task.Template, task.TemplateRef, task.When, stack)
+	}
+	dependedOn := make(map[string]bool)
+	var entries, exits []string
+	for _, task := range tmpl.DAG.Tasks {
+		if len(task.Dependencies) == 0 {
+			entries = append(entries, taskEntries[task.Name]...)
+		}
+		for _, dep := range task.Dependencies {
+			dependedOn[dep] = true
+			for _, from := range taskExits[dep] {
+				for _, to := range taskEntries[task.Name] {
+					g.edges = append(g.edges, graphEdge{from: from, to: to})
+				}
+			}
+		}
+	}
+	for _, task := range tmpl.DAG.Tasks {
+		if !dependedOn[task.Name] {
+			exits = append(exits, taskExits[task.Name]...)
+		}
+	}
+	return entries, exits
+}
+
+// printDot prints the graph in the Graphviz dot format
+func (g *graph) printDot(w io.Writer) {
+	fmt.Fprintf(w, "digraph %s {\n", dotQuote(g.label))
+	fmt.Fprintf(w, "  node [style=\"filled\", fillcolor=\"white\", fontname=\"Helvetica\"];\n")
+	g.printDotNodes(w, "  ", new(int))
+	for _, e := range g.allEdges() {
+		if e.dashed {
+			fmt.Fprintf(w, "  %s -> %s [style=dashed];\n", dotQuote(e.from), dotQuote(e.to))
+		} else {
+			fmt.Fprintf(w, "  %s -> %s;\n", dotQuote(e.from), dotQuote(e.to))
+		}
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+func (g *graph) printDotNodes(w io.Writer, indent string, clusters *int) {
+	for _, n := range g.nodes {
+		attrs := []string{"label=" + dotQuote(n.label)}
+		switch n.shape {
+		case shapeBox:
+			attrs = append(attrs, "shape=box")
+		case shapeRounded:
+			attrs = append(attrs, "shape=box", "style=\"filled,rounded\"")
+		case shapeCircle:
+			attrs = append(attrs, "shape=circle", "fixedsize=true", "width=0.3", "fontsize=8")
+		}
+		if color, ok := phaseColors[n.phase]; ok {
+			attrs = append(attrs, "fillcolor="+dotQuote(color))
+		}
+		fmt.Fprintf(w, "%s%s [%s];\n", indent, dotQuote(n.id), strings.Join(attrs, ", "))
+	}
+	for _, sub := range g.subgraphs {
+		fmt.Fprintf(w, "%ssubgraph cluster_%d {\n", indent, *clusters)
+		*clusters++
+		fmt.Fprintf(w, "%s  label=%s;\n", indent, dotQuote(sub.label))
+		sub.printDotNodes(w, indent+"  ", clusters)
+		fmt.Fprintf(w, "%s}\n", indent)
+	}
+}
+
+// printMermaid prints the graph as a Mermaid flowchart
+func (g *graph) printMermaid(w io.Writer) {
+	fmt.Fprintf(w, "graph TD\n")
+	// Mermaid IDs are restricted to plain words, so the nodes are numbered
+	ids := make(map[string]string)
+	phases := make(map[wfv1.NodePhase]bool)
+	g.printMermaidNodes(w, "  ", ids, phases, new(int))
+	for _, e := range g.allEdges() {
+		from, to := ids[e.from], ids[e.to]
+		if from == "" || to == "" {
+			continue
+		}
+		if e.dashed {
+			fmt.Fprintf(w, "  %s -.-> %s\n", from, to)
+		} else {
+			fmt.Fprintf(w, "  %s --> %s\n", from, to)
+		}
+	}
+	classes := make([]string, 0, len(phases))
+	for phase := range phases {
+		classes = append(classes, string(phase))
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		fmt.Fprintf(w, "  classDef %s fill:%s\n", class, phaseColors[wfv1.NodePhase(class)])
+	}
+}
+
+func (g *graph) printMermaidNodes(w io.Writer, indent string, ids map[string]string, phases map[wfv1.NodePhase]bool, subgraphs *int) {
+	for _, n := range g.nodes {
+		id := fmt.Sprintf("n%d", len(ids))
+		ids[n.id] = id
+		label := mermaidQuote(n.label)
+		var shape string
+		switch n.shape {
+		case shapeBox:
+			shape = "[" + label + "]"
+		case shapeRounded:
+			shape = "(" + label + ")"
+		case shapeCircle:
+			shape = "((" + label + "))"
+		}
+		fmt.Fprintf(w, "%s%s%s", indent, id, shape)
+		if _, ok := phaseColors[n.phase]; ok {
+			fmt.Fprintf(w, ":::%s", n.phase)
+			phases[n.phase] = true
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	for _, sub := range g.subgraphs {
+		fmt.Fprintf(w, "%ssubgraph s%d [%s]\n", indent, *subgraphs, mermaidQuote(sub.label))
+		*subgraphs++
+		sub.printMermaidNodes(w, indent+"  ", ids, phases, subgraphs)
+		fmt.Fprintf(w, "%send\n", indent)
+	}
+}
+
+// allEdges returns the edges of the graph and of its subgraphs
+func (g *graph) allEdges() []graphEdge {
+	edges := append([]graphEdge{}, g.edges...)
+	for _, sub := range g.subgraphs {
+		edges = append(edges, sub.allEdges()...)
+	}
+	return edges
+}
+
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + strings.Replace(s, "\n", `\n`, -1) + `"`
+}
+
+func mermaidQuote(s string) string {
+	s = strings.Replace(s, `"`, "#quot;", -1)
+	return `"` + strings.Replace(s, "\n", "<br/>", -1) + `"`
+}
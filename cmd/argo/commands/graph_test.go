@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj/argo/test"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the graph tests")
+
+// assertGolden compares the output with the golden file, or updates the golden file with -update
+func assertGolden(t *testing.T, goldenPath string, output []byte) {
+	if *updateGolden {
+		assert.NoError(t, ioutil.WriteFile(goldenPath, output, 0644))
+		return
+	}
+	expected, err := ioutil.ReadFile(goldenPath)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(expected), string(output), goldenPath)
+	}
+}
+
+// assertGraphGolden compares the dot and Mermaid output of a graph with the golden files of the workflow
+func assertGraphGolden(t *testing.T, wfPath string, g *graph) {
+	base := strings.TrimSuffix(wfPath, filepath.Ext(wfPath))
+	var dot, mermaid bytes.Buffer
+	g.printDot(&dot)
+	g.printMermaid(&mermaid)
+	assertGolden(t, base+".dot", dot.Bytes())
+	assertGolden(t, base+".mmd", mermaid.Bytes())
+}
+
+// TestNewWorkflowGraph verifies the graph of the nodes of a workflow, with the onExit node linked to its outbound nodes
+func TestNewWorkflowGraph(t *testing.T) {
+	wfPath := "testdata/graph-status.yaml"
+	assertGraphGolden(t, wfPath, newWorkflowGraph(test.LoadTestWorkflow(wfPath)))
+}
+
+// TestNewTemplateGraph verifies the graphs of the templates of steps, DAG and recursive workflows
+func TestNewTemplateGraph(t *testing.T) {
+	for _, wfPath := range []string{
+		"testdata/graph-steps.yaml",
+		"testdata/graph-dag.yaml",
+		"testdata/graph-recursive.yaml",
+	} {
+		assertGraphGolden(t, wfPath, newTemplateGraph(test.LoadTestWorkflow(wfPath)))
+	}
+}
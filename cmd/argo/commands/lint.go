@@ -2,22 +2,36 @@ package commands
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 	cmdutil "github.com/argoproj/argo/util/cmd"
 	"github.com/argoproj/argo/workflow/validate"
 )
 
 func NewLintCommand() *cobra.Command {
 	var (
-		strict bool
+		strict           bool
+		workflowDefaults string
+		graphFormat      string
 	)
 	var command = &cobra.Command{
 		Use:   "lint (DIRECTORY | FILE1 FILE2 FILE3...)",
 		Short: "validate a file or directory of workflow manifests",
+		Example: `# Validate a workflow manifest:
+  argo lint my-wf.yaml
+
+# Validate a workflow manifest and render the graph of its templates with Graphviz:
+  argo lint my-wf.yaml --graph | dot -Tsvg > my-wf.svg
+
+# Validate a workflow manifest and print the graph of its templates as a Mermaid flowchart:
+  argo lint my-wf.yaml --graph=mermaid`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
 				cmd.HelpFunc()(cmd, args)
@@ -29,15 +43,33 @@ func NewLintCommand() *cobra.Command {
 				log.Fatal(err)
 			}
 
+			var defaults *wfv1.Workflow
+			if workflowDefaults != "" {
+				defaults, err = readWorkflowDefaults(workflowDefaults)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+
+			if graphFormat != "" && graphFormat != "dot" && graphFormat != "mermaid" {
+				log.Fatalf("Unknown graph format: %s", graphFormat)
+			}
+			// with --graph, the graphs are the only output on stdout so that they can be piped into a renderer
+			out := os.Stdout
+			if graphFormat != "" {
+				out = os.Stderr
+			}
+
 			_ = InitWorkflowClient()
+			var workflows []wfv1.Workflow
 			validateDir := cmdutil.MustIsDir(args[0])
 			if validateDir {
 				if len(args) > 1 {
 					fmt.Printf("Validation of a single directory supported")
 					os.Exit(1)
 				}
-				fmt.Printf("Verifying all workflow manifests in directory: %s\n", args[0])
-				err = validate.LintWorkflowDir(wfClientset, namespace, args[0], strict)
+				fmt.Fprintf(out, "Verifying all workflow manifests in directory: %s\n", args[0])
+				workflows, err = validate.LintWorkflowDir(wfClientset, namespace, args[0], strict, defaults)
 			} else {
 				yamlFiles := make([]string, 0)
 				for _, filePath := range args {
@@ -48,18 +80,52 @@ func NewLintCommand() *cobra.Command {
 					yamlFiles = append(yamlFiles, filePath)
 				}
 				for _, yamlFile := range yamlFiles {
-					err = validate.LintWorkflowFile(wfClientset, namespace, yamlFile, strict)
+					var fileWorkflows []wfv1.Workflow
+					fileWorkflows, err = validate.LintWorkflowFile(wfClientset, namespace, yamlFile, strict, defaults)
 					if err != nil {
 						break
 					}
+					workflows = append(workflows, fileWorkflows...)
 				}
 			}
 			if err != nil {
 				log.Fatal(err)
 			}
-			fmt.Printf("Workflow manifests validated\n")
+			fmt.Fprintf(out, "Workflow manifests validated\n")
+			if graphFormat != "" {
+				printTemplateGraphs(os.Stdout, workflows, graphFormat)
+			}
 		},
 	}
 	command.Flags().BoolVar(&strict, "strict", true, "perform strict workflow validatation")
+	command.Flags().StringVar(&workflowDefaults, "workflow-defaults", "", "file containing the workflow defaults of the controller config, which are merged into the workflows before validation")
+	command.Flags().StringVar(&graphFormat, "graph", "", "print the graph of the templates of the workflows, without submitting them. One of: dot|mermaid")
+	command.Flags().Lookup("graph").NoOptDefVal = "dot"
 	return command
 }
+
+// printTemplateGraphs prints the graph of the templates of the workflows, as they were validated
+func printTemplateGraphs(w io.Writer, workflows []wfv1.Workflow, graphFormat string) {
+	for i := range workflows {
+		g := newTemplateGraph(&workflows[i])
+		if graphFormat == "mermaid" {
+			g.printMermaid(w)
+		} else {
+			g.printDot(w)
+		}
+	}
+}
+
+// readWorkflowDefaults reads the workflow defaults, i.e. a partial workflow, from a file
+func readWorkflowDefaults(filePath string) (*wfv1.Workflow, error) {
+	body, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var defaults wfv1.Workflow
+	err = yaml.UnmarshalStrict(body, &defaults)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed to parse: %v", filePath, err)
+	}
+	return &defaults, nil
+}
@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	wffake "github.com/argoproj/argo/pkg/client/clientset/versioned/fake"
+	"github.com/argoproj/argo/workflow/validate"
+)
+
+// TestPrintTemplateGraphsWorkflowDefaults verifies the graphs are of the workflows with the defaults merged
+func TestPrintTemplateGraphsWorkflowDefaults(t *testing.T) {
+	defaults := &wfv1.Workflow{Spec: wfv1.WorkflowSpec{OnExit: "echo"}}
+	workflows, err := validate.LintWorkflowFile(wffake.NewSimpleClientset(), "default", "testdata/graph-dag.yaml", true, defaults)
+	if !assert.NoError(t, err) || !assert.Len(t, workflows, 1) {
+		return
+	}
+	var out bytes.Buffer
+	printTemplateGraphs(&out, workflows, "dot")
+	assert.Contains(t, out.String(), `[label="onExit\n(echo)", shape=box];`)
+	assert.Contains(t, out.String(), `"n3" -> "n4" [style=dashed];`)
+}
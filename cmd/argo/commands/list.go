@@ -1,7 +1,9 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"sort"
@@ -15,8 +17,12 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
 
+	"github.com/argoproj/argo/pkg/apis/workflow"
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 	"github.com/argoproj/argo/pkg/client/clientset/versioned/typed/workflow/v1alpha1"
 	"github.com/argoproj/argo/workflow/common"
@@ -31,6 +37,17 @@ type listFlags struct {
 	output        string   // --output
 	since         string   // --since
 	chunkSize     int64    // --chunk-size
+	labels        string   // --selector
+	fields        string   // --field-selector
+	prefix        string   // --prefix
+	sortBy        string   // --sort-by
+	limit         int      // --limit
+}
+
+// customColumn is a column of the `custom-columns` output, with the JSONPath of its value
+type customColumn struct {
+	header string
+	path   *jsonpath.JSONPath
 }
 
 func NewListCommand() *cobra.Command {
@@ -40,15 +57,39 @@ func NewListCommand() *cobra.Command {
 	var command = &cobra.Command{
 		Use:   "list",
 		Short: "list workflows",
+		Example: `# List the workflows of the current namespace:
+  argo list
+
+# List the failed workflows of an app, longest first:
+  argo list -l app=my-app --status Failed --sort-by duration
+
+# List the names and entrypoints of the 10 most recently started workflows:
+  argo list --sort-by start --limit 10 -o custom-columns=NAME:.metadata.name,ENTRYPOINT:.spec.entrypoint`,
 		Run: func(cmd *cobra.Command, args []string) {
+			var columns []customColumn
+			if strings.HasPrefix(listArgs.output, "custom-columns=") {
+				var err error
+				columns, err = parseCustomColumns(strings.TrimPrefix(listArgs.output, "custom-columns="))
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			switch listArgs.sortBy {
+			case "", "start", "finish", "duration", "name":
+			default:
+				log.Fatalf("Unknown sort key: %s", listArgs.sortBy)
+			}
 			var wfClient v1alpha1.WorkflowInterface
 			if listArgs.allNamespaces {
 				wfClient = InitWorkflowClient(apiv1.NamespaceAll)
 			} else {
 				wfClient = InitWorkflowClient()
 			}
-			listOpts := metav1.ListOptions{}
-			labelSelector := labels.NewSelector()
+			listOpts := metav1.ListOptions{FieldSelector: listArgs.fields}
+			labelSelector, err := labels.Parse(listArgs.labels)
+			if err != nil {
+				log.Fatal(err)
+			}
 			if len(listArgs.status) != 0 {
 				req, _ := labels.NewRequirement(common.LabelKeyPhase, selection.In, listArgs.status)
 				if req != nil {
@@ -82,30 +123,25 @@ func NewListCommand() *cobra.Command {
 				tmpWorkFlows = append(tmpWorkFlows, wfList.Items...)
 			}
 
-			var workflows []wfv1.Workflow
-			if listArgs.since == "" {
-				workflows = tmpWorkFlows
-			} else {
-				workflows = make([]wfv1.Workflow, 0)
-				minTime, err := argotime.ParseSince(listArgs.since)
-				if err != nil {
-					log.Fatal(err)
-				}
-				for _, wf := range tmpWorkFlows {
-					if wf.Status.FinishedAt.IsZero() || wf.ObjectMeta.CreationTimestamp.After(*minTime) {
-						workflows = append(workflows, wf)
-					}
-				}
+			workflows, err := selectWorkflows(tmpWorkFlows, &listArgs)
+			if err != nil {
+				log.Fatal(err)
 			}
-			sort.Sort(ByFinishedAt(workflows))
 
-			switch listArgs.output {
-			case "", "wide":
+			switch {
+			case listArgs.output == "" || listArgs.output == "wide":
 				printTable(workflows, &listArgs)
-			case "name":
+			case listArgs.output == "name":
 				for _, wf := range workflows {
 					fmt.Println(wf.ObjectMeta.Name)
 				}
+			case listArgs.output == "json" || listArgs.output == "yaml":
+				printWorkflowList(workflows, listArgs.output)
+			case columns != nil:
+				err = printCustomColumns(os.Stdout, workflows, columns)
+				if err != nil {
+					log.Fatal(err)
+				}
 			default:
 				log.Fatalf("Unknown output mode: %s", listArgs.output)
 			}
@@ -115,9 +151,14 @@ func NewListCommand() *cobra.Command {
 	command.Flags().StringSliceVar(&listArgs.status, "status", []string{}, "Filter by status (comma separated)")
 	command.Flags().BoolVar(&listArgs.completed, "completed", false, "Show only completed workflows")
 	command.Flags().BoolVar(&listArgs.running, "running", false, "Show only running workflows")
-	command.Flags().StringVarP(&listArgs.output, "output", "o", "", "Output format. One of: wide|name")
+	command.Flags().StringVarP(&listArgs.output, "output", "o", "", "Output format. One of: wide|name|json|yaml|custom-columns=HEADER:JSONPATH,...")
 	command.Flags().StringVar(&listArgs.since, "since", "", "Show only workflows newer than a relative duration")
 	command.Flags().Int64VarP(&listArgs.chunkSize, "chunk-size", "", 500, "Return large lists in chunks rather than all at once. Pass 0 to disable.")
+	command.Flags().StringVarP(&listArgs.labels, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='")
+	command.Flags().StringVar(&listArgs.fields, "field-selector", "", "Selector (field query) to filter on, supports '=', '==', and '!='. Workflows only support metadata.name and metadata.namespace")
+	command.Flags().StringVar(&listArgs.prefix, "prefix", "", "Show only workflows whose name starts with this prefix")
+	command.Flags().StringVar(&listArgs.sortBy, "sort-by", "", "Sort the workflows by start (newest first), finish (newest first), duration (longest first) or name. Running workflows are listed first by default")
+	command.Flags().IntVar(&listArgs.limit, "limit", 0, "Show at most this many workflows, after they are filtered and sorted. Pass 0 to show all")
 	return command
 }
 
@@ -152,6 +193,181 @@ func printTable(wfList []wfv1.Workflow, listArgs *listFlags) {
 	_ = w.Flush()
 }
 
+// selectWorkflows filters the workflows with --since and --prefix, sorts them with --sort-by and keeps the
+// first ones up to --limit
+func selectWorkflows(workflows []wfv1.Workflow, listArgs *listFlags) ([]wfv1.Workflow, error) {
+	var minTime *time.Time
+	if listArgs.since != "" {
+		var err error
+		minTime, err = argotime.ParseSince(listArgs.since)
+		if err != nil {
+			return nil, err
+		}
+	}
+	selected := make([]wfv1.Workflow, 0)
+	for _, wf := range workflows {
+		if minTime != nil && !wf.Status.FinishedAt.IsZero() && !wf.ObjectMeta.CreationTimestamp.After(*minTime) {
+			continue
+		}
+		if !strings.HasPrefix(wf.ObjectMeta.Name, listArgs.prefix) {
+			continue
+		}
+		selected = append(selected, wf)
+	}
+	sortWorkflows(selected, listArgs.sortBy)
+	if listArgs.limit > 0 && len(selected) > listArgs.limit {
+		selected = selected[:listArgs.limit]
+	}
+	return selected, nil
+}
+
+// sortWorkflows sorts the workflows by a sort key, or running workflows first by default
+func sortWorkflows(workflows []wfv1.Workflow, sortBy string) {
+	switch sortBy {
+	case "start":
+		sort.SliceStable(workflows, func(i, j int) bool {
+			return workflows[j].Status.StartedAt.Before(&workflows[i].Status.StartedAt)
+		})
+	case "finish":
+		// running workflows have yet to finish, so they are listed before the finished ones
+		sort.SliceStable(workflows, func(i, j int) bool {
+			iFinish := workflows[i].Status.FinishedAt
+			jFinish := workflows[j].Status.FinishedAt
+			if iFinish.IsZero() || jFinish.IsZero() {
+				return iFinish.IsZero() && !jFinish.IsZero()
+			}
+			return jFinish.Before(&iFinish)
+		})
+	case "duration":
+		sort.SliceStable(workflows, func(i, j int) bool {
+			return workflowDuration(&workflows[i]) > workflowDuration(&workflows[j])
+		})
+	case "name":
+		sort.SliceStable(workflows, func(i, j int) bool {
+			return workflows[i].ObjectMeta.Name < workflows[j].ObjectMeta.Name
+		})
+	default:
+		sort.Sort(ByFinishedAt(workflows))
+	}
+}
+
+// workflowDuration returns how long a workflow ran, or has been running for
+func workflowDuration(wf *wfv1.Workflow) time.Duration {
+	if wf.Status.StartedAt.IsZero() {
+		return 0
+	}
+	if wf.Status.FinishedAt.IsZero() {
+		return time.Since(wf.Status.StartedAt.Time)
+	}
+	return wf.Status.FinishedAt.Sub(wf.Status.StartedAt.Time)
+}
+
+// printWorkflowList prints the workflows as a workflow list in json or yaml
+func printWorkflowList(workflows []wfv1.Workflow, output string) {
+	wfList := wfv1.WorkflowList{
+		TypeMeta: metav1.TypeMeta{Kind: "WorkflowList", APIVersion: wfv1.SchemeGroupVersion.String()},
+		Items:    workflows,
+	}
+	for i := range wfList.Items {
+		wfList.Items[i].TypeMeta = metav1.TypeMeta{Kind: workflow.WorkflowKind, APIVersion: wfv1.SchemeGroupVersion.String()}
+	}
+	if output == "json" {
+		outBytes, _ := json.MarshalIndent(wfList, "", "    ")
+		fmt.Println(string(outBytes))
+	} else {
+		outBytes, _ := yaml.Marshal(wfList)
+		fmt.Print(string(outBytes))
+	}
+}
+
+// parseCustomColumns parses the spec of the custom-columns output, e.g. NAME:.metadata.name,STATUS:.status.phase.
+// Like kubectl, the braces of the JSONPath templates may be omitted.
+func parseCustomColumns(spec string) ([]customColumn, error) {
+	var columns []customColumn
+	for _, columnSpec := range splitCustomColumns(spec) {
+		parts := strings.SplitN(columnSpec, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("custom column '%s' must be of the form HEADER:JSONPATH", columnSpec)
+		}
+		tmpl := parts[1]
+		if !strings.HasPrefix(tmpl, "{") {
+			if !strings.HasPrefix(tmpl, ".") {
+				tmpl = "." + tmpl
+			}
+			tmpl = "{" + tmpl + "}"
+		}
+		path := jsonpath.New(parts[0]).AllowMissingKeys(true)
+		err := path.Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSONPath '%s' of custom column %s: %v", parts[1], parts[0], err)
+		}
+		columns = append(columns, customColumn{header: parts[0], path: path})
+	}
+	return columns, nil
+}
+
+// splitCustomColumns splits the spec of the custom-columns output at the commas separating the columns,
+// ignoring the commas within the brackets, braces, parentheses and quotes of the JSONPaths
+func splitCustomColumns(spec string) []string {
+	var columnSpecs []string
+	depth := 0
+	var quote rune
+	start := 0
+	for i, r := range spec {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '[' || r == '{' || r == '(':
+			depth++
+		case r == ']' || r == '}' || r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			columnSpecs = append(columnSpecs, spec[start:i])
+			start = i + 1
+		}
+	}
+	return append(columnSpecs, spec[start:])
+}
+
+// printCustomColumns prints a table of the workflows with the custom columns, printing <none> for missing values
+func printCustomColumns(out io.Writer, workflows []wfv1.Workflow, columns []customColumn) error {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.header
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, wf := range workflows {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&wf)
+		if err != nil {
+			return err
+		}
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			results, err := column.path.FindResults(obj)
+			if err != nil {
+				return fmt.Errorf("failed to evaluate custom column %s of workflow %s: %v", column.header, wf.ObjectMeta.Name, err)
+			}
+			var strs []string
+			for _, result := range results {
+				for _, value := range result {
+					strs = append(strs, fmt.Sprintf("%v", value.Interface()))
+				}
+			}
+			values[i] = strings.Join(strs, ",")
+			if values[i] == "" {
+				values[i] = "<none>"
+			}
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	return w.Flush()
+}
+
 func countPendingRunningCompleted(wf *wfv1.Workflow) (int, int, int) {
 	pending := 0
 	running := 0
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+)
+
+// newListTestWorkflow returns a workflow created at its start, which is running if finish is zero
+func newListTestWorkflow(name string, start time.Time, finish time.Time) wfv1.Workflow {
+	wf := wfv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.NewTime(start)},
+		Spec:       wfv1.WorkflowSpec{Entrypoint: "main"},
+		Status:     wfv1.WorkflowStatus{Phase: wfv1.NodeRunning, StartedAt: metav1.NewTime(start)},
+	}
+	if !finish.IsZero() {
+		wf.Status.Phase = wfv1.NodeSucceeded
+		wf.Status.FinishedAt = metav1.NewTime(finish)
+	}
+	return wf
+}
+
+func newListTestWorkflows() []wfv1.Workflow {
+	now := time.Now()
+	return []wfv1.Workflow{
+		// finished an hour ago after 10 minutes
+		newListTestWorkflow("build-a", now.Add(-70*time.Minute), now.Add(-60*time.Minute)),
+		// running for 5 minutes
+		newListTestWorkflow("test-b", now.Add(-5*time.Minute), time.Time{}),
+		// finished 2 days ago after an hour
+		newListTestWorkflow("build-c", now.Add(-49*time.Hour), now.Add(-48*time.Hour)),
+		// finished a minute ago after 2 minutes
+		newListTestWorkflow("deploy-d", now.Add(-3*time.Minute), now.Add(-time.Minute)),
+	}
+}
+
+func workflowNames(workflows []wfv1.Workflow) []string {
+	names := make([]string, len(workflows))
+	for i, wf := range workflows {
+		names[i] = wf.ObjectMeta.Name
+	}
+	return names
+}
+
+func TestSortWorkflows(t *testing.T) {
+	tests := []struct {
+		sortBy   string
+		expected []string
+	}{
+		{"", []string{"test-b", "deploy-d", "build-a", "build-c"}},
+		{"start", []string{"deploy-d", "test-b", "build-a", "build-c"}},
+		{"finish", []string{"test-b", "deploy-d", "build-a", "build-c"}},
+		{"duration", []string{"build-c", "build-a", "test-b", "deploy-d"}},
+		{"name", []string{"build-a", "build-c", "deploy-d", "test-b"}},
+	}
+	for _, test := range tests {
+		workflows := newListTestWorkflows()
+		sortWorkflows(workflows, test.sortBy)
+		assert.Equal(t, test.expected, workflowNames(workflows), test.sortBy)
+	}
+}
+
+func TestSelectWorkflows(t *testing.T) {
+	tests := []struct {
+		name     string
+		listArgs listFlags
+		expected []string
+	}{
+		{"all", listFlags{}, []string{"test-b", "deploy-d", "build-a", "build-c"}},
+		{"prefix", listFlags{prefix: "build-"}, []string{"build-a", "build-c"}},
+		{"no match", listFlags{prefix: "other-"}, []string{}},
+		{"since", listFlags{since: "2h"}, []string{"test-b", "deploy-d", "build-a"}},
+		{"since excludes finished only", listFlags{since: "1m"}, []string{"test-b"}},
+		{"limit", listFlags{limit: 2}, []string{"test-b", "deploy-d"}},
+		{"limit after sort", listFlags{sortBy: "name", limit: 3}, []string{"build-a", "build-c", "deploy-d"}},
+		{"limit beyond", listFlags{limit: 10}, []string{"test-b", "deploy-d", "build-a", "build-c"}},
+		{"combined", listFlags{prefix: "build-", since: "2h", limit: 1}, []string{"build-a"}},
+	}
+	for _, test := range tests {
+		workflows, err := selectWorkflows(newListTestWorkflows(), &test.listArgs)
+		if assert.NoError(t, err, test.name) {
+			assert.Equal(t, test.expected, workflowNames(workflows), test.name)
+		}
+	}
+	_, err := selectWorkflows(newListTestWorkflows(), &listFlags{since: "yesterday"})
+	assert.Error(t, err)
+}
+
+func TestParseCustomColumns(t *testing.T) {
+	tests := []struct {
+		spec    string
+		headers []string
+		err     bool
+	}{
+		{"NAME:.metadata.name", []string{"NAME"}, false},
+		{"NAME:metadata.name,STATUS:{.status.phase}", []string{"NAME", "STATUS"}, false},
+		{`NAME:.metadata.name,PARAM:.spec.arguments.parameters[?(@.name=="a,b")].value`, []string{"NAME", "PARAM"}, false},
+		{"IDS:.metadata['name','namespace'],UID:.metadata.uid", []string{"IDS", "UID"}, false},
+		{"NAME", nil, true},
+		{"NAME:", nil, true},
+		{":.metadata.name", nil, true},
+		{"NAME:.metadata.name,", nil, true},
+		{"NAME:.metadata[", nil, true},
+	}
+	for _, test := range tests {
+		columns, err := parseCustomColumns(test.spec)
+		if test.err {
+			assert.Error(t, err, test.spec)
+			continue
+		}
+		if assert.NoError(t, err, test.spec) {
+			headers := make([]string, len(columns))
+			for i, column := range columns {
+				headers[i] = column.header
+			}
+			assert.Equal(t, test.headers, headers, test.spec)
+		}
+	}
+}
+
+func TestPrintCustomColumns(t *testing.T) {
+	workflows := newListTestWorkflows()[:2]
+	value := "x,y"
+	workflows[0].Spec.Arguments.Parameters = []wfv1.Parameter{{Name: "a,b", Value: &value}}
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"NAME:.metadata.name,STATUS:.status.phase", "NAME      STATUS\nbuild-a   Succeeded\ntest-b    Running\n"},
+		{"NAME:.metadata.name,MISSING:.spec.onExit", "NAME      MISSING\nbuild-a   <none>\ntest-b    <none>\n"},
+		{`NAME:.metadata.name,PARAM:.spec.arguments.parameters[?(@.name=="a,b")].value`, "NAME      PARAM\nbuild-a   x,y\ntest-b    <none>\n"},
+		{"IDS:.metadata['name','namespace']", "IDS\nbuild-a,default\ntest-b,default\n"},
+	}
+	for _, test := range tests {
+		columns, err := parseCustomColumns(test.spec)
+		if !assert.NoError(t, err, test.spec) {
+			continue
+		}
+		var out bytes.Buffer
+		assert.NoError(t, printCustomColumns(&out, workflows, columns), test.spec)
+		assert.Equal(t, test.expected, out.String(), test.spec)
+	}
+}
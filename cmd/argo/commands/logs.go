@@ -3,10 +3,15 @@ package commands
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"hash/fnv"
+	"io"
+	"io/ioutil"
 	"math"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,6 +20,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -25,24 +31,42 @@ import (
 
 	"github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 	workflowv1 "github.com/argoproj/argo/pkg/client/clientset/versioned/typed/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
 	"github.com/argoproj/argo/workflow/util"
 	"github.com/argoproj/pkg/errors"
 )
 
+// allContainers is the container name to print the logs of all containers of the pods
+const allContainers = "all"
+
 type logEntry struct {
+	workflow    string
 	displayName string
 	pod         string
+	container   string
 	time        time.Time
 	line        string
 }
 
+// logRecord is a log entry as printed with `-o json`
+type logRecord struct {
+	Workflow  string     `json:"workflow,omitempty"`
+	Node      string     `json:"node,omitempty"`
+	Pod       string     `json:"pod"`
+	Container string     `json:"container"`
+	Time      *time.Time `json:"time,omitempty"`
+	Line      string     `json:"line"`
+}
+
 func NewLogsCommand() *cobra.Command {
 	var (
-		printer   logPrinter
-		workflow  bool
-		since     string
-		sinceTime string
-		tail      int64
+		printer      logPrinter
+		workflow     bool
+		since        string
+		sinceTime    string
+		tail         int64
+		grep         string
+		nodeSelector string
 	)
 	var command = &cobra.Command{
 		Use:   "logs POD/WORKFLOW",
@@ -55,6 +79,24 @@ func NewLogsCommand() *cobra.Command {
 			conf, err := clientConfig.ClientConfig()
 			errors.CheckError(err)
 			printer.kubeClient = kubernetes.NewForConfigOrDie(conf)
+			printer.out = os.Stdout
+			if printer.archived && printer.follow {
+				log.Fatal("--archived cannot be used with --follow")
+			}
+			if printer.output != "" && printer.output != "json" {
+				log.Fatalf("Unknown output format: %s", printer.output)
+			}
+			if grep != "" {
+				printer.grep, err = regexp.Compile(grep)
+				errors.CheckError(err)
+			}
+			if nodeSelector != "" {
+				if !workflow {
+					log.Fatal("--selector can only be used with --workflow")
+				}
+				printer.nodeSelector, err = fields.ParseSelector(nodeSelector)
+				errors.CheckError(err)
+			}
 			if tail > 0 {
 				printer.tail = &tail
 			}
@@ -79,13 +121,17 @@ func NewLogsCommand() *cobra.Command {
 
 		},
 	}
-	command.Flags().StringVarP(&printer.container, "container", "c", "main", "Print the logs of this container")
+	command.Flags().StringVarP(&printer.container, "container", "c", common.MainContainerName, "Print the logs of this container, or of all containers of the pods including the init, wait and sidecar containers with 'all'")
 	command.Flags().BoolVarP(&workflow, "workflow", "w", false, "Specify that whole workflow logs should be printed")
 	command.Flags().BoolVarP(&printer.follow, "follow", "f", false, "Specify if the logs should be streamed.")
 	command.Flags().StringVar(&since, "since", "", "Only return logs newer than a relative duration like 5s, 2m, or 3h. Defaults to all logs. Only one of since-time / since may be used.")
 	command.Flags().StringVar(&sinceTime, "since-time", "", "Only return logs after a specific date (RFC3339). Defaults to all logs. Only one of since-time / since may be used.")
 	command.Flags().Int64Var(&tail, "tail", -1, "Lines of recent log file to display. Defaults to -1 with no selector, showing all log lines otherwise 10, if a selector is provided.")
-	command.Flags().BoolVar(&printer.timestamps, "timestamps", false, "Include timestamps on each line in the log output")
+	command.Flags().BoolVar(&printer.timestamps, "timestamps", false, "Include timestamps on each line in the log output. Lines of archived logs are stamped with the start time of their node.")
+	command.Flags().BoolVar(&printer.archived, "archived", false, "Print the archived logs of the main container even if the pods still exist. Archived logs are printed for pods which no longer exist. Since archived log lines have no timestamps, --since and --since-time only skip whole nodes which finished earlier.")
+	command.Flags().StringVar(&grep, "grep", "", "Only print the log lines matching this regular expression")
+	command.Flags().StringVar(&nodeSelector, "selector", "", "Only print the logs of the workflow nodes matching this field selector, e.g. --selector displayName=train. Supports id, name, displayName, templateName, phase and type")
+	command.Flags().StringVarP(&printer.output, "output", "o", "", "Output format. One of: json, which prints a record of workflow, node, pod, container, time and line per log line")
 	command.Flags().BoolVar(&noColor, "no-color", false, "Disable colorized output")
 	return command
 }
@@ -97,7 +143,12 @@ type logPrinter struct {
 	sinceTime    *metav1.Time
 	tail         *int64
 	timestamps   bool
+	archived     bool
+	grep         *regexp.Regexp
+	nodeSelector fields.Selector
+	output       string
 	kubeClient   kubernetes.Interface
+	out          io.Writer
 }
 
 // PrintWorkflowLogs prints logs for all workflow pods
@@ -121,9 +172,25 @@ func (p *logPrinter) PrintPodLogs(podName string) error {
 		return err
 	}
 	var logs []logEntry
-	err = p.getPodLogs(context.Background(), "", podName, namespace, p.follow, p.tail, p.sinceSeconds, p.sinceTime, func(entry logEntry) {
+	workflowName := ""
+	callback := func(entry logEntry) {
+		entry.workflow = workflowName
 		logs = append(logs, entry)
-	})
+	}
+	pod, err := p.kubeClient.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+	if err == nil {
+		workflowName = pod.Labels[common.LabelKeyWorkflow]
+		if !p.archived {
+			err = p.getPodLogs(context.Background(), "", podName, namespace, p.follow, p.tail, p.sinceSeconds, p.sinceTime, callback)
+		}
+	}
+	if p.archived || apierr.IsNotFound(err) {
+		var node *v1alpha1.NodeStatus
+		workflowName, node, err = findPodNode(InitWorkflowClient(namespace), podName, workflowName)
+		if err == nil {
+			err = p.getArchivedLogs(context.Background(), "", *node, namespace, p.tail, p.sinceSeconds, p.sinceTime, callback)
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -142,7 +209,7 @@ func (p *logPrinter) printRecentWorkflowLogs(wf *v1alpha1.Workflow) map[string]*
 		return nil
 	}
 	for _, node := range wf.Status.Nodes {
-		if node.Type == v1alpha1.NodeTypePod && node.Phase != v1alpha1.NodeError {
+		if p.printsNode(node) {
 			podNodes = append(podNodes, node)
 		}
 	}
@@ -156,9 +223,18 @@ func (p *logPrinter) printRecentWorkflowLogs(wf *v1alpha1.Workflow) map[string]*
 		go func() {
 			defer wg.Done()
 			var podLogs []logEntry
-			err := p.getPodLogs(context.Background(), getDisplayName(node), node.ID, wf.Namespace, false, p.tail, p.sinceSeconds, p.sinceTime, func(entry logEntry) {
+			callback := func(entry logEntry) {
+				entry.workflow = wf.Name
 				podLogs = append(podLogs, entry)
-			})
+			}
+			var err error
+			if !p.archived {
+				err = p.getPodLogs(context.Background(), getDisplayName(node), node.ID, wf.Namespace, false, p.tail, p.sinceSeconds, p.sinceTime, callback)
+			}
+			if p.archived || apierr.IsNotFound(err) {
+				// the pod is gone, e.g. garbage collected, so fall back to its archived logs
+				err = p.getArchivedLogs(context.Background(), getDisplayName(node), node, wf.Namespace, p.tail, p.sinceSeconds, p.sinceTime, callback)
+			}
 
 			if err != nil {
 				log.Warn(err)
@@ -180,7 +256,7 @@ func (p *logPrinter) printRecentWorkflowLogs(wf *v1alpha1.Workflow) map[string]*
 		if int64(len(flattenLogs)) < tail {
 			tail = int64(len(flattenLogs))
 		}
-		flattenLogs = flattenLogs[0:tail]
+		flattenLogs = flattenLogs[int64(len(flattenLogs))-tail:]
 	}
 	timeByPod := make(map[string]*time.Time)
 	for _, entry := range flattenLogs {
@@ -205,7 +281,7 @@ func (p *logPrinter) printLiveWorkflowLogs(workflowName string, wfClient workflo
 		}
 		for id := range wf.Status.Nodes {
 			node := wf.Status.Nodes[id]
-			if node.Type == v1alpha1.NodeTypePod && node.Phase != v1alpha1.NodeError && !streamedPods[node.ID] {
+			if p.printsNode(node) && !streamedPods[node.ID] {
 				streamedPods[node.ID] = true
 				go func() {
 					var sinceTimePtr *metav1.Time
@@ -215,9 +291,11 @@ func (p *logPrinter) printLiveWorkflowLogs(workflowName string, wfClient workflo
 						sinceTimePtr = &sinceTime
 					}
 					err := p.getPodLogs(ctx, getDisplayName(node), node.ID, wf.Namespace, true, nil, nil, sinceTimePtr, func(entry logEntry) {
+						entry.workflow = wf.Name
 						logs <- entry
 					})
-					if err != nil {
+					// the archived logs of pods which are gone were printed with the recent logs
+					if err != nil && !apierr.IsNotFound(err) {
 						log.Warn(err)
 					}
 				}()
@@ -256,6 +334,14 @@ func (p *logPrinter) printLiveWorkflowLogs(workflowName string, wfClient workflo
 	}
 }
 
+// printsNode returns whether the logs of the node are printed
+func (p *logPrinter) printsNode(node v1alpha1.NodeStatus) bool {
+	if node.Type != v1alpha1.NodeTypePod || node.Phase == v1alpha1.NodeError {
+		return false
+	}
+	return p.nodeSelector == nil || util.SelectorMatchesNode(p.nodeSelector, node)
+}
+
 func getDisplayName(node v1alpha1.NodeStatus) string {
 	res := node.DisplayName
 	if res == "" {
@@ -265,19 +351,45 @@ func getDisplayName(node v1alpha1.NodeStatus) string {
 }
 
 func (p *logPrinter) printLogEntry(entry logEntry) {
+	if p.grep != nil && !p.grep.MatchString(entry.line) {
+		return
+	}
+	if p.output == "json" {
+		record := logRecord{
+			Workflow:  entry.workflow,
+			Node:      entry.displayName,
+			Pod:       entry.pod,
+			Container: entry.container,
+			Line:      entry.line,
+		}
+		if !entry.time.IsZero() {
+			record.Time = &entry.time
+		}
+		recordBytes, err := json.Marshal(record)
+		errors.CheckError(err)
+		_, _ = fmt.Fprintln(p.out, string(recordBytes))
+		return
+	}
 	line := entry.line
 	if p.timestamps {
 		line = entry.time.Format(time.RFC3339) + "	" + line
 	}
-	if entry.displayName != "" {
+	prefix := entry.displayName
+	if p.container == allContainers {
+		if prefix != "" {
+			prefix += "/"
+		}
+		prefix += entry.container
+	}
+	if prefix != "" {
 		colors := []int{FgRed, FgGreen, FgYellow, FgBlue, FgMagenta, FgCyan, FgWhite, FgDefault}
 		h := fnv.New32a()
 		_, err := h.Write([]byte(entry.displayName))
 		errors.CheckError(err)
 		colorIndex := int(math.Mod(float64(h.Sum32()), float64(len(colors))))
-		line = ansiFormat(entry.displayName, colors[colorIndex]) + ":	" + line
+		line = ansiFormat(prefix, colors[colorIndex]) + ":	" + line
 	}
-	fmt.Println(line)
+	_, _ = fmt.Fprintln(p.out, line)
 }
 
 func (p *logPrinter) hasContainerStarted(podName string, podNamespace string, container string) (bool, error) {
@@ -286,7 +398,7 @@ func (p *logPrinter) hasContainerStarted(podName string, podNamespace string, co
 		return false, err
 	}
 	var containerStatus *v1.ContainerStatus
-	for _, status := range pod.Status.ContainerStatuses {
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
 		if status.Name == container {
 			containerStatus = &status
 			break
@@ -302,6 +414,8 @@ func (p *logPrinter) hasContainerStarted(podName string, podNamespace string, co
 	return true, nil
 }
 
+// getPodLogs gets the logs of the container of a pod, or of all its containers. The logs of multiple
+// containers are merged by time, unless they are followed.
 func (p *logPrinter) getPodLogs(
 	ctx context.Context,
 	displayName string,
@@ -313,8 +427,65 @@ func (p *logPrinter) getPodLogs(
 	sinceTime *metav1.Time,
 	callback func(entry logEntry)) error {
 
+	if p.container != allContainers {
+		return p.getContainerLogs(ctx, displayName, podName, podNamespace, p.container, follow, tail, sinceSeconds, sinceTime, callback)
+	}
+	pod, err := p.kubeClient.CoreV1().Pods(podNamespace).Get(podName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	var containers []string
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		containers = append(containers, container.Name)
+	}
+	logs := make([][]logEntry, len(containers))
+	var firstErr error
+	var wg sync.WaitGroup
+	var mux sync.Mutex
+	for i, container := range containers {
+		wg.Add(1)
+		go func(i int, container string) {
+			defer wg.Done()
+			err := p.getContainerLogs(ctx, displayName, podName, podNamespace, container, follow, tail, sinceSeconds, sinceTime, func(entry logEntry) {
+				if follow {
+					mux.Lock()
+					callback(entry)
+					mux.Unlock()
+				} else {
+					logs[i] = append(logs[i], entry)
+				}
+			})
+			if err != nil {
+				mux.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mux.Unlock()
+			}
+		}(i, container)
+	}
+	wg.Wait()
+	for _, entry := range mergeSorted(logs) {
+		callback(entry)
+	}
+	return firstErr
+}
+
+// getContainerLogs gets the logs of a container of a pod
+func (p *logPrinter) getContainerLogs(
+	ctx context.Context,
+	displayName string,
+	podName string,
+	podNamespace string,
+	container string,
+	follow bool,
+	tail *int64,
+	sinceSeconds *int64,
+	sinceTime *metav1.Time,
+	callback func(entry logEntry)) error {
+
 	for ctx.Err() == nil {
-		hasStarted, err := p.hasContainerStarted(podName, podNamespace, p.container)
+		hasStarted, err := p.hasContainerStarted(podName, podNamespace, container)
 
 		if err != nil {
 			return err
@@ -331,7 +502,7 @@ func (p *logPrinter) getPodLogs(
 	}
 
 	stream, err := p.kubeClient.CoreV1().Pods(podNamespace).GetLogs(podName, &v1.PodLogOptions{
-		Container:    p.container,
+		Container:    container,
 		Follow:       follow,
 		Timestamps:   true,
 		SinceSeconds: sinceSeconds,
@@ -339,9 +510,8 @@ func (p *logPrinter) getPodLogs(
 		TailLines:    tail,
 	}).Stream()
 	if err == nil {
-		scanner := bufio.NewScanner(stream)
-		for scanner.Scan() {
-			line := scanner.Text()
+		defer func() { _ = stream.Close() }()
+		err = readLines(stream, func(line string) bool {
 			parts := strings.Split(line, " ")
 			logTime, err := time.Parse(time.RFC3339, parts[0])
 			if err == nil {
@@ -350,6 +520,7 @@ func (p *logPrinter) getPodLogs(
 					if line != "" {
 						callback(logEntry{
 							pod:         podName,
+							container:   container,
 							displayName: displayName,
 							time:        logTime,
 							line:        line,
@@ -359,15 +530,146 @@ func (p *logPrinter) getPodLogs(
 			} else {
 				callback(logEntry{
 					pod:         podName,
+					container:   container,
 					displayName: displayName,
 					line:        line,
 				})
 			}
-		}
+			return true
+		})
 	}
 	return err
 }
 
+// readLines calls the callback with each line read from r until it returns false. Unlike a bufio.Scanner,
+// it does not limit the length of a line.
+func readLines(r io.Reader, callback func(line string) bool) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" && !callback(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")) {
+			return nil
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// getArchivedLogs reads the logs of the main container of a node from the artifact they were archived to
+func (p *logPrinter) getArchivedLogs(
+	ctx context.Context,
+	displayName string,
+	node v1alpha1.NodeStatus,
+	namespace string,
+	tail *int64,
+	sinceSeconds *int64,
+	sinceTime *metav1.Time,
+	callback func(entry logEntry)) error {
+
+	if p.container != common.MainContainerName && p.container != allContainers {
+		return fmt.Errorf("cannot print the archived logs of pod %s: only the logs of the %s container are archived", node.ID, common.MainContainerName)
+	}
+	var logsArt *v1alpha1.Artifact
+	if node.Outputs != nil {
+		for i, art := range node.Outputs.Artifacts {
+			if art.Name == common.MainLogsArtifactName {
+				logsArt = &node.Outputs.Artifacts[i]
+			}
+		}
+	}
+	if logsArt == nil {
+		return fmt.Errorf("cannot print the archived logs of pod %s: its logs were not archived", node.ID)
+	}
+
+	// Archived log lines have no timestamps, so the time window can only skip nodes as a whole
+	var since time.Time
+	if sinceTime != nil {
+		since = sinceTime.Time
+	} else if sinceSeconds != nil {
+		since = time.Now().Add(-time.Duration(*sinceSeconds) * time.Second)
+	}
+	if !since.IsZero() && !node.FinishedAt.IsZero() && node.FinishedAt.Time.Before(since) {
+		return nil
+	}
+
+	tmpDir, err := ioutil.TempDir("", "argo-logs")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	logsPath := filepath.Join(tmpDir, logsArt.Name)
+	err = copyArtifact(p.kubeClient, namespace, *logsArt, logsPath)
+	if err != nil {
+		return err
+	}
+	logsFile, err := os.Open(logsPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = logsFile.Close() }()
+
+	var lines []string
+	err = readLines(logsFile, func(text string) bool {
+		for _, line := range strings.Split(text, "\r") {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return err
+	}
+	if tail != nil && int64(len(lines)) > *tail {
+		lines = lines[int64(len(lines))-*tail:]
+	}
+	for _, line := range lines {
+		callback(logEntry{
+			pod:         node.ID,
+			container:   common.MainContainerName,
+			displayName: displayName,
+			time:        node.StartedAt.Time,
+			line:        line,
+		})
+	}
+	return nil
+}
+
+// findPodNode returns the workflow name and node of a pod. The workflow is the one of the pod's workflow
+// label if it is known. Otherwise, since the pods of a workflow are named after it, the workflows named
+// like a prefix of the pod name ending before a dash are tried, longest first.
+func findPodNode(wfClient workflowv1.WorkflowInterface, podName string, workflowName string) (string, *v1alpha1.NodeStatus, error) {
+	var candidates []string
+	if workflowName != "" {
+		candidates = append(candidates, workflowName)
+	} else {
+		for i := strings.LastIndex(podName, "-"); i > 0; i = strings.LastIndex(podName[:i], "-") {
+			candidates = append(candidates, podName[:i])
+		}
+	}
+	for _, name := range candidates {
+		wf, err := wfClient.Get(name, metav1.GetOptions{})
+		if apierr.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		err = util.DecompressWorkflow(wf)
+		if err != nil {
+			return "", nil, err
+		}
+		if node, ok := wf.Status.Nodes[podName]; ok {
+			return wf.ObjectMeta.Name, &node, nil
+		}
+	}
+	return "", nil, fmt.Errorf("pod %s belongs to no workflow", podName)
+}
+
 func mergeSorted(logs [][]logEntry) []logEntry {
 	if len(logs) == 0 {
 		return make([]logEntry, 0)
@@ -0,0 +1,243 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes/fake"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	wffake "github.com/argoproj/argo/pkg/client/clientset/versioned/fake"
+	"github.com/argoproj/argo/workflow/common"
+)
+
+// newArchivedLogsNode returns a pod node whose main container logs were archived
+func newArchivedLogsNode(id string, logs string, startedAt time.Time) wfv1.NodeStatus {
+	return wfv1.NodeStatus{
+		ID:          id,
+		Name:        id,
+		DisplayName: id,
+		Type:        wfv1.NodeTypePod,
+		Phase:       wfv1.NodeSucceeded,
+		StartedAt:   metav1.NewTime(startedAt),
+		FinishedAt:  metav1.NewTime(startedAt.Add(time.Minute)),
+		Outputs:     &wfv1.Outputs{Artifacts: []wfv1.Artifact{rawArtifact(common.MainLogsArtifactName, logs)}},
+	}
+}
+
+func getArchivedLines(p *logPrinter, node wfv1.NodeStatus, tail *int64, sinceSeconds *int64) ([]string, error) {
+	var lines []string
+	err := p.getArchivedLogs(context.Background(), "", node, "default", tail, sinceSeconds, nil, func(entry logEntry) {
+		lines = append(lines, entry.line)
+	})
+	return lines, err
+}
+
+// TestReadLines verifies lines longer than the buffer of a bufio.Scanner are read whole
+func TestReadLines(t *testing.T) {
+	long := strings.Repeat("x", 100*1024)
+	var lines []string
+	err := readLines(strings.NewReader("one\r\n"+long+"\nlast"), func(line string) bool {
+		lines = append(lines, line)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", long, "last"}, lines)
+
+	lines = nil
+	err = readLines(strings.NewReader("one\ntwo\n"), func(line string) bool {
+		lines = append(lines, line)
+		return false
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one"}, lines)
+}
+
+// TestGetArchivedLogs verifies the archived logs are read, with --tail and --since applied
+func TestGetArchivedLogs(t *testing.T) {
+	p := &logPrinter{container: common.MainContainerName, kubeClient: fake.NewSimpleClientset()}
+	node := newArchivedLogsNode("my-wf-1", "one\ntwo\rthree\n\nfour\n", time.Now().Add(-time.Hour))
+
+	lines, err := getArchivedLines(p, node, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "three", "four"}, lines)
+
+	tail := int64(2)
+	lines, err = getArchivedLines(p, node, &tail, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"three", "four"}, lines)
+
+	// the node finished before the time window
+	since := int64(60)
+	lines, err = getArchivedLines(p, node, nil, &since)
+	assert.NoError(t, err)
+	assert.Empty(t, lines)
+
+	since = int64(3600 * 2)
+	lines, err = getArchivedLines(p, node, nil, &since)
+	assert.NoError(t, err)
+	assert.Len(t, lines, 4)
+
+	p.container = common.WaitContainerName
+	_, err = getArchivedLines(p, node, nil, nil)
+	assert.Error(t, err)
+
+	p.container = common.MainContainerName
+	node.Outputs = nil
+	_, err = getArchivedLines(p, node, nil, nil)
+	assert.Error(t, err)
+}
+
+// TestPrintRecentWorkflowLogsArchived verifies the archived logs are printed for pods which are gone
+func TestPrintRecentWorkflowLogsArchived(t *testing.T) {
+	now := time.Now()
+	wf := &wfv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-wf", Namespace: "default"},
+		Status: wfv1.WorkflowStatus{Nodes: map[string]wfv1.NodeStatus{
+			"my-wf-1": newArchivedLogsNode("my-wf-1", "one\ntwo\n", now.Add(-2*time.Minute)),
+			"my-wf-2": newArchivedLogsNode("my-wf-2", "three\n", now.Add(-time.Minute)),
+		}},
+	}
+	noColor = true
+	var out bytes.Buffer
+	p := &logPrinter{container: common.MainContainerName, kubeClient: fake.NewSimpleClientset(), out: &out}
+	p.printRecentWorkflowLogs(wf)
+	assert.Equal(t, "my-wf-1:\tone\nmy-wf-1:\ttwo\nmy-wf-2:\tthree\n", out.String())
+
+	out.Reset()
+	tail := int64(2)
+	p.tail = &tail
+	p.printRecentWorkflowLogs(wf)
+	assert.Equal(t, "my-wf-1:\ttwo\nmy-wf-2:\tthree\n", out.String())
+}
+
+// TestFindPodNode verifies the workflow of a pod is found by its label, or by the prefix of the pod name
+func TestFindPodNode(t *testing.T) {
+	wfClient := wffake.NewSimpleClientset(&wfv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-wf", Namespace: "default"},
+		Status: wfv1.WorkflowStatus{Nodes: map[string]wfv1.NodeStatus{
+			"my-wf-123": {ID: "my-wf-123", Name: "my-wf[0].step"},
+		}},
+	}).ArgoprojV1alpha1().Workflows("default")
+
+	wfName, node, err := findPodNode(wfClient, "my-wf-123", "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "my-wf", wfName)
+		assert.Equal(t, "my-wf[0].step", node.Name)
+	}
+	wfName, _, err = findPodNode(wfClient, "my-wf-123", "my-wf")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-wf", wfName)
+
+	_, _, err = findPodNode(wfClient, "my-wf-456", "")
+	assert.Error(t, err)
+	_, _, err = findPodNode(wfClient, "other-123", "")
+	assert.Error(t, err)
+}
+
+// TestPrintRecentWorkflowLogsAllContainers verifies the archived logs of the main container are printed for
+// pods which are gone when the logs of all containers are printed
+func TestPrintRecentWorkflowLogsAllContainers(t *testing.T) {
+	noColor = true
+	wf := &wfv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-wf", Namespace: "default"},
+		Status: wfv1.WorkflowStatus{Nodes: map[string]wfv1.NodeStatus{
+			"my-wf-1": newArchivedLogsNode("my-wf-1", "one\n", time.Now()),
+		}},
+	}
+	var out bytes.Buffer
+	p := &logPrinter{container: allContainers, kubeClient: fake.NewSimpleClientset(), out: &out}
+	p.printRecentWorkflowLogs(wf)
+	assert.Equal(t, "my-wf-1/main:\tone\n", out.String())
+}
+
+// TestPrintRecentWorkflowLogsSelector verifies only the logs of the nodes matching the selector are printed
+func TestPrintRecentWorkflowLogsSelector(t *testing.T) {
+	noColor = true
+	now := time.Now()
+	wf := &wfv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-wf", Namespace: "default"},
+		Status: wfv1.WorkflowStatus{Nodes: map[string]wfv1.NodeStatus{
+			"my-wf-1": newArchivedLogsNode("my-wf-1", "one\n", now.Add(-time.Minute)),
+			"my-wf-2": newArchivedLogsNode("my-wf-2", "two\n", now),
+		}},
+	}
+	var out bytes.Buffer
+	p := &logPrinter{
+		container:    common.MainContainerName,
+		kubeClient:   fake.NewSimpleClientset(),
+		nodeSelector: fields.ParseSelectorOrDie("displayName=my-wf-2"),
+		out:          &out,
+	}
+	p.printRecentWorkflowLogs(wf)
+	assert.Equal(t, "my-wf-2:\ttwo\n", out.String())
+}
+
+func TestPrintsNode(t *testing.T) {
+	pod := wfv1.NodeStatus{ID: "my-wf-1", Name: "my-wf[0].train", DisplayName: "train", TemplateName: "train", Type: wfv1.NodeTypePod, Phase: wfv1.NodeSucceeded}
+	errored := pod
+	errored.Phase = wfv1.NodeError
+	steps := pod
+	steps.Type = wfv1.NodeTypeSteps
+	tests := []struct {
+		name     string
+		selector string
+		node     wfv1.NodeStatus
+		expected bool
+	}{
+		{"pod", "", pod, true},
+		{"errored pod", "", errored, false},
+		{"steps", "", steps, false},
+		{"matching display name", "displayName=train", pod, true},
+		{"other display name", "displayName=eval", pod, false},
+		{"matching template and phase", "templateName=train,phase=Succeeded", pod, true},
+		{"excluded phase", "phase!=Succeeded", pod, false},
+		{"matching selector of a steps node", "displayName=train", steps, false},
+	}
+	for _, test := range tests {
+		p := &logPrinter{}
+		if test.selector != "" {
+			p.nodeSelector = fields.ParseSelectorOrDie(test.selector)
+		}
+		assert.Equal(t, test.expected, p.printsNode(test.node), test.name)
+	}
+}
+
+func TestPrintLogEntry(t *testing.T) {
+	noColor = true
+	logTime := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	entry := logEntry{workflow: "my-wf", displayName: "train", pod: "my-wf-1", container: "main", time: logTime, line: "epoch 1: loss 0.5"}
+	untimed := entry
+	untimed.time = time.Time{}
+	tests := []struct {
+		name     string
+		printer  logPrinter
+		entry    logEntry
+		expected string
+	}{
+		{"text", logPrinter{container: "main"}, entry, "train:\tepoch 1: loss 0.5\n"},
+		{"all containers", logPrinter{container: allContainers}, entry, "train/main:\tepoch 1: loss 0.5\n"},
+		{"timestamps", logPrinter{container: "main", timestamps: true}, entry, "train:\t2019-10-01T12:00:00Z\tepoch 1: loss 0.5\n"},
+		{"matching grep", logPrinter{container: "main", grep: regexp.MustCompile("loss [0-9.]+")}, entry, "train:\tepoch 1: loss 0.5\n"},
+		{"other grep", logPrinter{container: "main", grep: regexp.MustCompile("^accuracy")}, entry, ""},
+		{"json", logPrinter{container: "main", output: "json"}, entry,
+			`{"workflow":"my-wf","node":"train","pod":"my-wf-1","container":"main","time":"2019-10-01T12:00:00Z","line":"epoch 1: loss 0.5"}` + "\n"},
+		{"json without time", logPrinter{container: "main", output: "json"}, untimed,
+			`{"workflow":"my-wf","node":"train","pod":"my-wf-1","container":"main","line":"epoch 1: loss 0.5"}` + "\n"},
+		{"json with grep", logPrinter{container: "main", output: "json", grep: regexp.MustCompile("^accuracy")}, entry, ""},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		p := test.printer
+		p.out = &out
+		p.printLogEntry(test.entry)
+		assert.Equal(t, test.expected, out.String(), test.name)
+	}
+}
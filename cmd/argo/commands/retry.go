@@ -13,10 +13,19 @@ import (
 func NewRetryCommand() *cobra.Command {
 	var (
 		cliSubmitOpts cliSubmitOpts
+		retryOpts     util.RetryOpts
 	)
 	var command = &cobra.Command{
 		Use:   "retry WORKFLOW",
 		Short: "retry a workflow",
+		Example: `# Retry the failed nodes of a workflow:
+  argo retry my-wf
+
+# Retry the node "train", its children and the nodes run after it, even if they succeeded:
+  argo retry my-wf --node-field-selector displayName=train --restart-successful
+
+# Retry a workflow with a different value of a global parameter:
+  argo retry my-wf -p epochs=10`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
 				cmd.HelpFunc()(cmd, args)
@@ -28,7 +37,7 @@ func NewRetryCommand() *cobra.Command {
 			if err != nil {
 				log.Fatal(err)
 			}
-			wf, err = util.RetryWorkflow(kubeClient, wfClient, wf)
+			wf, err = util.RetryWorkflow(kubeClient, wfClient, wf, &retryOpts)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -39,5 +48,8 @@ func NewRetryCommand() *cobra.Command {
 	command.Flags().StringVarP(&cliSubmitOpts.output, "output", "o", "", "Output format. One of: name|json|yaml|wide")
 	command.Flags().BoolVarP(&cliSubmitOpts.wait, "wait", "w", false, "wait for the workflow to complete")
 	command.Flags().BoolVar(&cliSubmitOpts.watch, "watch", false, "watch the workflow until it completes")
+	command.Flags().StringVar(&retryOpts.NodeFieldSelector, "node-field-selector", "", "selector of nodes to retry along with the nodes run within or after them, eg: --node-field-selector displayName=train. Supports id, name, displayName, templateName, phase and type")
+	command.Flags().BoolVar(&retryOpts.RestartSuccessful, "restart-successful", false, "also retry the successful nodes matching the --node-field-selector")
+	command.Flags().StringArrayVarP(&retryOpts.Parameters, "parameter", "p", []string{}, "override a global parameter of the workflow")
 	return command
 }
@@ -25,6 +25,8 @@ func NewCommand() *cobra.Command {
 	}
 
 	command.AddCommand(NewCompletionCommand())
+	command.AddCommand(NewConfigCommand())
+	command.AddCommand(NewCpCommand())
 	command.AddCommand(NewDeleteCommand())
 	command.AddCommand(NewGetCommand())
 	command.AddCommand(NewLintCommand())
@@ -33,11 +35,13 @@ func NewCommand() *cobra.Command {
 	command.AddCommand(NewResubmitCommand())
 	command.AddCommand(NewResumeCommand())
 	command.AddCommand(NewRetryCommand())
+	command.AddCommand(NewStopCommand())
 	command.AddCommand(NewSubmitCommand())
 	command.AddCommand(NewSuspendCommand())
 	command.AddCommand(NewWaitCommand())
 	command.AddCommand(NewWatchCommand())
 	command.AddCommand(NewTerminateCommand())
+	command.AddCommand(NewTuiCommand())
 	command.AddCommand(cmd.NewVersionCmd(CLIName))
 	command.AddCommand(template.NewTemplateCommand())
 
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+
+	"github.com/argoproj/argo/workflow/common"
+	"github.com/argoproj/argo/workflow/util"
+)
+
+// NewStopCommand returns a new instance of an `argo stop` command
+func NewStopCommand() *cobra.Command {
+	var (
+		selector string
+	)
+	var command = &cobra.Command{
+		Use:   "stop WORKFLOW WORKFLOW2...",
+		Short: "stop a workflow, still running its exit handler",
+		Long: `Stop a workflow. Its running pods are signaled to terminate and no new steps are scheduled,
+but unlike terminate, the exit handler of the workflow is still run.`,
+		Example: `# Stop a workflow:
+  argo stop my-wf
+
+# Stop all running workflows with a label:
+  argo stop -l app=my-app`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 && selector == "" {
+				cmd.HelpFunc()(cmd, args)
+				os.Exit(1)
+			}
+			InitWorkflowClient()
+			names := args
+			if selector != "" {
+				names = append(names, listRunningWorkflows(selector)...)
+			}
+			stoppedBy := currentUser()
+			for _, name := range names {
+				err := util.StopWorkflow(wfClient, name, stoppedBy)
+				if err != nil {
+					log.Fatalf("Failed to stop %s: %v", name, err)
+				}
+				fmt.Printf("Workflow '%s' stopped\n", name)
+			}
+		},
+	}
+	command.Flags().StringVarP(&selector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='")
+	return command
+}
+
+// listRunningWorkflows returns the names of the workflows matching the selector which are not completed
+func listRunningWorkflows(selector string) []string {
+	labelSelector, err := labels.Parse(selector)
+	if err != nil {
+		log.Fatal(err)
+	}
+	req, _ := labels.NewRequirement(common.LabelKeyCompleted, selection.NotEquals, []string{"true"})
+	labelSelector = labelSelector.Add(*req)
+	wfList, err := wfClient.List(metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var names []string
+	for _, wf := range wfList.Items {
+		names = append(names, wf.ObjectMeta.Name)
+	}
+	return names
+}
+
+// currentUser returns the user of the current kubeconfig context, falling back to the local user
+func currentUser() string {
+	rawConfig, err := clientConfig.RawConfig()
+	if err == nil {
+		if context, ok := rawConfig.Contexts[rawConfig.CurrentContext]; ok && context.AuthInfo != "" {
+			return context.AuthInfo
+		}
+	}
+	localUser, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return localUser.Username
+}
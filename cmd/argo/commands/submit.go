@@ -29,12 +29,18 @@ func NewSubmitCommand() *cobra.Command {
 		submitOpts    util.SubmitOpts
 		cliSubmitOpts cliSubmitOpts
 		priority      int32
+		from          string
 	)
 	var command = &cobra.Command{
-		Use:   "submit FILE1 FILE2...",
+		Use:   "submit (FILE1 FILE2... | --from KIND/NAME)",
 		Short: "submit a workflow",
+		Example: `# Submit a workflow from a file:
+  argo submit my-wf.yaml
+
+# Submit a workflow which runs a template of a workflow template, with a parameter:
+  argo submit --from workflowtemplate/my-wftmpl --entrypoint main -p message=hello`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
+			if (len(args) == 0) == (from == "") {
 				cmd.HelpFunc()(cmd, args)
 				os.Exit(1)
 			}
@@ -42,9 +48,14 @@ func NewSubmitCommand() *cobra.Command {
 				cliSubmitOpts.priority = &priority
 			}
 
+			if from != "" {
+				SubmitWorkflowFromResource(from, &submitOpts, &cliSubmitOpts)
+				return
+			}
 			SubmitWorkflows(args, &submitOpts, &cliSubmitOpts)
 		},
 	}
+	command.Flags().StringVar(&from, "from", "", "submit a workflow from a resource instead of files, e.g. workflowtemplate/NAME")
 	command.Flags().StringVar(&submitOpts.Name, "name", "", "override metadata.name")
 	command.Flags().StringVar(&submitOpts.GenerateName, "generate-name", "", "override metadata.generateName")
 	command.Flags().StringVar(&submitOpts.Entrypoint, "entrypoint", "", "override entrypoint")
@@ -89,8 +100,59 @@ func SubmitWorkflows(filePaths []string, submitOpts *util.SubmitOpts, cliOpts *c
 		workflows = append(workflows, wfs...)
 	}
 
+	checkSubmitOpts(len(workflows), submitOpts, cliOpts)
+
+	if len(workflows) == 0 {
+		log.Println("No Workflow found in given files")
+		os.Exit(1)
+	}
+
+	var workflowNames []string
+
+	for _, wf := range workflows {
+		wf.Spec.Priority = cliOpts.priority
+		wfClient := defaultWFClient
+		if wf.Namespace != "" {
+			wfClient = InitWorkflowClient(wf.Namespace)
+		} else {
+			// This is here to avoid passing an empty namespace when using --server-dry-run
+			namespace, _, err := clientConfig.Namespace()
+			if err != nil {
+				log.Fatal(err)
+			}
+			wf.Namespace = namespace
+		}
+		created, err := util.SubmitWorkflow(wfClient, wfClientset, namespace, &wf, submitOpts)
+		if err != nil {
+			log.Fatalf("Failed to submit workflow: %v", err)
+		}
+		printWorkflow(created, cliOpts.output, DefaultStatus)
+		workflowNames = append(workflowNames, created.Name)
+	}
+	waitOrWatch(workflowNames, *cliOpts)
+}
+
+// SubmitWorkflowFromResource submits a workflow from a resource, e.g. workflowtemplate/NAME
+func SubmitWorkflowFromResource(resource string, submitOpts *util.SubmitOpts, cliOpts *cliSubmitOpts) {
+	wfClient := InitWorkflowClient()
+	checkSubmitOpts(1, submitOpts, cliOpts)
+	submitOpts.Priority = cliOpts.priority
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		log.Fatal(err)
+	}
+	created, err := util.SubmitWorkflowFromResource(wfClient, wfClientset, namespace, resource, submitOpts)
+	if err != nil {
+		log.Fatalf("Failed to submit workflow: %v", err)
+	}
+	printWorkflow(created, cliOpts.output, DefaultStatus)
+	waitOrWatch([]string{created.Name}, *cliOpts)
+}
+
+// checkSubmitOpts verifies the submit options are compatible, given the number of workflows to submit
+func checkSubmitOpts(count int, submitOpts *util.SubmitOpts, cliOpts *cliSubmitOpts) {
 	if cliOpts.watch {
-		if len(workflows) > 1 {
+		if count > 1 {
 			log.Fatalf("Cannot watch more than one workflow")
 		}
 		if cliOpts.wait {
@@ -138,35 +200,6 @@ func SubmitWorkflows(filePaths []string, submitOpts *util.SubmitOpts, cliOpts *c
 			log.Fatalf("--server-dry-run is not available for server api versions older than v1.12")
 		}
 	}
-
-	if len(workflows) == 0 {
-		log.Println("No Workflow found in given files")
-		os.Exit(1)
-	}
-
-	var workflowNames []string
-
-	for _, wf := range workflows {
-		wf.Spec.Priority = cliOpts.priority
-		wfClient := defaultWFClient
-		if wf.Namespace != "" {
-			wfClient = InitWorkflowClient(wf.Namespace)
-		} else {
-			// This is here to avoid passing an empty namespace when using --server-dry-run
-			namespace, _, err := clientConfig.Namespace()
-			if err != nil {
-				log.Fatal(err)
-			}
-			wf.Namespace = namespace
-		}
-		created, err := util.SubmitWorkflow(wfClient, wfClientset, namespace, &wf, submitOpts)
-		if err != nil {
-			log.Fatalf("Failed to submit workflow: %v", err)
-		}
-		printWorkflow(created, cliOpts.output, DefaultStatus)
-		workflowNames = append(workflowNames, created.Name)
-	}
-	waitOrWatch(workflowNames, *cliOpts)
 }
 
 // Checks whether the server has support for the dry-run option
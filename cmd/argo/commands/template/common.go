@@ -15,7 +15,7 @@ var (
 	restConfig   *rest.Config
 	clientConfig clientcmd.ClientConfig
 	clientset    *kubernetes.Clientset
-	wfClientset  *versioned.Clientset
+	wfClientset  versioned.Interface
 	wftmplClient v1alpha1.WorkflowTemplateInterface
 	namespace    string
 )
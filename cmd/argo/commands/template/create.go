@@ -45,21 +45,7 @@ func CreateWorkflowTemplates(filePaths []string, cliOpts *cliCreateOpts) {
 	}
 	defaultWFTmplClient := InitWorkflowTemplateClient()
 
-	fileContents, err := util.ReadManifest(filePaths...)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	var workflowTemplates []wfv1.WorkflowTemplate
-	for _, body := range fileContents {
-		wftmpls := unmarshalWorkflowTemplates(body, cliOpts.strict)
-		workflowTemplates = append(workflowTemplates, wftmpls...)
-	}
-
-	if len(workflowTemplates) == 0 {
-		log.Println("No WorkflowTemplate found in given files")
-		os.Exit(1)
-	}
+	workflowTemplates := readWorkflowTemplates(filePaths, cliOpts.strict)
 
 	for _, wftmpl := range workflowTemplates {
 		err := validate.ValidateWorkflowTemplate(wfClientset, namespace, &wftmpl)
@@ -78,6 +64,26 @@ func CreateWorkflowTemplates(filePaths []string, cliOpts *cliCreateOpts) {
 	}
 }
 
+// readWorkflowTemplates reads the workflow templates from files, exiting if there are none
+func readWorkflowTemplates(filePaths []string, strict bool) []wfv1.WorkflowTemplate {
+	fileContents, err := util.ReadManifest(filePaths...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var workflowTemplates []wfv1.WorkflowTemplate
+	for _, body := range fileContents {
+		wftmpls := unmarshalWorkflowTemplates(body, strict)
+		workflowTemplates = append(workflowTemplates, wftmpls...)
+	}
+
+	if len(workflowTemplates) == 0 {
+		log.Println("No WorkflowTemplate found in given files")
+		os.Exit(1)
+	}
+	return workflowTemplates
+}
+
 // unmarshalWorkflowTemplates unmarshals the input bytes as either json or yaml
 func unmarshalWorkflowTemplates(wfBytes []byte, strict bool) []wfv1.WorkflowTemplate {
 	var wf wfv1.WorkflowTemplate
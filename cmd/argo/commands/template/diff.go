@@ -0,0 +1,179 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/yaml"
+
+	"github.com/argoproj/argo/pkg/apis/workflow"
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
+)
+
+// lastAppliedAnnotation is the annotation kubectl apply records the applied manifest in
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+func NewDiffCommand() *cobra.Command {
+	var (
+		strict bool
+	)
+	var command = &cobra.Command{
+		Use:   "diff FILE1 FILE2...",
+		Short: "show the changes an update would make to workflow templates",
+		Long: `Show a unified diff of the workflow templates in the cluster against the ones in files, and list the
+running workflows which reference the changed workflow templates. Like diff, it exits with status 1
+if there are changes.`,
+		Example: `# Show the changes to a workflow template:
+  argo template diff my-template.yaml`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.HelpFunc()(cmd, args)
+				os.Exit(1)
+			}
+			InitWorkflowTemplateClient()
+			changed := false
+			for _, wftmpl := range readWorkflowTemplates(args, strict) {
+				wftmplChanged, err := diffWorkflowTemplate(os.Stdout, &wftmpl)
+				if err != nil {
+					log.Fatal(err)
+				}
+				changed = changed || wftmplChanged
+			}
+			if changed {
+				os.Exit(1)
+			}
+		},
+	}
+	command.Flags().BoolVar(&strict, "strict", true, "perform strict workflow validation")
+	return command
+}
+
+// diffWorkflowTemplate prints the diff of a workflow template against the cluster copy, followed by the running
+// workflows referencing it. It returns whether there are changes.
+func diffWorkflowTemplate(out io.Writer, wftmpl *wfv1.WorkflowTemplate) (bool, error) {
+	ns := wftmpl.Namespace
+	if ns == "" {
+		ns = namespace
+	}
+	fromFile := fmt.Sprintf("%s/%s (cluster)", ns, wftmpl.Name)
+	var currentYAML string
+	current, err := workflowTemplateClient(wftmpl.Namespace).Get(wftmpl.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierr.IsNotFound(err) {
+			return false, fmt.Errorf("failed to get workflow template %s: %v", wftmpl.Name, err)
+		}
+		// the workflow template is created by an update
+		current = nil
+		fromFile = "/dev/null"
+	} else {
+		currentYAML = normalizedWorkflowTemplateYAML(current, ns)
+	}
+	newYAML := normalizedWorkflowTemplateYAML(wftmpl, ns)
+	if currentYAML == newYAML {
+		return false, nil
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(currentYAML),
+		B:        difflib.SplitLines(newYAML),
+		FromFile: fromFile,
+		ToFile:   fmt.Sprintf("%s/%s (local)", ns, wftmpl.Name),
+		Context:  3,
+	})
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprint(out, diff)
+	if current != nil {
+		names, err := listWorkflowsReferencingTemplate(ns, wftmpl.Name)
+		if err != nil {
+			return false, err
+		}
+		if len(names) > 0 {
+			fmt.Fprintf(out, "\nRunning workflows referencing workflow template %s:\n", wftmpl.Name)
+			for _, name := range names {
+				fmt.Fprintf(out, "  %s\n", name)
+			}
+		}
+		fmt.Fprintln(out)
+	}
+	return true, nil
+}
+
+// normalizedWorkflowTemplateYAML returns the yaml of the fields of a workflow template which are set by its
+// manifest, leaving out the metadata managed by the cluster
+func normalizedWorkflowTemplateYAML(wftmpl *wfv1.WorkflowTemplate, ns string) string {
+	annotations := make(map[string]string)
+	for key, value := range wftmpl.Annotations {
+		if key != lastAppliedAnnotation {
+			annotations[key] = value
+		}
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	normalized := wfv1.WorkflowTemplate{
+		TypeMeta: metav1.TypeMeta{Kind: workflow.WorkflowTemplateKind, APIVersion: wfv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        wftmpl.Name,
+			Namespace:   ns,
+			Labels:      wftmpl.Labels,
+			Annotations: annotations,
+		},
+		Spec: wftmpl.Spec,
+	}
+	outBytes, err := yaml.Marshal(normalized)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(outBytes)
+}
+
+// listWorkflowsReferencingTemplate returns the names of the running workflows with steps or tasks referencing
+// the workflow template
+func listWorkflowsReferencingTemplate(ns string, name string) ([]string, error) {
+	req, _ := labels.NewRequirement(common.LabelKeyCompleted, selection.NotEquals, []string{"true"})
+	wfList, err := wfClientset.ArgoprojV1alpha1().Workflows(ns).List(metav1.ListOptions{LabelSelector: labels.NewSelector().Add(*req).String()})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, wf := range wfList.Items {
+		if workflowReferencesTemplate(&wf, name) {
+			names = append(names, wf.Name)
+		}
+	}
+	return names, nil
+}
+
+func workflowReferencesTemplate(wf *wfv1.Workflow, name string) bool {
+	for _, tmpl := range wf.Spec.Templates {
+		// e.g. the entrypoint of a workflow submitted from a workflow template
+		if tmpl.TemplateRef != nil && tmpl.TemplateRef.Name == name {
+			return true
+		}
+		for _, group := range tmpl.Steps {
+			for _, step := range group {
+				if step.TemplateRef != nil && step.TemplateRef.Name == name {
+					return true
+				}
+			}
+		}
+		if tmpl.DAG != nil {
+			for _, task := range tmpl.DAG.Tasks {
+				if task.TemplateRef != nil && task.TemplateRef.Name == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
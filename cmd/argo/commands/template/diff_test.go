@@ -0,0 +1,65 @@
+package template
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
+	"github.com/argoproj/argo/workflow/util"
+)
+
+// TestDiffWorkflowTemplate verifies the diff against the cluster copy lists the running workflows referencing it
+func TestDiffWorkflowTemplate(t *testing.T) {
+	current := newTestWorkflowTemplate("default", "library", "hello")
+	current.ResourceVersion = "2"
+	current.Annotations = map[string]string{lastAppliedAnnotation: "{}"}
+	running := &wfv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "running-wf", Namespace: "default"},
+		Spec: wfv1.WorkflowSpec{Templates: []wfv1.Template{{
+			Name: "main",
+			DAG:  &wfv1.DAGTemplate{Tasks: []wfv1.DAGTask{{Name: "print", TemplateRef: &wfv1.TemplateRef{Name: "library", Template: "print"}}}},
+		}}},
+	}
+	completed := running.DeepCopy()
+	completed.Name = "completed-wf"
+	completed.Labels = map[string]string{common.LabelKeyCompleted: "true"}
+	other := &wfv1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: "other-wf", Namespace: "default"}}
+	submitted, err := util.NewWorkflowFromWorkflowTemplate(current, "")
+	assert.NoError(t, err)
+	submitted.Name = "submitted-wf"
+	setTestClients(current, running, completed, other, submitted)
+
+	// the cluster managed metadata is left out
+	var out bytes.Buffer
+	changed, err := diffWorkflowTemplate(&out, newTestWorkflowTemplate("", "library", "hello"))
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Empty(t, out.String())
+
+	changed, err = diffWorkflowTemplate(&out, newTestWorkflowTemplate("", "library", "updated"))
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Contains(t, out.String(), "--- default/library (cluster)\n+++ default/library (local)\n")
+	assert.Contains(t, out.String(), "-      - hello\n+      - updated\n")
+	assert.Contains(t, out.String(), "Running workflows referencing workflow template library:\n")
+	assert.Contains(t, out.String(), "  running-wf\n")
+	assert.Contains(t, out.String(), "  submitted-wf\n")
+	assert.NotContains(t, out.String(), "completed-wf")
+	assert.NotContains(t, out.String(), "other-wf")
+}
+
+// TestDiffWorkflowTemplateNotFound verifies a workflow template which does not exist is diffed against nothing
+func TestDiffWorkflowTemplateNotFound(t *testing.T) {
+	setTestClients()
+	var out bytes.Buffer
+	changed, err := diffWorkflowTemplate(&out, newTestWorkflowTemplate("", "new", "hello"))
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Contains(t, out.String(), "--- /dev/null\n+++ default/new (local)\n")
+	assert.Contains(t, out.String(), "+  name: new\n")
+	assert.NotContains(t, out.String(), "Running workflows")
+}
@@ -19,6 +19,8 @@ func NewTemplateCommand() *cobra.Command {
 	command.AddCommand(NewGetCommand())
 	command.AddCommand(NewListCommand())
 	command.AddCommand(NewCreateCommand())
+	command.AddCommand(NewUpdateCommand())
+	command.AddCommand(NewDiffCommand())
 	command.AddCommand(NewDeleteCommand())
 	command.AddCommand(NewLintCommand())
 
@@ -0,0 +1,120 @@
+package template
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/pkg/client/clientset/versioned/typed/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/validate"
+)
+
+type cliUpdateOpts struct {
+	output string // --output
+	strict bool   // --strict
+}
+
+func NewUpdateCommand() *cobra.Command {
+	var (
+		cliUpdateOpts cliUpdateOpts
+	)
+	var command = &cobra.Command{
+		Use:   "update FILE1 FILE2...",
+		Short: "update a workflow template, or create it if it does not exist",
+		Long: `Update workflow templates in place from files, creating the ones which do not exist yet. The
+workflow templates are validated before any of them is updated.
+
+A workflow template is replaced with the one in the file, overwriting any change made in the cluster since
+it was reviewed, e.g. with argo template diff. To fail instead, set the metadata.resourceVersion of the
+reviewed workflow template in the file, e.g. by editing the output of argo template get -o yaml.`,
+		Example: `# Update a workflow template:
+  argo template update my-template.yaml
+
+# Review the changes to a workflow template before updating it:
+  argo template diff my-template.yaml && argo template update my-template.yaml`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.HelpFunc()(cmd, args)
+				os.Exit(1)
+			}
+
+			UpdateWorkflowTemplates(args, &cliUpdateOpts)
+		},
+	}
+	command.Flags().StringVarP(&cliUpdateOpts.output, "output", "o", "", "Output format. One of: name|json|yaml|wide")
+	command.Flags().BoolVar(&cliUpdateOpts.strict, "strict", true, "perform strict workflow validation")
+	return command
+}
+
+func UpdateWorkflowTemplates(filePaths []string, cliOpts *cliUpdateOpts) {
+	if cliOpts == nil {
+		cliOpts = &cliUpdateOpts{}
+	}
+	InitWorkflowTemplateClient()
+
+	workflowTemplates := readWorkflowTemplates(filePaths, cliOpts.strict)
+
+	err := validateWorkflowTemplates(workflowTemplates)
+	if err != nil {
+		log.Fatalf("Failed to update workflow template: %v", err)
+	}
+	for i := range workflowTemplates {
+		updated, err := updateWorkflowTemplate(&workflowTemplates[i])
+		if err != nil {
+			log.Fatalf("Failed to update workflow template %s: %v", workflowTemplates[i].Name, err)
+		}
+		printWorkflowTemplate(updated, cliOpts.output)
+	}
+}
+
+// validateWorkflowTemplates validates the workflow templates in their namespaces, so that none of them is
+// updated if one is invalid
+func validateWorkflowTemplates(workflowTemplates []wfv1.WorkflowTemplate) error {
+	for i := range workflowTemplates {
+		wftmpl := &workflowTemplates[i]
+		if wftmpl.Name == "" {
+			return fmt.Errorf("a name is required to update a workflow template in place")
+		}
+		ns := wftmpl.Namespace
+		if ns == "" {
+			ns = namespace
+		}
+		err := validate.ValidateWorkflowTemplate(wfClientset, ns, wftmpl)
+		if err != nil {
+			return fmt.Errorf("%s: %v", wftmpl.Name, err)
+		}
+	}
+	return nil
+}
+
+// updateWorkflowTemplate replaces a workflow template, or creates it if it does not exist. Unless the workflow
+// template has a resource version, the resource version of the current one is used, overwriting it whatever
+// its changes.
+func updateWorkflowTemplate(wftmpl *wfv1.WorkflowTemplate) (*wfv1.WorkflowTemplate, error) {
+	wftmplClient := workflowTemplateClient(wftmpl.Namespace)
+	current, err := wftmplClient.Get(wftmpl.Name, metav1.GetOptions{})
+	if apierr.IsNotFound(err) {
+		return wftmplClient.Create(wftmpl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if wftmpl.ResourceVersion == "" {
+		wftmpl.ResourceVersion = current.ResourceVersion
+	}
+	return wftmplClient.Update(wftmpl)
+}
+
+// workflowTemplateClient returns the client of the workflow templates of a namespace, defaulting to the
+// namespace of the session
+func workflowTemplateClient(ns string) v1alpha1.WorkflowTemplateInterface {
+	if ns == "" || ns == namespace {
+		return InitWorkflowTemplateClient()
+	}
+	return wfClientset.ArgoprojV1alpha1().WorkflowTemplates(ns)
+}
@@ -0,0 +1,94 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/pkg/client/clientset/versioned/fake"
+)
+
+// setTestClients replaces the clients of the session with fake ones, in the namespace default
+func setTestClients(objects ...runtime.Object) *fake.Clientset {
+	clientset := fake.NewSimpleClientset(objects...)
+	wfClientset = clientset
+	namespace = "default"
+	wftmplClient = clientset.ArgoprojV1alpha1().WorkflowTemplates(namespace)
+	return clientset
+}
+
+// newTestWorkflowTemplate returns a workflow template with a template printing a message
+func newTestWorkflowTemplate(ns string, name string, message string) *wfv1.WorkflowTemplate {
+	return &wfv1.WorkflowTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec: wfv1.WorkflowTemplateSpec{Templates: []wfv1.Template{{
+			Name:      "print",
+			Container: &apiv1.Container{Image: "alpine:3.7", Command: []string{"echo", message}},
+		}}},
+	}
+}
+
+// newTestWorkflowTemplateRef returns a workflow template with steps referencing a template of another one
+func newTestWorkflowTemplateRef(ns string, name string, ref string) *wfv1.WorkflowTemplate {
+	return &wfv1.WorkflowTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec: wfv1.WorkflowTemplateSpec{Templates: []wfv1.Template{{
+			Name:  "main",
+			Steps: [][]wfv1.WorkflowStep{{{Name: "print", TemplateRef: &wfv1.TemplateRef{Name: ref, Template: "print"}}}},
+		}}},
+	}
+}
+
+// TestValidateWorkflowTemplates verifies workflow templates are validated in their own namespace
+func TestValidateWorkflowTemplates(t *testing.T) {
+	setTestClients(newTestWorkflowTemplate("other", "library", "hello"))
+
+	err := validateWorkflowTemplates([]wfv1.WorkflowTemplate{*newTestWorkflowTemplateRef("other", "pipeline", "library")})
+	assert.NoError(t, err)
+
+	err = validateWorkflowTemplates([]wfv1.WorkflowTemplate{*newTestWorkflowTemplateRef("", "pipeline", "library")})
+	assert.Error(t, err)
+
+	err = validateWorkflowTemplates([]wfv1.WorkflowTemplate{*newTestWorkflowTemplate("", "", "hello")})
+	assert.EqualError(t, err, "a name is required to update a workflow template in place")
+}
+
+// TestUpdateWorkflowTemplate verifies workflow templates are created, or replaced with the resource version of
+// the manifest if it has one
+func TestUpdateWorkflowTemplate(t *testing.T) {
+	current := newTestWorkflowTemplate("default", "library", "hello")
+	current.ResourceVersion = "2"
+	clientset := setTestClients(current)
+	var resourceVersions []string
+	clientset.PrependReactor("update", "workflowtemplates", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := action.(k8stesting.UpdateAction).GetObject().(*wfv1.WorkflowTemplate)
+		resourceVersions = append(resourceVersions, obj.ResourceVersion)
+		return false, nil, nil
+	})
+
+	created, err := updateWorkflowTemplate(newTestWorkflowTemplate("", "new", "hello"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "new", created.Name)
+	}
+
+	updated, err := updateWorkflowTemplate(newTestWorkflowTemplate("", "library", "updated"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "updated", updated.Spec.Templates[0].Container.Command[1])
+	}
+
+	reviewed := newTestWorkflowTemplate("default", "library", "reviewed")
+	reviewed.ResourceVersion = "1"
+	_, err = updateWorkflowTemplate(reviewed)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "1"}, resourceVersions)
+
+	wftmpl, err := wftmplClient.Get("library", metav1.GetOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "reviewed", wftmpl.Spec.Templates[0].Container.Command[1])
+	}
+}
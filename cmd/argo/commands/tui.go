@@ -0,0 +1,564 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/argoproj/pkg/humanize"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/pkg/client/clientset/versioned/typed/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
+	"github.com/argoproj/argo/workflow/util"
+)
+
+// tuiView is a screen of the terminal UI
+type tuiView int
+
+const (
+	tuiViewWorkflows tuiView = iota
+	tuiViewNodes
+	tuiViewLogs
+)
+
+// keys as reported by readKeys
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyEnter     = "enter"
+	keyBack      = "back"
+	keyInterrupt = "interrupt"
+)
+
+const tuiHelp = "↑/↓ select  enter open  esc back  s suspend  r resume  R retry  t terminate  q quit"
+
+// tuiRow is a line of the workflow list or the node tree, and the workflow or node it selects
+type tuiRow struct {
+	line string
+	id   string
+}
+
+type tui struct {
+	kubeClient kubernetes.Interface
+	wfClient   v1alpha1.WorkflowInterface
+	namespace  string
+	interval   time.Duration
+
+	view     tuiView
+	rows     []tuiRow
+	cursor   int
+	wfName   string
+	wf       *wfv1.Workflow
+	nodeID   string
+	logs     []string
+	message  string
+	confirm  func() string
+	quitting bool
+}
+
+// NewTuiCommand returns a new instance of an `argo tui` command
+func NewTuiCommand() *cobra.Command {
+	var (
+		interval time.Duration
+	)
+	var command = &cobra.Command{
+		Use:   "tui [WORKFLOW]",
+		Short: "interactive terminal UI for the workflows of a namespace",
+		Long: `Open an interactive terminal UI listing the workflows of the namespace. Selecting a workflow shows
+its node tree, and selecting a pod node shows its live logs. The selected workflow can be
+suspended, resumed, retried and terminated from any view.`,
+		Example: `# Browse the workflows of the current namespace:
+  argo tui
+
+# Open the node tree of a workflow:
+  argo tui my-wf`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 1 {
+				cmd.HelpFunc()(cmd, args)
+				os.Exit(1)
+			}
+			wfName := ""
+			if len(args) == 1 {
+				wfName = args[0]
+			}
+			runTui(wfName, interval)
+		},
+	}
+	command.Flags().DurationVar(&interval, "interval", 2*time.Second, "interval at which workflows and logs are refreshed")
+	return command
+}
+
+// runTui runs the terminal UI until it is quit, starting at the node tree of the workflow if one is given
+func runTui(wfName string, interval time.Duration) {
+	kubeClient := initKubeClient()
+	wfClient := InitWorkflowClient()
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		log.Fatal("argo tui requires an interactive terminal")
+	}
+	t := &tui{
+		kubeClient: kubeClient,
+		wfClient:   wfClient,
+		namespace:  namespace,
+		interval:   interval,
+	}
+	if wfName != "" {
+		t.view = tuiViewNodes
+		t.wfName = wfName
+	}
+
+	oldState, err := terminal.MakeRaw(fd)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// switch to the alternate screen and hide the cursor, restoring both on exit
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		_ = terminal.Restore(fd, oldState)
+	}()
+
+	keys := make(chan string)
+	go readKeys(keys)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	t.refresh()
+	for !t.quitting {
+		t.draw()
+		select {
+		case key := <-keys:
+			t.handleKey(key)
+		case <-ticker.C:
+			t.refresh()
+		}
+	}
+}
+
+// readKeys reads key presses from the terminal in raw mode, translating escape sequences to key names
+func readKeys(keys chan<- string) {
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			keys <- keyInterrupt
+			return
+		}
+		switch input := string(buf[:n]); input {
+		case "\x1b[A", "k":
+			keys <- keyUp
+		case "\x1b[B", "j":
+			keys <- keyDown
+		case "\x1b[C", "\r", "\n":
+			keys <- keyEnter
+		case "\x1b[D", "\x1b", "\x7f":
+			keys <- keyBack
+		case "\x03":
+			keys <- keyInterrupt
+		default:
+			keys <- input
+		}
+	}
+}
+
+// handleKey applies a key press to the state of the UI
+func (t *tui) handleKey(key string) {
+	if t.confirm != nil {
+		if key == "y" {
+			t.message = t.confirm()
+		} else {
+			t.message = ""
+		}
+		t.confirm = nil
+		return
+	}
+	t.message = ""
+	switch key {
+	case keyInterrupt, "q":
+		t.quitting = true
+	case keyUp:
+		t.moveCursor(-1)
+	case keyDown:
+		t.moveCursor(1)
+	case keyEnter:
+		t.open()
+	case keyBack:
+		t.back()
+	case "s":
+		t.message = t.act("suspended", func(name string) error {
+			return util.SuspendWorkflow(t.wfClient, name)
+		})
+	case "r":
+		t.message = t.act("resumed", func(name string) error {
+			return util.ResumeWorkflow(t.wfClient, name)
+		})
+	case "R":
+		t.confirmAct("Retry", "retried", func(name string) error {
+			wf, err := t.wfClient.Get(name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			_, err = util.RetryWorkflow(t.kubeClient, t.wfClient, wf, &util.RetryOpts{})
+			return err
+		})
+	case "t":
+		t.confirmAct("Terminate", "terminated", func(name string) error {
+			return util.TerminateWorkflow(t.wfClient, name)
+		})
+	}
+}
+
+// confirmAct asks to confirm an action on the selected workflow, which is run once it is confirmed
+func (t *tui) confirmAct(verb string, done string, action func(name string) error) {
+	name := t.selectedWorkflow()
+	if name == "" {
+		return
+	}
+	t.message = fmt.Sprintf("%s workflow %s? (y/n)", verb, name)
+	t.confirm = func() string {
+		return t.act(done, action)
+	}
+}
+
+// act runs an action on the selected workflow and returns the message reporting its outcome
+func (t *tui) act(done string, action func(name string) error) string {
+	name := t.selectedWorkflow()
+	if name == "" {
+		return ""
+	}
+	err := action(name)
+	t.refresh()
+	if err != nil {
+		return fmt.Sprintf("Failed to update workflow %s: %v", name, err)
+	}
+	return fmt.Sprintf("Workflow %s %s", name, done)
+}
+
+// selectedWorkflow returns the name of the workflow selected in the list, or of the workflow being viewed
+func (t *tui) selectedWorkflow() string {
+	if t.view != tuiViewWorkflows {
+		return t.wfName
+	}
+	if t.cursor < len(t.rows) {
+		return t.rows[t.cursor].id
+	}
+	return ""
+}
+
+// moveCursor moves the cursor by delta rows, skipping the rows which select nothing
+func (t *tui) moveCursor(delta int) {
+	for i := t.cursor + delta; i >= 0 && i < len(t.rows); i += delta {
+		if t.rows[i].id != "" {
+			t.cursor = i
+			return
+		}
+	}
+}
+
+// resetCursor moves the cursor to the first selectable row
+func (t *tui) resetCursor() {
+	t.cursor = 0
+	if len(t.rows) > 0 && t.rows[0].id == "" {
+		t.moveCursor(1)
+	}
+}
+
+func (t *tui) open() {
+	if t.cursor >= len(t.rows) || t.rows[t.cursor].id == "" {
+		return
+	}
+	switch t.view {
+	case tuiViewWorkflows:
+		t.view = tuiViewNodes
+		t.wfName = t.rows[t.cursor].id
+		t.rows = nil
+		t.refresh()
+		t.resetCursor()
+	case tuiViewNodes:
+		node, ok := t.wf.Status.Nodes[t.rows[t.cursor].id]
+		if !ok || node.Type != wfv1.NodeTypePod {
+			t.message = "Logs are only available for pod nodes"
+			return
+		}
+		t.view = tuiViewLogs
+		t.nodeID = node.ID
+		t.logs = nil
+		t.refresh()
+	}
+}
+
+func (t *tui) back() {
+	switch t.view {
+	case tuiViewNodes:
+		t.view = tuiViewWorkflows
+		t.wf = nil
+		t.rows = nil
+		t.refresh()
+		t.cursor = 0
+		for i, row := range t.rows {
+			if row.id == t.wfName {
+				t.cursor = i
+			}
+		}
+	case tuiViewLogs:
+		t.view = tuiViewNodes
+		t.logs = nil
+	}
+}
+
+// refresh reloads the data of the current view
+func (t *tui) refresh() {
+	var err error
+	switch t.view {
+	case tuiViewWorkflows:
+		err = t.refreshWorkflows()
+	case tuiViewNodes:
+		err = t.refreshWorkflow()
+	case tuiViewLogs:
+		err = t.refreshWorkflow()
+		if err == nil {
+			err = t.refreshLogs()
+		}
+	}
+	if err != nil {
+		t.message = err.Error()
+	}
+}
+
+func (t *tui) refreshWorkflows() error {
+	wfList, err := t.wfClient.List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	workflows := wfList.Items
+	sort.Sort(ByFinishedAt(workflows))
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 3, ' ', 0)
+	fmt.Fprint(w, "NAME\tSTATUS\tAGE\tDURATION\n")
+	for _, wf := range workflows {
+		ageStr := humanize.RelativeDurationShort(wf.ObjectMeta.CreationTimestamp.Time, time.Now())
+		durationStr := humanize.RelativeDurationShort(wf.Status.StartedAt.Time, wf.Status.FinishedAt.Time)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", wf.ObjectMeta.Name, workflowStatus(&wf), ageStr, durationStr)
+	}
+	_ = w.Flush()
+	ids := []string{""}
+	for _, wf := range workflows {
+		ids = append(ids, wf.ObjectMeta.Name)
+	}
+	t.setRows(buf.String(), ids)
+	return nil
+}
+
+func (t *tui) refreshWorkflow() error {
+	wf, err := t.wfClient.Get(t.wfName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	err = util.DecompressWorkflow(wf)
+	if err != nil {
+		return err
+	}
+	t.wf = wf
+	if t.view != tuiViewNodes {
+		return nil
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	// apply a dummy FgDefault format to align tabwriter with the rest of the columns
+	fmt.Fprintf(w, "%s\tPODNAME\tDURATION\tMESSAGE\n", ansiFormat("STEP", FgDefault))
+	ids := []string{""}
+	roots := convertToRenderTrees(wf)
+	if mainRoot, ok := roots[wf.ObjectMeta.Name]; ok {
+		mainRoot.renderNodes(w, wf, 0, " ", " ", getFlags{})
+		ids = append(ids, renderedNodeIDs(wf, mainRoot)...)
+	}
+	onExitID := wf.NodeID(wf.ObjectMeta.Name + "." + onExitSuffix)
+	if onExitRoot, ok := roots[onExitID]; ok {
+		fmt.Fprintf(w, "\t\t\t\t\t\n")
+		onExitRoot.renderNodes(w, wf, 0, " ", " ", getFlags{})
+		ids = append(ids, "")
+		ids = append(ids, renderedNodeIDs(wf, onExitRoot)...)
+	}
+	_ = w.Flush()
+	t.setRows(buf.String(), ids)
+	return nil
+}
+
+// refreshLogs reloads the last lines of the main container logs of the selected node, reading them from
+// the archived logs once its pod is gone
+func (t *tui) refreshLogs() error {
+	node, ok := t.wf.Status.Nodes[t.nodeID]
+	if !ok {
+		return fmt.Errorf("node %s not found", t.nodeID)
+	}
+	_, height := t.size()
+	tail := int64(height)
+	p := logPrinter{container: common.MainContainerName, kubeClient: t.kubeClient}
+	var logs []string
+	callback := func(entry logEntry) {
+		logs = append(logs, entry.line)
+	}
+	err := p.getPodLogs(context.Background(), "", node.ID, t.namespace, false, &tail, nil, nil, callback)
+	if apierr.IsNotFound(err) {
+		logs = nil
+		err = p.getArchivedLogs(context.Background(), "", node, t.namespace, &tail, nil, nil, callback)
+	}
+	if err != nil {
+		return err
+	}
+	t.logs = logs
+	return nil
+}
+
+// setRows replaces the rows of the current view, keeping the cursor on the same selection
+func (t *tui) setRows(text string, ids []string) {
+	selected := ""
+	if t.cursor < len(t.rows) {
+		selected = t.rows[t.cursor].id
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	t.rows = make([]tuiRow, len(lines))
+	for i, line := range lines {
+		t.rows[i].line = line
+		if i < len(ids) {
+			t.rows[i].id = ids[i]
+		}
+	}
+	for i, row := range t.rows {
+		if selected != "" && row.id == selected {
+			t.cursor = i
+			return
+		}
+	}
+	if t.cursor >= len(t.rows) || t.rows[t.cursor].id == "" {
+		t.resetCursor()
+	}
+}
+
+// renderedNodeIDs returns the IDs of the nodes of a render tree in the order renderNodes prints them
+func renderedNodeIDs(wf *wfv1.Workflow, n renderNode) []string {
+	var ids []string
+	if filtered, _ := filterNode(n.getNodeStatus(wf)); !filtered {
+		ids = append(ids, n.getID())
+	}
+	var children []renderNode
+	switch n := n.(type) {
+	case *boundaryNode:
+		children = n.boundaryContained
+	case *nonBoundaryParentNode:
+		children = n.children
+	}
+	for _, child := range children {
+		ids = append(ids, renderedNodeIDs(wf, child)...)
+	}
+	return ids
+}
+
+func (t *tui) size() (int, int) {
+	width, height, err := terminal.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 80, 24
+	}
+	return width, height
+}
+
+// draw redraws the whole screen
+func (t *tui) draw() {
+	width, height := t.size()
+	var header []string
+	var body []string
+	switch t.view {
+	case tuiViewWorkflows:
+		header = []string{fmt.Sprintf("Workflows in namespace %s", t.namespace)}
+	case tuiViewNodes, tuiViewLogs:
+		header = []string{fmt.Sprintf("Workflow %s", t.wfName)}
+		if t.wf != nil {
+			header[0] = fmt.Sprintf("Workflow %s: %s", t.wfName, workflowStatus(t.wf))
+			if t.wf.Status.Message != "" {
+				header = append(header, t.wf.Status.Message)
+			}
+		}
+	}
+	if t.view == tuiViewLogs && t.wf != nil {
+		header = append(header, fmt.Sprintf("Logs of %s (%s)", getDisplayName(t.wf.Status.Nodes[t.nodeID]), t.nodeID))
+	}
+	header = append(header, "")
+	footer := []string{"", t.message, tuiHelp}
+	rows := height - len(header) - len(footer)
+	if rows < 1 {
+		rows = 1
+	}
+
+	if t.view == tuiViewLogs {
+		body = t.logs
+		if len(body) > rows {
+			body = body[len(body)-rows:]
+		}
+	} else {
+		// scroll the rows to keep the cursor visible
+		offset := 0
+		if t.cursor >= rows {
+			offset = t.cursor - rows + 1
+		}
+		for i := offset; i < len(t.rows) && i < offset+rows; i++ {
+			prefix := "  "
+			if i == t.cursor && t.rows[i].id != "" {
+				prefix = ansiFormat("▶", Bold) + " "
+			}
+			body = append(body, prefix+t.rows[i].line)
+		}
+	}
+	for len(body) < rows {
+		body = append(body, "")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[H")
+	for i, line := range append(append(header, body...), footer...) {
+		if i > 0 {
+			buf.WriteString("\r\n")
+		}
+		buf.WriteString(truncateVisible(line, width))
+		// clear the rest of the line
+		buf.WriteString("\x1b[K")
+	}
+	fmt.Print(buf.String())
+}
+
+// truncateVisible truncates a line to a number of visible characters, keeping its ANSI escape sequences
+func truncateVisible(line string, width int) string {
+	var buf strings.Builder
+	visible := 0
+	inEscape := false
+	for _, r := range line {
+		switch {
+		case inEscape:
+			buf.WriteRune(r)
+			if r >= '@' && r <= '~' && r != '[' {
+				inEscape = false
+			}
+		case r == '\x1b':
+			buf.WriteRune(r)
+			inEscape = true
+		case visible < width:
+			if r == '\t' {
+				// tabs would be expanded by the terminal beyond the visible width
+				r = ' '
+			}
+			buf.WriteRune(r)
+			visible++
+		}
+	}
+	return buf.String()
+}
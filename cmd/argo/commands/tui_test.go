@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	wffake "github.com/argoproj/argo/pkg/client/clientset/versioned/fake"
+)
+
+// newTuiTestWorkflow returns a workflow of two parallel steps and an exit handler
+func newTuiTestWorkflow(name string) *wfv1.Workflow {
+	wf := &wfv1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.Now()}}
+	started := metav1.NewTime(time.Now().Add(-time.Minute))
+	onExitID := wf.NodeID(name + "." + onExitSuffix)
+	wf.Status = wfv1.WorkflowStatus{
+		Phase:     wfv1.NodeRunning,
+		StartedAt: started,
+		Nodes: map[string]wfv1.NodeStatus{
+			name:            {ID: name, Name: name, DisplayName: name, Type: wfv1.NodeTypeSteps, Phase: wfv1.NodeRunning, StartedAt: started, Children: []string{name + "-g0"}},
+			name + "-g0":    {ID: name + "-g0", Name: name + "[0]", DisplayName: "[0]", Type: wfv1.NodeTypeStepGroup, Phase: wfv1.NodeRunning, StartedAt: started, BoundaryID: name, Children: []string{name + "-alpha", name + "-beta"}},
+			name + "-alpha": {ID: name + "-alpha", Name: name + "[0].alpha", DisplayName: "alpha", Type: wfv1.NodeTypePod, Phase: wfv1.NodeRunning, StartedAt: started, BoundaryID: name},
+			name + "-beta":  {ID: name + "-beta", Name: name + "[0].beta", DisplayName: "beta", Type: wfv1.NodeTypePod, Phase: wfv1.NodeRunning, StartedAt: started, BoundaryID: name},
+			onExitID:        {ID: onExitID, Name: name + "." + onExitSuffix, DisplayName: name + "." + onExitSuffix, Type: wfv1.NodeTypePod, Phase: wfv1.NodePending, StartedAt: started},
+		},
+	}
+	return wf
+}
+
+func newTestTui(workflows ...*wfv1.Workflow) *tui {
+	var objects []runtime.Object
+	for _, wf := range workflows {
+		objects = append(objects, wf)
+	}
+	return &tui{
+		kubeClient: fake.NewSimpleClientset(),
+		wfClient:   wffake.NewSimpleClientset(objects...).ArgoprojV1alpha1().Workflows("default"),
+		namespace:  "default",
+	}
+}
+
+// TestRenderedNodeIDs verifies each row of the node tree selects the node printed on it
+func TestRenderedNodeIDs(t *testing.T) {
+	noColor = true
+	wf := newTuiTestWorkflow("my-wf")
+	tui := newTestTui(wf)
+	tui.view = tuiViewNodes
+	tui.wfName = "my-wf"
+	assert.NoError(t, tui.refreshWorkflow())
+
+	var selectable []string
+	for _, row := range tui.rows {
+		if row.id == "" {
+			continue
+		}
+		node := wf.Status.Nodes[row.id]
+		assert.Contains(t, row.line, node.DisplayName, row.id)
+		selectable = append(selectable, row.id)
+	}
+	// the step group is not printed
+	assert.Equal(t, []string{"my-wf", "my-wf-alpha", "my-wf-beta", wf.NodeID("my-wf." + onExitSuffix)}, selectable)
+	assert.Equal(t, 1, tui.cursor)
+}
+
+func TestTruncateVisible(t *testing.T) {
+	tests := []struct {
+		line     string
+		width    int
+		expected string
+	}{
+		{"hello", 10, "hello"},
+		{"hello world", 5, "hello"},
+		{"a\tb", 3, "a b"},
+		{"héllo", 2, "hé"},
+		{"\x1b[1mbold\x1b[0m text", 2, "\x1b[1mbo\x1b[0m"},
+		{"\x1b[1mbold\x1b[0m text", 6, "\x1b[1mbold\x1b[0m t"},
+		{"", 5, ""},
+		{"hello", 0, ""},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, truncateVisible(test.line, test.width), test.line)
+	}
+}
+
+// TestSetRows verifies the cursor stays on the same selection when the rows change
+func TestSetRows(t *testing.T) {
+	tui := &tui{}
+	tui.setRows("HEADER\na\nb\nc\n", []string{"", "a", "b", "c"})
+	assert.Len(t, tui.rows, 4)
+	assert.Equal(t, 1, tui.cursor)
+
+	tui.cursor = 2
+	tui.setRows("HEADER\nnew\na\nb\nc\n", []string{"", "new", "a", "b", "c"})
+	assert.Equal(t, 3, tui.cursor)
+	assert.Equal(t, "b", tui.rows[tui.cursor].line)
+
+	// the selection is gone, so the cursor stays in place
+	tui.setRows("HEADER\nnew\na\nc\n", []string{"", "new", "a", "c"})
+	assert.Equal(t, 3, tui.cursor)
+
+	// the cursor is beyond the rows
+	tui.setRows("HEADER\nnew\n", []string{"", "new"})
+	assert.Equal(t, 1, tui.cursor)
+
+	tui.setRows("HEADER\n", []string{""})
+	assert.Equal(t, 0, tui.cursor)
+}
+
+// TestMoveCursor verifies the cursor skips the rows which select nothing and stops at the ends
+func TestMoveCursor(t *testing.T) {
+	tui := &tui{}
+	tui.setRows("HEADER\na\n\nb\n", []string{"", "a", "", "b"})
+	assert.Equal(t, 1, tui.cursor)
+	tui.moveCursor(-1)
+	assert.Equal(t, 1, tui.cursor)
+	tui.moveCursor(1)
+	assert.Equal(t, 3, tui.cursor)
+	tui.moveCursor(1)
+	assert.Equal(t, 3, tui.cursor)
+	tui.moveCursor(-1)
+	assert.Equal(t, 1, tui.cursor)
+}
+
+// TestHandleKey verifies navigating between the views and acting on the selected workflow
+func TestHandleKey(t *testing.T) {
+	noColor = true
+	tui := newTestTui(newTuiTestWorkflow("my-wf"), newTuiTestWorkflow("other-wf"))
+	tui.refresh()
+	assert.Equal(t, tuiViewWorkflows, tui.view)
+	assert.Len(t, tui.rows, 3)
+	first := tui.selectedWorkflow()
+	assert.NotEmpty(t, first)
+
+	tui.handleKey(keyDown)
+	second := tui.selectedWorkflow()
+	assert.NotEqual(t, first, second)
+	tui.handleKey(keyUp)
+	assert.Equal(t, first, tui.selectedWorkflow())
+
+	tui.handleKey(keyEnter)
+	assert.Equal(t, tuiViewNodes, tui.view)
+	assert.Equal(t, first, tui.wfName)
+	assert.Equal(t, first, tui.rows[tui.cursor].id)
+
+	// the logs of a steps node are not available
+	tui.handleKey(keyEnter)
+	assert.Equal(t, tuiViewNodes, tui.view)
+	assert.Equal(t, "Logs are only available for pod nodes", tui.message)
+
+	tui.handleKey(keyBack)
+	assert.Equal(t, tuiViewWorkflows, tui.view)
+	assert.Equal(t, first, tui.selectedWorkflow())
+
+	tui.handleKey("s")
+	assert.Equal(t, "Workflow "+first+" suspended", tui.message)
+	wf, err := tui.wfClient.Get(first, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.True(t, *wf.Spec.Suspend)
+
+	tui.handleKey("q")
+	assert.True(t, tui.quitting)
+}
+
+// TestHandleKeyConfirm verifies terminating and retrying a workflow are only done once confirmed
+func TestHandleKeyConfirm(t *testing.T) {
+	tui := newTestTui(newTuiTestWorkflow("my-wf"))
+	tui.view = tuiViewNodes
+	tui.wfName = "my-wf"
+	tui.refresh()
+
+	for _, key := range []string{"t", "R"} {
+		tui.handleKey(key)
+		assert.NotNil(t, tui.confirm, key)
+		assert.Contains(t, tui.message, "workflow my-wf? (y/n)", key)
+		tui.handleKey("n")
+		assert.Nil(t, tui.confirm, key)
+		assert.Empty(t, tui.message, key)
+	}
+	wf, err := tui.wfClient.Get("my-wf", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, wf.Spec.ActiveDeadlineSeconds)
+
+	tui.handleKey("t")
+	assert.Equal(t, "Terminate workflow my-wf? (y/n)", tui.message)
+	tui.handleKey("y")
+	assert.Equal(t, "Workflow my-wf terminated", tui.message)
+	wf, err = tui.wfClient.Get("my-wf", metav1.GetOptions{})
+	assert.NoError(t, err)
+	if assert.NotNil(t, wf.Spec.ActiveDeadlineSeconds) {
+		assert.Equal(t, int64(0), *wf.Spec.ActiveDeadlineSeconds)
+	}
+
+	tui.handleKey("R")
+	assert.Equal(t, "Retry workflow my-wf? (y/n)", tui.message)
+}
@@ -15,6 +15,9 @@ import (
 )
 
 func NewWatchCommand() *cobra.Command {
+	var (
+		interactive bool
+	)
 	var command = &cobra.Command{
 		Use:   "watch WORKFLOW",
 		Short: "watch a workflow until it completes",
@@ -24,9 +27,14 @@ func NewWatchCommand() *cobra.Command {
 				os.Exit(1)
 			}
 			InitWorkflowClient()
+			if interactive {
+				runTui(args[0], 2*time.Second)
+				return
+			}
 			watchWorkflow(args[0])
 		},
 	}
+	command.Flags().BoolVarP(&interactive, "interactive", "i", false, "watch the workflow in the interactive terminal UI of argo tui")
 	return command
 }
 
@@ -0,0 +1,31 @@
+package commands
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewDataCommand() *cobra.Command {
+	var command = cobra.Command{
+		Use:   "data",
+		Short: "list and transform the source of a data template",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := execData()
+			if err != nil {
+				log.Fatalf("%+v", err)
+			}
+		},
+	}
+	return &command
+}
+
+func execData() error {
+	wfExecutor := initExecutor()
+	defer wfExecutor.HandleError()
+	err := wfExecutor.Data()
+	if err != nil {
+		wfExecutor.AddError(err)
+		return err
+	}
+	return nil
+}
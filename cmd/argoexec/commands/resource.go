@@ -11,7 +11,7 @@ import (
 
 func NewResourceCommand() *cobra.Command {
 	var command = cobra.Command{
-		Use:   "resource (get|create|apply|delete) MANIFEST",
+		Use:   "resource (get|create|apply|delete|replace|patch) MANIFEST",
 		Short: "update a resource and wait for resource conditions",
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) != 1 {
@@ -41,18 +41,18 @@ func execResource(action string) error {
 		wfExecutor.AddError(err)
 		return err
 	}
-	resourceNamespace, resourceName, err := wfExecutor.ExecResource(action, common.ExecutorResourceManifestPath)
+	obj, err := wfExecutor.ExecResource(action, common.ExecutorResourceManifestPath)
 	if err != nil {
 		wfExecutor.AddError(err)
 		return err
 	}
 	if !isDelete {
-		err = wfExecutor.WaitResource(resourceNamespace, resourceName)
+		err = wfExecutor.WaitResource(obj)
 		if err != nil {
 			wfExecutor.AddError(err)
 			return err
 		}
-		err = wfExecutor.SaveResourceParameters(resourceNamespace, resourceName)
+		err = wfExecutor.SaveResourceParameters(obj)
 		if err != nil {
 			wfExecutor.AddError(err)
 			return err
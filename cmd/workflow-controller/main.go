@@ -4,16 +4,22 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/argoproj/pkg/cli"
 	kubecli "github.com/argoproj/pkg/kube/cli"
 	"github.com/argoproj/pkg/stats"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/azure"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
 	wfclientset "github.com/argoproj/argo/pkg/client/clientset/versioned"
@@ -37,6 +43,13 @@ func NewRootCommand() *cobra.Command {
 		glogLevel               int    // --gloglevel
 		workflowWorkers         int    // --workflow-workers
 		podWorkers              int    // --pod-workers
+		leaderElect             bool   // --leader-elect
+		leaderElection          controller.LeaderElectionConfig
+		shards                  int           // --shards
+		shard                   int           // --shard
+		shutdownTimeout         time.Duration // --shutdown-timeout
+		qps                     float32       // --qps
+		burst                   int           // --burst
 	)
 
 	var command = cobra.Command{
@@ -52,16 +65,16 @@ func NewRootCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			config.Burst = 30
-			config.QPS = 20.0
+			config.Burst = burst
+			config.QPS = qps
 
 			namespace, _, err := clientConfig.Namespace()
 			if err != nil {
 				return err
 			}
 
-			kubeclientset := kubernetes.NewForConfigOrDie(config)
-			wflientset := wfclientset.NewForConfigOrDie(config)
+			kubeclientset := kubernetes.NewForConfigOrDie(withThrottleMetrics(config))
+			wflientset := wfclientset.NewForConfigOrDie(withThrottleMetrics(config))
 
 			// start a controller on instances of our custom resource
 			wfController := controller.NewWorkflowController(config, kubeclientset, wflientset, namespace, executorImage, executorImagePullPolicy, configMap)
@@ -69,16 +82,44 @@ func NewRootCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if shards > 1 {
+				if shard < 0 {
+					shard, err = hostnameOrdinal()
+					if err != nil {
+						return err
+					}
+				}
+				err = wfController.SetShard(shard, shards)
+				if err != nil {
+					return err
+				}
+				// replicas of the same shard elect a leader among themselves
+				leaderElection.LeaseName = fmt.Sprintf("%s-%d", leaderElection.LeaseName, shard)
+				log.Infof("Operating on shard %d of %d", shard, shards)
+			}
+
+			wfController.SetShutdownTimeout(shutdownTimeout)
 
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			go wfController.Run(ctx, workflowWorkers, podWorkers)
+			// shut down gracefully when the pod is terminated
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+			go func() {
+				sig := <-signals
+				log.Infof("Received %s, shutting down", sig)
+				cancel()
+			}()
+
 			go wfController.MetricsServer(ctx)
 			go wfController.TelemetryServer(ctx)
-			go wfController.RunTTLController(ctx)
-
-			// Wait forever
-			select {}
+			go wfController.HealthServer(ctx)
+			if !leaderElect {
+				go wfController.RunTTLController(ctx)
+				wfController.Run(ctx, workflowWorkers, podWorkers)
+				return nil
+			}
+			return wfController.RunWithLeaderElection(ctx, workflowWorkers, podWorkers, leaderElection)
 
 		},
 	}
@@ -92,9 +133,40 @@ func NewRootCommand() *cobra.Command {
 	command.Flags().IntVar(&glogLevel, "gloglevel", 0, "Set the glog logging level")
 	command.Flags().IntVar(&workflowWorkers, "workflow-workers", 8, "Number of workflow workers")
 	command.Flags().IntVar(&podWorkers, "pod-workers", 8, "Number of pod workers")
+	command.Flags().BoolVar(&leaderElect, "leader-elect", false, "Elect a leader among the controller replicas, so that only the leader operates on workflows. Requires the coordination.k8s.io/v1 Lease API (Kubernetes 1.14+) and permission to get, create and update leases in the controller's namespace")
+	command.Flags().StringVar(&leaderElection.LeaseName, "leader-election-lease-name", "workflow-controller", "Name of the lease used for leader election")
+	command.Flags().DurationVar(&leaderElection.LeaseDuration, "leader-election-lease-duration", 15*time.Second, "Duration followers wait before attempting to acquire an unrenewed lease")
+	command.Flags().DurationVar(&leaderElection.RenewDeadline, "leader-election-renew-deadline", 10*time.Second, "Duration the leader retries renewing the lease before giving up the leadership")
+	command.Flags().DurationVar(&leaderElection.RetryPeriod, "leader-election-retry-period", 2*time.Second, "Duration replicas wait between attempts to acquire or renew the lease")
+	command.Flags().IntVar(&shards, "shards", 1, "Number of shards to split workflows across, each operated on by a separate controller. Growing it only assigns new workflows to the new shards")
+	command.Flags().IntVar(&shard, "shard", -1, "Shard of workflows to operate on, defaults to the ordinal of the hostname, e.g. 2 for workflow-controller-2")
+	command.Flags().Float32Var(&qps, "qps", 20.0, "Queries per second the controller may send to the Kubernetes API server, per client")
+	command.Flags().IntVar(&burst, "burst", 30, "Maximum burst of queries the controller may send to the Kubernetes API server, per client")
+	command.Flags().DurationVar(&shutdownTimeout, "shutdown-timeout", 10*time.Second, "Duration to wait on shutdown for in-flight workflow operations to persist and completed pods to be labeled and deleted. With leader election, it must be less than the lease duration less the retry period")
 	return &command
 }
 
+// withThrottleMetrics returns a copy of the client config whose rate limiter records the requests
+// it delays in the controller metrics
+func withThrottleMetrics(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.RateLimiter = controller.NewThrottleCountingRateLimiter(config.QPS, config.Burst)
+	return config
+}
+
+// hostnameOrdinal returns the ordinal suffix of the hostname, as given to the pods of a StatefulSet
+func hostnameOrdinal() (int, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return 0, err
+	}
+	ordinal, err := strconv.Atoi(hostname[strings.LastIndex(hostname, "-")+1:])
+	if err != nil {
+		return 0, fmt.Errorf("cannot determine the shard from hostname %s, use --shard", hostname)
+	}
+	return ordinal, nil
+}
+
 func main() {
 	if err := NewRootCommand().Execute(); err != nil {
 		fmt.Println(err)
@@ -22,6 +22,8 @@ const (
 	TemplateTypeResource  TemplateType = "Resource"
 	TemplateTypeDAG       TemplateType = "DAG"
 	TemplateTypeSuspend   TemplateType = "Suspend"
+	TemplateTypeHTTP      TemplateType = "HTTP"
+	TemplateTypeData      TemplateType = "Data"
 	TemplateTypeUnknown   TemplateType = "Unknown"
 )
 
@@ -51,6 +53,8 @@ const (
 	NodeTypeRetry     NodeType = "Retry"
 	NodeTypeSkipped   NodeType = "Skipped"
 	NodeTypeSuspend   NodeType = "Suspend"
+	NodeTypeHTTP      NodeType = "HTTP"
+	NodeTypeMap       NodeType = "Map"
 )
 
 // PodGCStrategy is the strategy when to delete completed pods for GC.
@@ -64,6 +68,15 @@ const (
 	PodGCOnWorkflowSuccess    PodGCStrategy = "OnWorkflowSuccess"
 )
 
+// ShutdownStrategy is the strategy used to shut down a running workflow.
+type ShutdownStrategy string
+
+// ShutdownStrategy
+const (
+	// ShutdownStrategyStop signals the running pods and schedules no new nodes, but still runs the exit handler
+	ShutdownStrategyStop ShutdownStrategy = "Stop"
+)
+
 // TemplateGetter is an interface to get templates.
 type TemplateGetter interface {
 	GetNamespace() string
@@ -194,11 +207,19 @@ type WorkflowSpec struct {
 	// ttlSecondsAfterFinished expires immediately after the Workflow finishes.
 	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
 
+	// TTLStrategy limits the lifetime of a Workflow that has finished execution depending on if it
+	// succeeded or failed. It takes precedence over ttlSecondsAfterFinished.
+	TTLStrategy *TTLStrategy `json:"ttlStrategy,omitempty"`
+
 	// Optional duration in seconds relative to the workflow start time which the workflow is
 	// allowed to run before the controller terminates the workflow. A value of zero is used to
 	// terminate a Running workflow
 	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
 
+	// Shutdown will shut down a running workflow according to the strategy. Unlike a zero
+	// activeDeadlineSeconds, the Stop strategy still runs the workflow's exit handler
+	Shutdown ShutdownStrategy `json:"shutdown,omitempty"`
+
 	// Priority is used if controller is configured to process limited number of workflows in parallel. Workflows with higher priority are processed first.
 	Priority *int32 `json:"priority,omitempty"`
 
@@ -280,6 +301,12 @@ type Template struct {
 	// Suspend template subtype which can suspend a workflow when reaching the step
 	Suspend *SuspendTemplate `json:"suspend,omitempty"`
 
+	// HTTP template subtype which makes an HTTP request from the controller
+	HTTP *HTTPTemplate `json:"http,omitempty"`
+
+	// Data template subtype which lists and transforms data, e.g. the keys under an artifact location
+	Data *DataTemplate `json:"data,omitempty"`
+
 	// Volumes is a list of volumes that can be mounted by containers in a template.
 	// +patchStrategy=merge
 	// +patchMergeKey=name
@@ -427,6 +454,16 @@ type ValueFrom struct {
 	// Parameter reference to a step or dag task in which to retrieve an output parameter value from
 	// (e.g. '{{steps.mystep.outputs.myparam}}')
 	Parameter string `json:"parameter,omitempty"`
+
+	// Expression computes an output parameter value of a steps or dag template from the outputs of
+	// its steps or tasks (e.g. "'{{steps.flip.outputs.result}}' == 'heads' ? '{{steps.heads.outputs.result}}' : '{{steps.tails.outputs.result}}'").
+	// The referenced values are passed to the expression as values rather than substituted into it. References to
+	// the outputs of skipped steps or tasks resolve to empty strings, and any other unresolved reference is an error.
+	Expression string `json:"expression,omitempty"`
+
+	// Default is the value of the output parameter if it cannot be retrieved (e.g. the path does
+	// not exist because the step failed), or is empty (e.g. the referenced step was skipped)
+	Default *string `json:"default,omitempty"`
 }
 
 // Artifact indicates an artifact to place at a specified path
@@ -458,9 +495,28 @@ type Artifact struct {
 	Optional bool `json:"optional,omitempty"`
 }
 
+// TTLStrategy is the strategy for the time to live of a finished workflow, depending on its outcome
+type TTLStrategy struct {
+	// SecondsAfterCompletion is the number of seconds to live after the workflow finished, unless
+	// a more specific TTL applies
+	SecondsAfterCompletion *int32 `json:"secondsAfterCompletion,omitempty"`
+	// SecondsAfterSuccess is the number of seconds to live after the workflow succeeded
+	SecondsAfterSuccess *int32 `json:"secondsAfterSuccess,omitempty"`
+	// SecondsAfterFailure is the number of seconds to live after the workflow failed or errored
+	SecondsAfterFailure *int32 `json:"secondsAfterFailure,omitempty"`
+}
+
 // PodGC describes how to delete completed pods as they complete
 type PodGC struct {
 	Strategy PodGCStrategy `json:"strategy,omitempty"`
+
+	// LabelSelector restricts the pods which are deleted to the ones matching the selector. Pods which
+	// do not match are kept.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// DeleteDelayDuration is how long to wait before a pod is deleted once the strategy applies to it,
+	// e.g. to keep pods around for debugging for a while
+	DeleteDelayDuration *metav1.Duration `json:"deleteDelayDuration,omitempty"`
 }
 
 // ArchiveStrategy describes how to archive files/directory when saving artifacts
@@ -543,12 +599,19 @@ type WorkflowStep struct {
 	WithItems []Item `json:"withItems,omitempty"`
 
 	// WithParam expands a step into multiple parallel steps from the value in the parameter,
-	// which is expected to be a JSON list.
+	// which is expected to be a JSON list. It may also be a reference to an artifact
+	// (e.g. {{steps.generate.outputs.artifacts.items}}), in which case the JSON list is read from the artifact,
+	// which must be an S3, Artifactory or raw artifact of at most 256KB.
 	WithParam string `json:"withParam,omitempty"`
 
 	// WithSequence expands a step into a numeric sequence
 	WithSequence *Sequence `json:"withSequence,omitempty"`
 
+	// MapStrategy streams the items of withItems/withParam/withSequence through a bounded number of
+	// steps rather than expanding all of them at once. Only aggregate counters and the failed items are
+	// kept in the workflow status, which allows for fan-outs too large to fit in the workflow object.
+	MapStrategy *MapStrategy `json:"mapStrategy,omitempty"`
+
 	// When is an expression in which the step should conditionally execute
 	When string `json:"when,omitempty"`
 
@@ -573,6 +636,12 @@ type Item struct {
 	Value interface{} `json:"value,omitempty"`
 }
 
+// MapStrategy configures the streamed expansion of the items of a step or task
+type MapStrategy struct {
+	// Parallelism limits the number of items which are run at the same time (default: 100)
+	Parallelism *int64 `json:"parallelism,omitempty"`
+}
+
 // Sequence expands a workflow step into numeric range
 type Sequence struct {
 	// Count is number of elements in the sequence (default: 0). Not to be used with end
@@ -771,6 +840,28 @@ type NodeStatus struct {
 	// a DAG/steps template invokes another DAG/steps template. In other words, the outbound nodes of
 	// a template, will be a superset of the outbound nodes of its last children.
 	OutboundNodes []string `json:"outboundNodes,omitempty"`
+
+	// MapStatus holds the aggregate progress of the items of a Map node
+	MapStatus *MapStatus `json:"mapStatus,omitempty"`
+}
+
+// MapStatus holds the aggregate progress of a step or task expanded with a map strategy. Succeeded
+// and skipped items are only counted, whereas failed items remain as children of the Map node.
+type MapStatus struct {
+	// Total is the number of items
+	Total int64 `json:"total"`
+
+	// Started is the number of items which were started, or skipped due to their when expression
+	Started int64 `json:"started"`
+
+	// Succeeded is the number of items which succeeded
+	Succeeded int64 `json:"succeeded"`
+
+	// Failed is the number of items which failed or errored
+	Failed int64 `json:"failed"`
+
+	// Skipped is the number of items whose when expression evaluated false
+	Skipped int64 `json:"skipped"`
 }
 
 var _ TemplateHolder = &NodeStatus{}
@@ -1038,18 +1129,28 @@ type ResourceTemplate struct {
 	// Must be one of: strategic, merge, json
 	MergeStrategy string `json:"mergeStrategy,omitempty"`
 
-	// Manifest contains the kubernetes manifest
+	// Manifest contains the kubernetes manifest. For patch actions it is the patch, which is a list
+	// of operations when the merge strategy is json
 	Manifest string `json:"manifest"`
 
+	// Flags identify the resource the action is performed on, as "TYPE NAME" or "TYPE/NAME" with an
+	// optional "-n NAMESPACE", instead of the manifest. Only valid for the get, patch and delete
+	// actions, and required for json patches
+	Flags []string `json:"flags,omitempty"`
+
 	// SetOwnerReference sets the reference to the workflow on the OwnerReference of generated resource.
+	// Not supported for json patches
 	SetOwnerReference bool `json:"setOwnerReference,omitempty"`
 
 	// SuccessCondition is a label selector expression which describes the conditions
-	// of the k8s resource in which it is acceptable to proceed to the following step
+	// of the k8s resource in which it is acceptable to proceed to the following step.
+	// Alternatively, it can be an expression over JSONPath templates of the resource, e.g.
+	// "{.status.conditions[?(@.type=='Ready')].status} == True"
 	SuccessCondition string `json:"successCondition,omitempty"`
 
 	// FailureCondition is a label selector expression which describes the conditions
-	// of the k8s resource in which the step was considered failed
+	// of the k8s resource in which the step was considered failed. Like SuccessCondition,
+	// it can also be an expression over JSONPath templates of the resource
 	FailureCondition string `json:"failureCondition,omitempty"`
 }
 
@@ -1073,13 +1174,19 @@ func (tmpl *Template) GetType() TemplateType {
 	if tmpl.Suspend != nil {
 		return TemplateTypeSuspend
 	}
+	if tmpl.HTTP != nil {
+		return TemplateTypeHTTP
+	}
+	if tmpl.Data != nil {
+		return TemplateTypeData
+	}
 	return TemplateTypeUnknown
 }
 
 // IsPodType returns whether or not the template is a pod type
 func (tmpl *Template) IsPodType() bool {
 	switch tmpl.GetType() {
-	case TemplateTypeContainer, TemplateTypeScript, TemplateTypeResource:
+	case TemplateTypeContainer, TemplateTypeScript, TemplateTypeResource, TemplateTypeData:
 		return true
 	}
 	return false
@@ -1088,7 +1195,7 @@ func (tmpl *Template) IsPodType() bool {
 // IsLeaf returns whether or not the template is a leaf
 func (tmpl *Template) IsLeaf() bool {
 	switch tmpl.GetType() {
-	case TemplateTypeContainer, TemplateTypeScript, TemplateTypeResource:
+	case TemplateTypeContainer, TemplateTypeScript, TemplateTypeResource, TemplateTypeData:
 		return true
 	}
 	return false
@@ -1134,12 +1241,19 @@ type DAGTask struct {
 	WithItems []Item `json:"withItems,omitempty"`
 
 	// WithParam expands a task into multiple parallel tasks from the value in the parameter,
-	// which is expected to be a JSON list.
+	// which is expected to be a JSON list. It may also be a reference to an artifact
+	// (e.g. {{tasks.generate.outputs.artifacts.items}}), in which case the JSON list is read from the artifact,
+	// which must be an S3, Artifactory or raw artifact of at most 256KB.
 	WithParam string `json:"withParam,omitempty"`
 
 	// WithSequence expands a task into a numeric sequence
 	WithSequence *Sequence `json:"withSequence,omitempty"`
 
+	// MapStrategy streams the items of withItems/withParam/withSequence through a bounded number of
+	// tasks rather than expanding all of them at once. Only aggregate counters and the failed items are
+	// kept in the workflow status, which allows for fan-outs too large to fit in the workflow object.
+	MapStrategy *MapStrategy `json:"mapStrategy,omitempty"`
+
 	// When is an expression in which the task should conditionally execute
 	When string `json:"when,omitempty"`
 
@@ -1162,6 +1276,80 @@ func (t *DAGTask) GetTemplateRef() *TemplateRef {
 type SuspendTemplate struct {
 }
 
+// HTTPTemplate is a template subtype which makes an HTTP request directly from the workflow controller,
+// without scheduling a pod. The response body is made available as the template's outputs.result.
+type HTTPTemplate struct {
+	// URL of the request
+	URL string `json:"url"`
+
+	// Method is the HTTP method of the request. Defaults to GET
+	Method string `json:"method,omitempty"`
+
+	// Headers are the request headers. Values may be taken from secrets
+	// +patchStrategy=merge
+	// +patchMergeKey=name
+	Headers []HTTPHeader `json:"headers,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+
+	// Body is the request body
+	Body string `json:"body,omitempty"`
+
+	// TimeoutSeconds is the request timeout in seconds. Defaults to 30, and may be at most 120
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+
+	// SuccessCondition is an expression which determines if the request succeeded, e.g.
+	// "{{response.statusCode}} == 201". The response status code and body are available as
+	// {{response.statusCode}} and {{response.body}}. If omitted, any 2xx status code is a success
+	SuccessCondition string `json:"successCondition,omitempty"`
+}
+
+// HTTPHeader is a header of an HTTP template request
+type HTTPHeader struct {
+	// Name of the header
+	Name string `json:"name"`
+
+	// Value of the header
+	Value string `json:"value,omitempty"`
+
+	// ValueFrom is the source of the header value, used if Value is not set
+	ValueFrom *HTTPHeaderSource `json:"valueFrom,omitempty"`
+}
+
+// HTTPHeaderSource is the source of an HTTP header value
+type HTTPHeaderSource struct {
+	// SecretKeyRef selects a key of a secret in the workflow's namespace
+	SecretKeyRef *apiv1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// DataTemplate is a template subtype which reads a list of items from a source and applies a
+// series of transformations to it. The resulting JSON list is the template's outputs.result,
+// which is typically used to fan out with withParam.
+type DataTemplate struct {
+	// Source is the source of the items
+	Source DataSource `json:"source"`
+
+	// Transformation is a list of steps applied in order to the items of the source
+	Transformation []TransformationStep `json:"transformation,omitempty"`
+}
+
+// DataSource is the source of the items of a data template
+type DataSource struct {
+	// ArtifactPaths lists the keys under an artifact location, e.g. the objects under an S3 key prefix.
+	// The location must be an S3 or HDFS location, whose drivers support listing
+	ArtifactPaths *Artifact `json:"artifactPaths,omitempty"`
+}
+
+// TransformationStep is a single step of a data template transformation. Exactly one of
+// filter or map must be set.
+type TransformationStep struct {
+	// Filter is an expression evaluated for every item, which drops the items it is false for,
+	// e.g. "'{{item}}' =~ '.csv$'"
+	Filter string `json:"filter,omitempty"`
+
+	// Map is a template evaluated for every item, whose result replaces the item,
+	// e.g. "s3://my-bucket/{{item}}"
+	Map string `json:"map,omitempty"`
+}
+
 // GetArtifactByName returns an input artifact by its name
 func (in *Inputs) GetArtifactByName(name string) *Artifact {
 	for _, art := range in.Artifacts {
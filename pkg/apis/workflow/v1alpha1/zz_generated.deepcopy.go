@@ -6,6 +6,7 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -244,6 +245,11 @@ func (in *DAGTask) DeepCopyInto(out *DAGTask) {
 		*out = new(Sequence)
 		**out = **in
 	}
+	if in.MapStrategy != nil {
+		in, out := &in.MapStrategy, &out.MapStrategy
+		*out = new(MapStrategy)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ContinueOn != nil {
 		in, out := &in.ContinueOn, &out.ContinueOn
 		*out = new(ContinueOn)
@@ -290,6 +296,49 @@ func (in *DAGTemplate) DeepCopy() *DAGTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataSource) DeepCopyInto(out *DataSource) {
+	*out = *in
+	if in.ArtifactPaths != nil {
+		in, out := &in.ArtifactPaths, &out.ArtifactPaths
+		*out = new(Artifact)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataSource.
+func (in *DataSource) DeepCopy() *DataSource {
+	if in == nil {
+		return nil
+	}
+	out := new(DataSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataTemplate) DeepCopyInto(out *DataTemplate) {
+	*out = *in
+	in.Source.DeepCopyInto(&out.Source)
+	if in.Transformation != nil {
+		in, out := &in.Transformation, &out.Transformation
+		*out = make([]TransformationStep, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataTemplate.
+func (in *DataTemplate) DeepCopy() *DataTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(DataTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExecutorConfig) DeepCopyInto(out *ExecutorConfig) {
 	*out = *in
@@ -433,6 +482,76 @@ func (in *HTTPArtifact) DeepCopy() *HTTPArtifact {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPHeader) DeepCopyInto(out *HTTPHeader) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(HTTPHeaderSource)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHeader.
+func (in *HTTPHeader) DeepCopy() *HTTPHeader {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPHeader)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPHeaderSource) DeepCopyInto(out *HTTPHeaderSource) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHeaderSource.
+func (in *HTTPHeaderSource) DeepCopy() *HTTPHeaderSource {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPHeaderSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPTemplate) DeepCopyInto(out *HTTPTemplate) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]HTTPHeader, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTemplate.
+func (in *HTTPTemplate) DeepCopy() *HTTPTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Inputs) DeepCopyInto(out *Inputs) {
 	*out = *in
@@ -473,6 +592,43 @@ func (in *Item) DeepCopy() *Item {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MapStatus) DeepCopyInto(out *MapStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MapStatus.
+func (in *MapStatus) DeepCopy() *MapStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MapStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MapStrategy) DeepCopyInto(out *MapStrategy) {
+	*out = *in
+	if in.Parallelism != nil {
+		in, out := &in.Parallelism, &out.Parallelism
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MapStrategy.
+func (in *MapStrategy) DeepCopy() *MapStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(MapStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Metadata) DeepCopyInto(out *Metadata) {
 	*out = *in
@@ -538,6 +694,11 @@ func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.MapStatus != nil {
+		in, out := &in.MapStatus, &out.MapStatus
+		*out = new(MapStatus)
+		**out = **in
+	}
 	return
 }
 
@@ -618,7 +779,7 @@ func (in *Parameter) DeepCopyInto(out *Parameter) {
 	if in.ValueFrom != nil {
 		in, out := &in.ValueFrom, &out.ValueFrom
 		*out = new(ValueFrom)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
@@ -636,6 +797,16 @@ func (in *Parameter) DeepCopy() *Parameter {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodGC) DeepCopyInto(out *PodGC) {
 	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeleteDelayDuration != nil {
+		in, out := &in.DeleteDelayDuration, &out.DeleteDelayDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -668,6 +839,11 @@ func (in *RawArtifact) DeepCopy() *RawArtifact {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceTemplate) DeepCopyInto(out *ResourceTemplate) {
 	*out = *in
+	if in.Flags != nil {
+		in, out := &in.Flags, &out.Flags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -791,6 +967,37 @@ func (in *SuspendTemplate) DeepCopy() *SuspendTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TTLStrategy) DeepCopyInto(out *TTLStrategy) {
+	*out = *in
+	if in.SecondsAfterCompletion != nil {
+		in, out := &in.SecondsAfterCompletion, &out.SecondsAfterCompletion
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SecondsAfterSuccess != nil {
+		in, out := &in.SecondsAfterSuccess, &out.SecondsAfterSuccess
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SecondsAfterFailure != nil {
+		in, out := &in.SecondsAfterFailure, &out.SecondsAfterFailure
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TTLStrategy.
+func (in *TTLStrategy) DeepCopy() *TTLStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(TTLStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TarStrategy) DeepCopyInto(out *TarStrategy) {
 	*out = *in
@@ -862,7 +1069,7 @@ func (in *Template) DeepCopyInto(out *Template) {
 	if in.Resource != nil {
 		in, out := &in.Resource, &out.Resource
 		*out = new(ResourceTemplate)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.DAG != nil {
 		in, out := &in.DAG, &out.DAG
@@ -874,6 +1081,16 @@ func (in *Template) DeepCopyInto(out *Template) {
 		*out = new(SuspendTemplate)
 		**out = **in
 	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = new(DataTemplate)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Volumes != nil {
 		in, out := &in.Volumes, &out.Volumes
 		*out = make([]v1.Volume, len(*in))
@@ -978,6 +1195,22 @@ func (in *TemplateRef) DeepCopy() *TemplateRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransformationStep) DeepCopyInto(out *TransformationStep) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransformationStep.
+func (in *TransformationStep) DeepCopy() *TransformationStep {
+	if in == nil {
+		return nil
+	}
+	out := new(TransformationStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UserContainer) DeepCopyInto(out *UserContainer) {
 	*out = *in
@@ -1003,6 +1236,11 @@ func (in *UserContainer) DeepCopy() *UserContainer {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValueFrom) DeepCopyInto(out *ValueFrom) {
 	*out = *in
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(string)
+		**out = **in
+	}
 	return
 }
 
@@ -1171,6 +1409,11 @@ func (in *WorkflowSpec) DeepCopyInto(out *WorkflowSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.TTLStrategy != nil {
+		in, out := &in.TTLStrategy, &out.TTLStrategy
+		*out = new(TTLStrategy)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ActiveDeadlineSeconds != nil {
 		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
 		*out = new(int64)
@@ -1184,7 +1427,7 @@ func (in *WorkflowSpec) DeepCopyInto(out *WorkflowSpec) {
 	if in.PodGC != nil {
 		in, out := &in.PodGC, &out.PodGC
 		*out = new(PodGC)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.PodPriority != nil {
 		in, out := &in.PodPriority, &out.PodPriority
@@ -1281,6 +1524,11 @@ func (in *WorkflowStep) DeepCopyInto(out *WorkflowStep) {
 		*out = new(Sequence)
 		**out = **in
 	}
+	if in.MapStrategy != nil {
+		in, out := &in.MapStrategy, &out.MapStrategy
+		*out = new(MapStrategy)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ContinueOn != nil {
 		in, out := &in.ContinueOn, &out.ContinueOn
 		*out = new(ContinueOn)
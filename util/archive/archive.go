@@ -4,8 +4,10 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/argoproj/argo/errors"
 	"github.com/argoproj/argo/util"
@@ -130,3 +132,117 @@ func tarFile(sourcePath string, tw *tar.Writer) error {
 	_, err = io.Copy(tw, f)
 	return err
 }
+
+// IsTarGz returns whether the file is a tar.gz, such as written by TarGzToWriter
+func IsTarGz(filePath string) bool {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer util.Close(f)
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		return false
+	}
+	defer util.Close(gzr)
+	_, err = tar.NewReader(gzr).Next()
+	return err == nil || err == io.EOF
+}
+
+// UntarGz extracts a tar.gz to the destination path. If the tarball contains a single file or directory,
+// as written by TarGzToWriter, it is extracted to the destination path itself. Otherwise the destination
+// path is a directory containing the entries of the tarball. Nothing is left at the destination path if
+// the extraction fails.
+func UntarGz(tarPath string, destPath string) error {
+	tmpDir := destPath + ".tmpdir"
+	err := untarGzToDir(tarPath, tmpDir)
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return err
+	}
+	files, err := ioutil.ReadDir(tmpDir)
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return errors.InternalWrapError(err)
+	}
+	if len(files) != 1 {
+		err = os.Rename(tmpDir, destPath)
+		if err != nil {
+			_ = os.RemoveAll(tmpDir)
+			return errors.InternalWrapError(err)
+		}
+		return nil
+	}
+	err = os.Rename(filepath.Join(tmpDir, files[0].Name()), destPath)
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return errors.InternalWrapError(err)
+	}
+	return os.Remove(tmpDir)
+}
+
+// untarGzToDir extracts the entries of a tar.gz into a directory, refusing entries outside of it
+func untarGzToDir(tarPath string, dir string) error {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
+	defer util.Close(f)
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
+	defer util.Close(gzr)
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.InternalWrapError(err)
+		}
+		target := filepath.Join(dir, header.Name)
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return errors.InternalErrorf("tarball entry %s is outside of the destination", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, os.FileMode(header.Mode))
+		case tar.TypeReg:
+			err = extractFile(tr, target, os.FileMode(header.Mode))
+		case tar.TypeSymlink:
+			err = os.MkdirAll(filepath.Dir(target), os.ModePerm)
+			if err == nil {
+				err = os.Symlink(header.Linkname, target)
+			}
+		default:
+			log.Warnf("Skipping tarball entry %s of type %c", header.Name, header.Typeflag)
+		}
+		if err != nil {
+			return errors.InternalWrapError(err)
+		}
+	}
+}
+
+// extractFile writes the current entry of a tarball to a file
+func extractFile(r io.Reader, target string, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(target), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	closeErr := f.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -1,9 +1,12 @@
 package archive
 
 import (
+	"archive/tar"
 	"bufio"
+	"compress/gzip"
 	"crypto/rand"
 	"encoding/hex"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -58,3 +61,66 @@ func TestTarFile(t *testing.T) {
 	err = f.Close()
 	assert.Nil(t, err)
 }
+
+// TestUntarGz verifies a tarball written by TarGzToWriter is extracted to the destination path
+func TestUntarGz(t *testing.T) {
+	dir, err := ioutil.TempDir("", "argo-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	src := filepath.Join(dir, "src")
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "sub", "file.txt"), []byte("hello"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plain.txt"), []byte("plain"), 0644))
+
+	tarPath := filepath.Join(dir, "src.tgz")
+	f, err := os.Create(tarPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, TarGzToWriter(src, f))
+	assert.NoError(t, f.Close())
+
+	assert.True(t, IsTarGz(tarPath))
+	assert.False(t, IsTarGz(filepath.Join(dir, "plain.txt")))
+	assert.False(t, IsTarGz(filepath.Join(dir, "missing")))
+
+	dest := filepath.Join(dir, "dest")
+	if assert.NoError(t, UntarGz(tarPath, dest)) {
+		data, err := ioutil.ReadFile(filepath.Join(dest, "sub", "file.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	}
+	_, err = os.Stat(dest + ".tmpdir")
+	assert.True(t, os.IsNotExist(err))
+}
+
+// TestUntarGzOutsideDestination verifies entries outside of the destination are refused and nothing is left behind
+func TestUntarGzOutsideDestination(t *testing.T) {
+	dir, err := ioutil.TempDir("", "argo-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	tarPath := filepath.Join(dir, "evil.tgz")
+	f, err := os.Create(tarPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	gzw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gzw)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "../evil.txt", Mode: 0644, Size: 4, Typeflag: tar.TypeReg}))
+	_, err = tw.Write([]byte("evil"))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gzw.Close())
+	assert.NoError(t, f.Close())
+
+	dest := filepath.Join(dir, "dest")
+	assert.Error(t, UntarGz(tarPath, dest))
+	for _, path := range []string{dest, dest + ".tmpdir", filepath.Join(dir, "dest.tmpdir", "..", "evil.txt")} {
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err), path)
+	}
+}
@@ -70,3 +70,8 @@ func (a *ArtifactoryArtifactDriver) Save(path string, artifact *wfv1.Artifact) e
 	}
 	return nil
 }
+
+// ListObjects is unsupported for artifactory artifacts
+func (a *ArtifactoryArtifactDriver) ListObjects(artifact *wfv1.Artifact) ([]string, error) {
+	return nil, errors.Errorf(errors.CodeNotImplemented, "Artifactory artifacts do not support listing")
+}
@@ -53,6 +53,11 @@ func (g *GitArtifactDriver) Save(path string, outputArtifact *wfv1.Artifact) err
 	return errors.Errorf(errors.CodeBadRequest, "Git output artifacts unsupported")
 }
 
+// ListObjects is unsupported for git artifacts
+func (g *GitArtifactDriver) ListObjects(artifact *wfv1.Artifact) ([]string, error) {
+	return nil, errors.Errorf(errors.CodeNotImplemented, "Git artifacts do not support listing")
+}
+
 func writePrivateKey(key string, insecureIgnoreHostKey bool) error {
 	usr, err := user.Current()
 	if err != nil {
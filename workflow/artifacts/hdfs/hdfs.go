@@ -215,3 +215,24 @@ func (driver *ArtifactDriver) Save(path string, outputArtifact *wfv1.Artifact) e
 
 	return hdfscli.CopyToRemote(path, driver.Path)
 }
+
+// ListObjects returns the paths of the files in the HDFS directory of the artifact
+func (driver *ArtifactDriver) ListObjects(artifact *wfv1.Artifact) ([]string, error) {
+	hdfscli, err := createHDFSClient(driver.Addresses, driver.HDFSUser, driver.KrbOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer util.Close(hdfscli)
+
+	infos, err := hdfscli.ReadDir(driver.Path)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if !info.IsDir() {
+			paths = append(paths, filepath.Join(driver.Path, info.Name()))
+		}
+	}
+	return paths, nil
+}
@@ -18,3 +18,8 @@ func (h *HTTPArtifactDriver) Load(inputArtifact *wfv1.Artifact, path string) err
 func (h *HTTPArtifactDriver) Save(path string, outputArtifact *wfv1.Artifact) error {
 	return errors.Errorf(errors.CodeBadRequest, "HTTP output artifacts unsupported")
 }
+
+// ListObjects is unsupported for HTTP artifacts
+func (h *HTTPArtifactDriver) ListObjects(artifact *wfv1.Artifact) ([]string, error) {
+	return nil, errors.Errorf(errors.CodeNotImplemented, "HTTP artifacts do not support listing")
+}
@@ -28,3 +28,8 @@ func (a *RawArtifactDriver) Load(artifact *wfv1.Artifact, path string) error {
 func (g *RawArtifactDriver) Save(path string, outputArtifact *wfv1.Artifact) error {
 	return errors.Errorf(errors.CodeBadRequest, "Raw output artifacts unsupported")
 }
+
+// ListObjects is unsupported for raw artifacts
+func (g *RawArtifactDriver) ListObjects(artifact *wfv1.Artifact) ([]string, error) {
+	return nil, errors.Errorf(errors.CodeNotImplemented, "Raw artifacts do not support listing")
+}
@@ -1,8 +1,13 @@
 package s3
 
 import (
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/credentials"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/wait"
 
@@ -34,6 +39,25 @@ func (s3Driver *S3ArtifactDriver) newS3Client() (argos3.S3Client, error) {
 	return argos3.NewS3Client(opts)
 }
 
+// newMinioClient instantiates a minio client with the same credentials as newS3Client, for the
+// operations which the S3Client of argoproj/pkg does not provide.
+func (s3Driver *S3ArtifactDriver) newMinioClient() (*minio.Client, error) {
+	var creds *credentials.Credentials
+	switch {
+	case s3Driver.RoleARN != "":
+		value, err := stscreds.NewCredentials(session.Must(session.NewSession()), s3Driver.RoleARN).Get()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewStaticV4(value.AccessKeyID, value.SecretAccessKey, value.SessionToken)
+	case s3Driver.AccessKey != "":
+		creds = credentials.NewStaticV4(strings.TrimSpace(s3Driver.AccessKey), strings.TrimSpace(s3Driver.SecretKey), "")
+	default:
+		creds = credentials.NewIAM("")
+	}
+	return minio.NewWithCredentials(s3Driver.Endpoint, creds, s3Driver.Secure, s3Driver.Region)
+}
+
 // Load downloads artifacts from S3 compliant storage
 func (s3Driver *S3ArtifactDriver) Load(inputArtifact *wfv1.Artifact, path string) error {
 	err := wait.ExponentialBackoff(wait.Backoff{Duration: time.Second * 2, Factor: 2.0, Steps: 5, Jitter: 0.1},
@@ -103,3 +127,42 @@ func (s3Driver *S3ArtifactDriver) Save(path string, outputArtifact *wfv1.Artifac
 		})
 	return err
 }
+
+// ListObjects returns the keys of the objects under the key prefix of the artifact
+func (s3Driver *S3ArtifactDriver) ListObjects(artifact *wfv1.Artifact) ([]string, error) {
+	var keys []string
+	err := wait.ExponentialBackoff(wait.Backoff{Duration: time.Second * 2, Factor: 2.0, Steps: 5, Jitter: 0.1},
+		func() (bool, error) {
+			log.Infof("S3 List bucket: %s, key prefix: %s", artifact.S3.Bucket, artifact.S3.Key)
+			minioClient, err := s3Driver.newMinioClient()
+			if err != nil {
+				log.Warnf("Failed to create new S3 client: %v", err)
+				return false, nil
+			}
+			keys, err = listObjects(minioClient, artifact.S3.Bucket, artifact.S3.Key)
+			if err != nil {
+				log.Warnf("Failed to list objects: %v", err)
+				return false, nil
+			}
+			return true, nil
+		})
+	return keys, err
+}
+
+// listObjects returns the keys of the objects under a key prefix, skipping the empty objects which
+// represent directories
+func listObjects(minioClient *minio.Client, bucket, keyPrefix string) ([]string, error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	var keys []string
+	for obj := range minioClient.ListObjectsV2(bucket, keyPrefix, true, doneCh) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		if strings.HasSuffix(obj.Key, "/") {
+			continue
+		}
+		keys = append(keys, obj.Key)
+	}
+	return keys, nil
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"strings"
 	"time"
 
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
@@ -73,3 +74,46 @@ func insertTask(visited map[string]time.Time, ancestry []string, newTaskName str
 		}
 	}
 }
+
+// GetNodeAncestry returns the IDs of the nodes which contain the given node, from its direct parent up to
+// the root node of the workflow. These are the step groups, task groups and retry nodes the node was run
+// in, as well as the boundary nodes of its templates. Unlike node children, this does not include the
+// nodes the node merely depends on, e.g. the tasks of a DAG it depends on or the previous step groups.
+func GetNodeAncestry(nodes map[string]wfv1.NodeStatus, nodeID string) []string {
+	ancestry := make([]string, 0)
+	visited := map[string]bool{nodeID: true}
+	for parentID := getNodeParent(nodes, nodeID); parentID != "" && !visited[parentID]; parentID = getNodeParent(nodes, parentID) {
+		visited[parentID] = true
+		ancestry = append(ancestry, parentID)
+	}
+	return ancestry
+}
+
+// getNodeParent returns the ID of the node which directly contains the node, or an empty string for the
+// root node.
+func getNodeParent(nodes map[string]wfv1.NodeStatus, nodeID string) string {
+	node, ok := nodes[nodeID]
+	if !ok {
+		return ""
+	}
+	if node.Type != wfv1.NodeTypeStepGroup {
+		for _, parent := range nodes {
+			switch parent.Type {
+			case wfv1.NodeTypeStepGroup, wfv1.NodeTypeTaskGroup:
+			case wfv1.NodeTypeRetry:
+				// besides its attempts, the children of a retry node include the nodes depending on it
+				if !strings.HasPrefix(node.Name, parent.Name+"(") {
+					continue
+				}
+			default:
+				continue
+			}
+			for _, childID := range parent.Children {
+				if childID == nodeID {
+					return parent.ID
+				}
+			}
+		}
+	}
+	return node.BoundaryID
+}
@@ -152,3 +152,44 @@ func TestGetTaskAncestryForGlobalArtifacts(t *testing.T) {
 		})
 	}
 }
+
+func TestGetNodeAncestry(t *testing.T) {
+	stepsNodes := map[string]wfv1.NodeStatus{
+		"steps":            {ID: "steps", Name: "steps", Type: wfv1.NodeTypeSteps, Children: []string{"steps[0]"}},
+		"steps[0]":         {ID: "steps[0]", Name: "steps[0]", Type: wfv1.NodeTypeStepGroup, BoundaryID: "steps", Children: []string{"steps[0].a"}},
+		"steps[0].a":       {ID: "steps[0].a", Name: "steps[0].a", Type: wfv1.NodeTypePod, BoundaryID: "steps", Children: []string{"steps[1]"}},
+		"steps[1]":         {ID: "steps[1]", Name: "steps[1]", Type: wfv1.NodeTypeStepGroup, BoundaryID: "steps", Children: []string{"steps[1].b"}},
+		"steps[1].b":       {ID: "steps[1].b", Name: "steps[1].b", Type: wfv1.NodeTypeRetry, BoundaryID: "steps", Children: []string{"steps[1].b(0)"}},
+		"steps[1].b(0)":    {ID: "steps[1].b(0)", Name: "steps[1].b(0)", Type: wfv1.NodeTypeSteps, BoundaryID: "steps", Children: []string{"steps[1].b(0)[0]"}},
+		"steps[1].b(0)[0]": {ID: "steps[1].b(0)[0]", Name: "steps[1].b(0)[0]", Type: wfv1.NodeTypeStepGroup, BoundaryID: "steps[1].b(0)"},
+	}
+	dagNodes := map[string]wfv1.NodeStatus{
+		"dag":      {ID: "dag", Name: "dag", Type: wfv1.NodeTypeDAG, Children: []string{"dag.A"}},
+		"dag.A":    {ID: "dag.A", Name: "dag.A", Type: wfv1.NodeTypeRetry, BoundaryID: "dag", Children: []string{"dag.A(0)", "dag.B"}},
+		"dag.A(0)": {ID: "dag.A(0)", Name: "dag.A(0)", Type: wfv1.NodeTypePod, BoundaryID: "dag"},
+		"dag.B":    {ID: "dag.B", Name: "dag.B", Type: wfv1.NodeTypeDAG, BoundaryID: "dag", Children: []string{"dag.B.C"}},
+		"dag.B.C":  {ID: "dag.B.C", Name: "dag.B.C", Type: wfv1.NodeTypePod, BoundaryID: "dag.B"},
+	}
+
+	tests := []struct {
+		name   string
+		nodes  map[string]wfv1.NodeStatus
+		nodeID string
+		want   []string
+	}{
+		{name: "root node", nodes: stepsNodes, nodeID: "steps", want: []string{}},
+		{name: "step", nodes: stepsNodes, nodeID: "steps[0].a", want: []string{"steps[0]", "steps"}},
+		{name: "later step group", nodes: stepsNodes, nodeID: "steps[1]", want: []string{"steps"}},
+		{name: "nested steps in a retry", nodes: stepsNodes, nodeID: "steps[1].b(0)[0]", want: []string{"steps[1].b(0)", "steps[1].b", "steps[1]", "steps"}},
+		{name: "dag task attempt", nodes: dagNodes, nodeID: "dag.A(0)", want: []string{"dag.A", "dag"}},
+		{name: "dag task depending on a retry", nodes: dagNodes, nodeID: "dag.B", want: []string{"dag"}},
+		{name: "nested dag task", nodes: dagNodes, nodeID: "dag.B.C", want: []string{"dag.B", "dag"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetNodeAncestry(tt.nodes, tt.nodeID); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetNodeAncestry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -14,6 +14,12 @@ const (
 	// DefaultArchivePattern is the default pattern when storing artifacts in an archive repository
 	DefaultArchivePattern = "{{workflow.name}}/{{pod.name}}"
 
+	// MainLogsArtifactName is the name of the output artifact the logs of the main container are archived to
+	MainLogsArtifactName = "main-logs"
+
+	// MaxHTTPTimeoutSeconds is the maximum timeout of the request of an HTTP template, which is made by the controller
+	MaxHTTPTimeoutSeconds = 120
+
 	// Container names used in the workflow pod
 	MainContainerName = "main"
 	InitContainerName = "init"
@@ -46,6 +52,11 @@ const (
 	// set by the controller and obeyed by the executor. For example, the controller will use this annotation to
 	// signal the executors of daemoned containers that it should terminate.
 	AnnotationKeyExecutionControl = workflow.WorkflowFullName + "/execution"
+	// AnnotationKeyStoppedBy is the workflow metadata annotation key containing who stopped the workflow
+	AnnotationKeyStoppedBy = workflow.WorkflowFullName + "/stopped-by"
+	// AnnotationKeyPodGCDeleteAfter is the pod metadata annotation key containing the time (RFC3339) after
+	// which the controller deletes the pod, according to the pod GC strategy of its workflow
+	AnnotationKeyPodGCDeleteAfter = workflow.WorkflowFullName + "/pod-gc-delete-after"
 
 	// LabelKeyControllerInstanceID is the label the controller will carry forward to workflows/pod labels
 	// for the purposes of workflow segregation
@@ -57,6 +68,14 @@ const (
 	LabelKeyWorkflow = workflow.WorkflowFullName + "/workflow"
 	// LabelKeyPhase is a label applied to workflows to indicate the current phase of the workflow (for filtering purposes)
 	LabelKeyPhase = workflow.WorkflowFullName + "/phase"
+	// LabelKeyShard is the label applied on workflows and workflow pods by a sharded controller to indicate
+	// the shard they are assigned to
+	LabelKeyShard = workflow.WorkflowFullName + "/shard"
+	// LabelKeyConfigMapType is the label which marks a configmap as containing configuration for argo
+	LabelKeyConfigMapType = workflow.WorkflowFullName + "/configmap-type"
+	// LabelValueConfigMapTypeParameter is the configmap type of the configmaps which override the
+	// controller configuration for the workflows of their namespace
+	LabelValueConfigMapTypeParameter = "Parameter"
 
 	// ExecutorArtifactBaseDir is the base directory in the init container in which artifacts will be copied to.
 	// Each artifact will be named according to its input name (e.g: /argo/inputs/artifacts/CODE)
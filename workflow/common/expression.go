@@ -0,0 +1,101 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Knetic/govaluate"
+
+	"github.com/argoproj/argo/errors"
+)
+
+// expressionRefRegex matches a {{tag}} reference in an expression, along with the quotes it may be written in
+var expressionRefRegex = regexp.MustCompile(`'\{\{([^{}]+)\}\}'|"\{\{([^{}]+)\}\}"|\{\{([^{}]+)\}\}`)
+
+// expressionParamPrefix is the prefix of the names of the govaluate parameters the references are passed as.
+// It cannot start a bare word, so the parameters are told apart from the words which are treated as strings.
+const expressionParamPrefix = "$ref"
+
+// EvaluateExpression evaluates a govaluate expression in which {{tag}} references are resolved from values.
+// The values are passed to govaluate as parameters rather than substituted into the expression, so that
+// they never need quoting and cannot change the meaning of the expression. A reference written in quotes,
+// e.g. '{{item}}', is always a string; other references are numbers or booleans if their value looks like
+// one, as they would be if written into the expression. As in 'when' expressions, bare words are treated
+// as strings, allowing expressions like "{{steps.flip.outputs.result}} == heads". A reference to a tag
+// which is not in values is an error.
+func EvaluateExpression(expr string, values map[string]string) (interface{}, error) {
+	params := make(map[string]interface{})
+	var unresolved []string
+	rewritten := expressionRefRegex.ReplaceAllStringFunc(expr, func(ref string) string {
+		match := expressionRefRegex.FindStringSubmatch(ref)
+		quoted := match[3] == ""
+		tag := strings.TrimSpace(match[1] + match[2] + match[3])
+		val, ok := values[tag]
+		if !ok {
+			unresolved = append(unresolved, tag)
+			return ref
+		}
+		name := fmt.Sprintf("%s%d", expressionParamPrefix, len(params))
+		if quoted {
+			params[name] = val
+		} else {
+			params[name] = expressionValue(val)
+		}
+		return "[" + name + "]"
+	})
+	if len(unresolved) > 0 {
+		return nil, errors.Errorf(errors.CodeBadRequest, "Unable to resolve {{%s}} in expression '%s'", strings.Join(unresolved, "}}, {{"), expr)
+	}
+	expression, err := govaluate.NewEvaluableExpression(rewritten)
+	if err != nil {
+		return nil, errors.Errorf(errors.CodeBadRequest, "Invalid expression '%s': %v", expr, err)
+	}
+	// The following loop converts govaluate variables, other than the parameters of the references, into
+	// strings. This allows us to have expressions like: "foo != bar" without requiring foo and bar to be quoted.
+	tokens := expression.Tokens()
+	for i, tok := range tokens {
+		if tok.Kind != govaluate.VARIABLE {
+			continue
+		}
+		if name, ok := tok.Value.(string); ok && strings.HasPrefix(name, expressionParamPrefix) {
+			continue
+		}
+		tok.Kind = govaluate.STRING
+		tokens[i] = tok
+	}
+	expression, err = govaluate.NewEvaluableExpressionFromTokens(tokens)
+	if err != nil {
+		return nil, errors.InternalWrapErrorf(err, "Failed to parse expression '%s': %v", expr, err)
+	}
+	result, err := expression.Evaluate(params)
+	if err != nil {
+		return nil, errors.Errorf(errors.CodeBadRequest, "Failed to evaluate expression '%s': %v", expr, err)
+	}
+	return result, nil
+}
+
+// EvaluateBoolExpression evaluates an expression like EvaluateExpression, which must evaluate to a boolean
+func EvaluateBoolExpression(expr string, values map[string]string) (bool, error) {
+	result, err := EvaluateExpression(expr, values)
+	if err != nil {
+		return false, err
+	}
+	boolRes, ok := result.(bool)
+	if !ok {
+		return false, errors.Errorf(errors.CodeBadRequest, "Expected boolean evaluation for '%s'. Got %v", expr, result)
+	}
+	return boolRes, nil
+}
+
+// expressionValue returns the value of an unquoted reference, which is a number or boolean if it looks like one
+func expressionValue(val string) interface{} {
+	if f, err := strconv.ParseFloat(val, 64); err == nil {
+		return f
+	}
+	if b, err := strconv.ParseBool(val); err == nil && (val == "true" || val == "false") {
+		return b
+	}
+	return val
+}
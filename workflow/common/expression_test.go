@@ -0,0 +1,52 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEvaluateExpression verifies references are passed to the expression as parameters
+func TestEvaluateExpression(t *testing.T) {
+	values := map[string]string{
+		"response.statusCode": "200",
+		"response.body":       `it's "ready" || true`,
+		"item":                "data.csv",
+		"steps.flip.result":   "heads",
+		"steps.heads.result":  "3",
+	}
+	tests := []struct {
+		expr     string
+		expected interface{}
+	}{
+		{"{{response.statusCode}} < 300", true},
+		{"{{response.statusCode}} == '200'", false},
+		{"'{{response.statusCode}}' == '200'", true},
+		{"{{response.body}} =~ 'ready' && {{response.statusCode}} == 200", true},
+		{"{{response.body}} == 'true'", false},
+		{"{{response.body}} == ready", false},
+		{"'{{item}}' =~ '.csv$'", true},
+		{"{{steps.flip.result}} == heads", true},
+		{"'{{steps.flip.result}}' == 'heads' ? '{{steps.heads.result}}' : 'none'", "3"},
+		{"{{steps.flip.result}} == heads ? {{steps.heads.result}} : 0", float64(3)},
+	}
+	for _, test := range tests {
+		result, err := EvaluateExpression(test.expr, values)
+		if assert.NoError(t, err, test.expr) {
+			assert.Equal(t, test.expected, result, test.expr)
+		}
+	}
+}
+
+// TestEvaluateExpressionErrors verifies unresolved references and non-boolean results are errors
+func TestEvaluateExpressionErrors(t *testing.T) {
+	_, err := EvaluateExpression("{{steps.missing.outputs.result}} == heads", map[string]string{})
+	assert.EqualError(t, err, "Unable to resolve {{steps.missing.outputs.result}} in expression '{{steps.missing.outputs.result}} == heads'")
+
+	_, err = EvaluateBoolExpression("{{item}}", map[string]string{"item": "foo"})
+	assert.Error(t, err)
+
+	res, err := EvaluateBoolExpression("{{item}} == foo", map[string]string{"item": "foo"})
+	assert.NoError(t, err)
+	assert.True(t, res)
+}
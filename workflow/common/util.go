@@ -20,6 +20,7 @@ import (
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
@@ -467,12 +468,48 @@ func DeletePod(c kubernetes.Interface, podName, namespace string) error {
 
 // IsPodTemplate returns whether the template corresponds to a pod
 func IsPodTemplate(tmpl *wfv1.Template) bool {
-	if tmpl.Container != nil || tmpl.Script != nil || tmpl.Resource != nil {
+	if tmpl.Container != nil || tmpl.Script != nil || tmpl.Resource != nil || tmpl.Data != nil {
 		return true
 	}
 	return false
 }
 
+// MergeWorkflowDefaults strategic-merges the spec, labels and annotations of the workflow over the given
+// defaults, so that the values specified by the workflow take precedence. The rest of the workflow,
+// including its status, is left untouched.
+func MergeWorkflowDefaults(wf *wfv1.Workflow, defaults *wfv1.Workflow) error {
+	if defaults == nil {
+		return nil
+	}
+	defaultsBytes, err := json.Marshal(wfv1.Workflow{ObjectMeta: metav1.ObjectMeta{
+		Labels:      defaults.ObjectMeta.Labels,
+		Annotations: defaults.ObjectMeta.Annotations,
+	}, Spec: defaults.Spec})
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
+	wfBytes, err := json.Marshal(wfv1.Workflow{ObjectMeta: metav1.ObjectMeta{
+		Labels:      wf.ObjectMeta.Labels,
+		Annotations: wf.ObjectMeta.Annotations,
+	}, Spec: wf.Spec})
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
+	merged, err := strategicpatch.StrategicMergePatch(defaultsBytes, wfBytes, wfv1.Workflow{})
+	if err != nil {
+		return errors.Errorf(errors.CodeBadRequest, "failed to merge workflow defaults: %v", err)
+	}
+	var mergedWf wfv1.Workflow
+	err = json.Unmarshal(merged, &mergedWf)
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
+	wf.ObjectMeta.Labels = mergedWf.ObjectMeta.Labels
+	wf.ObjectMeta.Annotations = mergedWf.ObjectMeta.Annotations
+	wf.Spec = mergedWf.Spec
+	return nil
+}
+
 var yamlSeparator = regexp.MustCompile(`\n---`)
 
 // SplitWorkflowYAMLFile is a helper to split a body into multiple workflow objects
@@ -619,3 +656,47 @@ func GetTemplateHolderString(tmplHolder wfv1.TemplateHolder) string {
 		return fmt.Sprintf("%T (%s)", tmplHolder, tmplName)
 	}
 }
+
+// KubeResourceInterface gives artifact drivers created outside of a workflow pod, such as by the
+// controller or the CLI, access to the secrets and config maps of a namespace. Secrets are not
+// mounted as volumes there, so they are retrieved from the API server.
+type KubeResourceInterface struct {
+	KubeClient kubernetes.Interface
+	Namespace  string
+}
+
+// GetNamespace returns the namespace
+func (ri *KubeResourceInterface) GetNamespace() string {
+	return ri.Namespace
+}
+
+// GetSecrets retrieves a secret value
+func (ri *KubeResourceInterface) GetSecrets(namespace, name, key string) ([]byte, error) {
+	secret, err := ri.KubeClient.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.InternalWrapError(err)
+	}
+	val, ok := secret.Data[key]
+	if !ok {
+		return nil, errors.Errorf(errors.CodeBadRequest, "secret '%s' does not have the key '%s'", name, key)
+	}
+	return val, nil
+}
+
+// GetSecretFromVolMount retrieves a secret value of the namespace from the API server
+func (ri *KubeResourceInterface) GetSecretFromVolMount(name, key string) ([]byte, error) {
+	return ri.GetSecrets(ri.Namespace, name, key)
+}
+
+// GetConfigMapKey retrieves a config map value
+func (ri *KubeResourceInterface) GetConfigMapKey(namespace, name, key string) (string, error) {
+	cm, err := ri.KubeClient.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.InternalWrapError(err)
+	}
+	val, ok := cm.Data[key]
+	if !ok {
+		return "", errors.Errorf(errors.CodeBadRequest, "configmap '%s' does not have the key '%s'", name, key)
+	}
+	return val, nil
+}
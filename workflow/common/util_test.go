@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 )
@@ -24,3 +26,89 @@ func TestFindOverlappingVolume(t *testing.T) {
 	assert.Equal(t, &volMnt, FindOverlappingVolume(templateWithVolMount, "/user-mount/subdir"))
 	assert.Nil(t, FindOverlappingVolume(templateWithVolMount, "/user-mount-coincidental-prefix"))
 }
+
+// TestMergeWorkflowDefaults verifies the defaults apply unless the workflow specifies them
+func TestMergeWorkflowDefaults(t *testing.T) {
+	deadline := int64(300)
+	defaults := &wfv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"team": "default", "cost-center": "42"},
+		},
+		Spec: wfv1.WorkflowSpec{
+			ServiceAccountName:    "workflow",
+			ActiveDeadlineSeconds: &deadline,
+			PodGC:                 &wfv1.PodGC{Strategy: wfv1.PodGCOnWorkflowSuccess},
+			Tolerations:           []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+		},
+	}
+	wf := &wfv1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "my-wf",
+			ResourceVersion: "7",
+			Labels:          map[string]string{"team": "mine"},
+		},
+		Spec: wfv1.WorkflowSpec{
+			Entrypoint:         "main",
+			ServiceAccountName: "mine",
+			Templates:          []wfv1.Template{{Name: "main", Container: &corev1.Container{Image: "docker/whalesay"}}},
+		},
+		Status: wfv1.WorkflowStatus{
+			Phase: wfv1.NodeRunning,
+			Nodes: map[string]wfv1.NodeStatus{"my-wf": {ID: "my-wf", Phase: wfv1.NodeRunning}},
+		},
+	}
+	status := wf.Status.DeepCopy()
+	err := MergeWorkflowDefaults(wf, defaults)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-wf", wf.Name)
+	assert.Equal(t, "7", wf.ResourceVersion)
+	assert.Equal(t, *status, wf.Status)
+	assert.Equal(t, map[string]string{"team": "mine", "cost-center": "42"}, wf.Labels)
+	assert.Equal(t, "main", wf.Spec.Entrypoint)
+	assert.Equal(t, "mine", wf.Spec.ServiceAccountName)
+	if assert.NotNil(t, wf.Spec.ActiveDeadlineSeconds) {
+		assert.Equal(t, int64(300), *wf.Spec.ActiveDeadlineSeconds)
+	}
+	if assert.NotNil(t, wf.Spec.PodGC) {
+		assert.Equal(t, wfv1.PodGCOnWorkflowSuccess, wf.Spec.PodGC.Strategy)
+	}
+	assert.Len(t, wf.Spec.Tolerations, 1)
+	assert.Len(t, wf.Spec.Templates, 1)
+
+	// nil defaults leave the workflow unchanged
+	before := wf.DeepCopy()
+	err = MergeWorkflowDefaults(wf, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, before, wf)
+}
+
+// TestKubeResourceInterface verifies secrets and config maps are read from the API server
+func TestKubeResourceInterface(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-secret", Namespace: "my-ns"},
+			Data:       map[string][]byte{"accesskey": []byte("key")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-cm", Namespace: "my-ns"},
+			Data:       map[string]string{"endpoint": "minio:9000"},
+		},
+	)
+	ri := &KubeResourceInterface{KubeClient: kubeClient, Namespace: "my-ns"}
+
+	val, err := ri.GetSecretFromVolMount("my-secret", "accesskey")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "key", string(val))
+	}
+	_, err = ri.GetSecretFromVolMount("my-secret", "secretkey")
+	assert.Error(t, err)
+	_, err = ri.GetSecrets("other-ns", "my-secret", "accesskey")
+	assert.Error(t, err)
+
+	cmVal, err := ri.GetConfigMapKey("my-ns", "my-cm", "endpoint")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "minio:9000", cmVal)
+	}
+	_, err = ri.GetConfigMapKey("my-ns", "my-cm", "bucket")
+	assert.Error(t, err)
+}
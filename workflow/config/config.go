@@ -1,9 +1,13 @@
 package config
 
 import (
+	"github.com/argoproj/argo/errors"
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
 	"github.com/argoproj/argo/workflow/metrics"
 	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
 // WorkflowControllerConfig contain the configuration settings for the workflow controller
@@ -54,6 +58,9 @@ type WorkflowControllerConfig struct {
 
 	TelemetryConfig metrics.PrometheusConfig `json:"telemetryConfig,omitempty"`
 
+	// HealthConfig configures the server for the health endpoints of the controller
+	HealthConfig HealthConfig `json:"healthConfig,omitempty"`
+
 	// Parallelism limits the max total parallel workflows that can execute at the same time
 	Parallelism int `json:"parallelism,omitempty"`
 
@@ -62,6 +69,99 @@ type WorkflowControllerConfig struct {
 
 	// Config customized Docker Sock path
 	DockerSockPath string `json:"dockerSockPath,omitempty"`
+
+	// PodGC is the default strategy to delete completed pods of workflows which do not specify one
+	PodGC *wfv1.PodGC `json:"podGC,omitempty"`
+
+	// WorkflowDefaults are the values of the spec, labels and annotations of a workflow which apply
+	// unless the workflow itself specifies them. They are merged into a workflow once, when it starts,
+	// and persisted in the workflow.
+	WorkflowDefaults *wfv1.Workflow `json:"workflowDefaults,omitempty"`
+
+	// PodCreationRateLimit limits the rate at which the controller creates pods
+	PodCreationRateLimit *RateLimit `json:"podCreationRateLimit,omitempty"`
+
+	// WorkflowRequeueBackoff is the backoff of workflows which are requeued because their update
+	// failed to persist
+	WorkflowRequeueBackoff *RequeueBackoff `json:"workflowRequeueBackoff,omitempty"`
+
+	// TTLStrategy is the default time to live of finished workflows which specify neither a
+	// ttlStrategy nor ttlSecondsAfterFinished
+	TTLStrategy *wfv1.TTLStrategy `json:"ttlStrategy,omitempty"`
+}
+
+// RateLimit is a token bucket rate limit
+type RateLimit struct {
+	// Limit is the number of events per second
+	Limit float32 `json:"limit"`
+	// Burst is the number of events which may happen at once
+	Burst int `json:"burst"`
+}
+
+// RequeueBackoff is an exponential backoff per item of a queue
+type RequeueBackoff struct {
+	// BaseDelay is the delay of the first requeue, which doubles with each following requeue.
+	// Defaults to 5ms.
+	BaseDelay metav1.Duration `json:"baseDelay,omitempty"`
+	// MaxDelay is the longest delay of a requeue. Defaults to 1000s.
+	MaxDelay metav1.Duration `json:"maxDelay,omitempty"`
+}
+
+// NamespaceConfig is the subset of the controller configuration which a namespace can override for
+// its workflows, with a configmap labeled workflows.argoproj.io/configmap-type: Parameter
+type NamespaceConfig struct {
+	// ArtifactRepository replaces the default artifact repository of the controller
+	ArtifactRepository *ArtifactRepository `json:"artifactRepository,omitempty"`
+
+	// ExecutorResources replaces the resource requirements of the executor sidecar
+	ExecutorResources *apiv1.ResourceRequirements `json:"executorResources,omitempty"`
+
+	// ServiceAccountName is the service account of the workflows which do not specify one
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Parallelism limits the max total parallel workflows of the namespace that can execute at the same time
+	Parallelism int `json:"parallelism,omitempty"`
+}
+
+// UnmarshalNamespaceConfig parses the overrides of a namespace from the config key of its configmap
+func UnmarshalNamespaceConfig(cm *apiv1.ConfigMap) (*NamespaceConfig, error) {
+	var nc NamespaceConfig
+	err := yaml.Unmarshal([]byte(cm.Data[common.WorkflowControllerConfigMapKey]), &nc)
+	if err != nil {
+		return nil, errors.Errorf(errors.CodeBadRequest, "ConfigMap '%s/%s' has an invalid config: %v", cm.Namespace, cm.Name, err)
+	}
+	return &nc, nil
+}
+
+// ForNamespace returns the configuration which is in effect for the workflows of a namespace,
+// given the overrides of the namespace. The parallelism is the limit which applies to the namespace.
+func (c WorkflowControllerConfig) ForNamespace(nc *NamespaceConfig) WorkflowControllerConfig {
+	if nc == nil {
+		return c
+	}
+	if nc.ArtifactRepository != nil {
+		c.ArtifactRepository = *nc.ArtifactRepository
+	}
+	if nc.ExecutorResources != nil {
+		executor := apiv1.Container{}
+		if c.Executor != nil {
+			executor = *c.Executor.DeepCopy()
+		}
+		executor.Resources = *nc.ExecutorResources
+		c.Executor = &executor
+	}
+	if nc.ServiceAccountName != "" {
+		defaults := wfv1.Workflow{}
+		if c.WorkflowDefaults != nil {
+			defaults = *c.WorkflowDefaults.DeepCopy()
+		}
+		defaults.Spec.ServiceAccountName = nc.ServiceAccountName
+		c.WorkflowDefaults = &defaults
+	}
+	if nc.Parallelism > 0 && (c.Parallelism < 1 || nc.Parallelism < c.Parallelism) {
+		c.Parallelism = nc.Parallelism
+	}
+	return c
 }
 
 // KubeConfig is used for wait & init sidecar containers to communicate with a k8s apiserver by a outofcluster method,
@@ -79,6 +179,16 @@ type KubeConfig struct {
 	MountPath string `json:"mountPath,omitempty"`
 }
 
+// HealthConfig configures the HTTP server of the controller which serves /healthz and /readyz
+type HealthConfig struct {
+	// Disabled turns off the server, which is started by default
+	Disabled bool `json:"disabled,omitempty"`
+	// Port the server listens on, defaults to 6060
+	Port string `json:"port,omitempty"`
+	// Pprof additionally serves the runtime profiles of the controller under /debug/pprof
+	Pprof bool `json:"pprof,omitempty"`
+}
+
 // ArtifactRepository represents a artifact repository in which a controller will store its artifacts
 type ArtifactRepository struct {
 	// ArchiveLogs enables log archiving
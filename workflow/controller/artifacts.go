@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/argoproj/argo/errors"
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	artifact "github.com/argoproj/argo/workflow/artifacts"
+	"github.com/argoproj/argo/workflow/common"
+)
+
+// maxArtifactParamSize is the largest artifact the controller will load in order to expand a withParam.
+// The loaded lists are kept in the memory of the controller.
+const maxArtifactParamSize = 256 * 1024
+
+// withParamArtifactTTL is how long a list loaded from an artifact is kept after it was last used
+const withParamArtifactTTL = 10 * time.Minute
+
+// artifactRefRegex matches a reference to an artifact, e.g. {{steps.generate.outputs.artifacts.items}}
+var artifactRefRegex = regexp.MustCompile(`^\{\{[^{}]+\.artifacts\.[^{}]+\}\}$`)
+
+// errWithParamLoading indicates the artifact of a withParam is being loaded in the background
+var errWithParamLoading = errors.New(errors.CodeTimeout, "withParam artifact is being loaded")
+
+// withParamArtifacts holds the JSON lists loaded from the artifacts referenced by withParam. The lists are
+// kept in memory rather than in the workflow so that large fan-outs do not grow the workflow object, and are
+// loaded again from the artifacts after a restart. It is keyed by the UID of the workflow and the location
+// of the artifact.
+type withParamArtifacts struct {
+	lock    sync.Mutex
+	entries map[string]*withParamArtifact
+}
+
+// withParamArtifact is the outcome of loading the artifact of a withParam
+type withParamArtifact struct {
+	loaded   bool
+	list     string
+	err      error
+	lastUsed time.Time
+}
+
+// get returns the list loaded from an artifact. If it was not loaded yet, it starts loading it in the
+// background and returns errWithParamLoading. The workflow is requeued once the artifact was loaded.
+func (c *withParamArtifacts) get(wfc *WorkflowController, key string, wfKey string, load func() ([]byte, error)) (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := time.Now()
+	if c.entries == nil {
+		c.entries = make(map[string]*withParamArtifact)
+	}
+	for k, entry := range c.entries {
+		if entry.loaded && now.Sub(entry.lastUsed) > withParamArtifactTTL {
+			delete(c.entries, k)
+		}
+	}
+	entry, ok := c.entries[key]
+	if !ok {
+		c.entries[key] = &withParamArtifact{lastUsed: now}
+		go func() {
+			data, err := load()
+			c.lock.Lock()
+			c.entries[key] = &withParamArtifact{loaded: true, list: string(data), err: err, lastUsed: time.Now()}
+			c.lock.Unlock()
+			wfc.wfQueue.Add(wfKey)
+		}()
+		return "", errWithParamLoading
+	}
+	if !entry.loaded {
+		return "", errWithParamLoading
+	}
+	entry.lastUsed = now
+	return entry.list, entry.err
+}
+
+// resolveWithParam returns the JSON list to expand a withParam from. If withParam is a reference to an
+// artifact, the list is loaded from the artifact in the background, and errWithParamLoading is returned
+// until it was loaded. Otherwise withParam is returned as is.
+func (woc *wfOperationCtx) resolveWithParam(withParam string, scope *wfScope) (string, error) {
+	ref := strings.TrimSpace(withParam)
+	if !artifactRefRegex.MatchString(ref) {
+		return withParam, nil
+	}
+	art, err := scope.resolveArtifact(ref)
+	if err != nil {
+		return "", err
+	}
+	location, err := json.Marshal(art.ArtifactLocation)
+	if err != nil {
+		return "", errors.InternalWrapError(err)
+	}
+	key := fmt.Sprintf("%s/%s", woc.wf.ObjectMeta.UID, location)
+	namespace := woc.wf.ObjectMeta.Namespace
+	list, err := woc.controller.withParamArtifacts.get(woc.controller, key, woc.key(), func() ([]byte, error) {
+		return woc.controller.loadArtifact(art, namespace)
+	})
+	if err != nil && err != errWithParamLoading {
+		return "", errors.Errorf(errors.CodeBadRequest, "withParam artifact %s could not be loaded: %v", ref, err)
+	}
+	return list, err
+}
+
+// loadArtifact loads the contents of an artifact of a workflow in a namespace into memory. Artifacts which
+// were archived as a tarball (the default for output artifacts) are expected to contain a single file.
+func (wfc *WorkflowController) loadArtifact(art *wfv1.Artifact, namespace string) ([]byte, error) {
+	// Only the drivers of artifact repositories are run by the controller. The others would make
+	// arbitrary requests from the controller, or modify its environment, e.g. the git driver.
+	if art.S3 == nil && art.Artifactory == nil && art.Raw == nil {
+		return nil, errors.Errorf(errors.CodeBadRequest, "artifact %s must be an s3, artifactory or raw artifact", art.Name)
+	}
+	driver, err := artifact.NewDriver(*art, &common.KubeResourceInterface{KubeClient: wfc.kubeclientset, Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+	tmpDir, err := ioutil.TempDir("", "artifact")
+	if err != nil {
+		return nil, errors.InternalWrapError(err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	path := filepath.Join(tmpDir, art.Name)
+	err = driver.Load(art, path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.InternalWrapError(err)
+	}
+	if info.IsDir() {
+		return nil, errors.Errorf(errors.CodeBadRequest, "artifact %s is a directory", art.Name)
+	}
+	if info.Size() > maxArtifactParamSize {
+		return nil, errors.Errorf(errors.CodeBadRequest, "artifact %s exceeds the maximum size of %d bytes", art.Name, maxArtifactParamSize)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.InternalWrapError(err)
+	}
+	return untarSingleFile(data)
+}
+
+// untarSingleFile returns the contents of the only file in a gzipped tarball. Data which is not
+// gzipped is returned as is.
+func untarSingleFile(data []byte) ([]byte, error) {
+	gzr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return data, nil
+	}
+	defer func() { _ = gzr.Close() }()
+	tr := tar.NewReader(gzr)
+	var contents []byte
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.InternalWrapError(err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if found {
+			return nil, errors.Errorf(errors.CodeBadRequest, "expected a single file in the artifact tarball")
+		}
+		if hdr.Size > maxArtifactParamSize {
+			return nil, errors.Errorf(errors.CodeBadRequest, "artifact exceeds the maximum size of %d bytes", maxArtifactParamSize)
+		}
+		contents, err = ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, errors.InternalWrapError(err)
+		}
+		found = true
+	}
+	if !found {
+		return nil, errors.Errorf(errors.CodeBadRequest, "artifact tarball contains no files")
+	}
+	return contents, nil
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+)
+
+func newTarball(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for name, contents := range files {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg})
+		assert.NoError(t, err)
+		_, err = tw.Write([]byte(contents))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gzw.Close())
+	return buf.Bytes()
+}
+
+func TestUntarSingleFile(t *testing.T) {
+	data, err := untarSingleFile([]byte(`["a"]`))
+	assert.NoError(t, err)
+	assert.Equal(t, `["a"]`, string(data))
+
+	data, err = untarSingleFile(newTarball(t, map[string]string{"items.json": `["a", "b"]`}))
+	assert.NoError(t, err)
+	assert.Equal(t, `["a", "b"]`, string(data))
+
+	_, err = untarSingleFile(newTarball(t, map[string]string{"a.json": "[]", "b.json": "[]"}))
+	assert.Error(t, err)
+}
+
+// waitForWithParamArtifacts waits for the artifacts of withParam being loaded in the background
+func waitForWithParamArtifacts(t *testing.T, controller *WorkflowController) {
+	c := &controller.withParamArtifacts
+	for i := 0; i < 100; i++ {
+		loading := false
+		c.lock.Lock()
+		for _, entry := range c.entries {
+			loading = loading || !entry.loaded
+		}
+		c.lock.Unlock()
+		if !loading {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("withParam artifacts were not loaded")
+}
+
+// TestWithParamArtifactsGet verifies an artifact is loaded once in the background
+func TestWithParamArtifactsGet(t *testing.T) {
+	controller := newController()
+	var c withParamArtifacts
+	loads := 0
+	load := func() ([]byte, error) {
+		loads++
+		return []byte(`["a"]`), nil
+	}
+	_, err := c.get(controller, "uid/location", "default/wf", load)
+	assert.Equal(t, errWithParamLoading, err)
+	waitForWithParamArtifacts(t, &WorkflowController{withParamArtifacts: withParamArtifacts{entries: c.entries}})
+	list, err := c.get(controller, "uid/location", "default/wf", load)
+	assert.NoError(t, err)
+	assert.Equal(t, `["a"]`, list)
+	assert.Equal(t, 1, loads)
+	// the workflow was requeued once the artifact was loaded
+	assert.Equal(t, 1, controller.wfQueue.Len())
+
+	// lists which were not used for a while are forgotten
+	c.entries["uid/location"].lastUsed = time.Now().Add(-2 * withParamArtifactTTL)
+	_, err = c.get(controller, "uid/other", "default/wf", load)
+	assert.Equal(t, errWithParamLoading, err)
+	c.lock.Lock()
+	_, ok := c.entries["uid/location"]
+	c.lock.Unlock()
+	assert.False(t, ok)
+}
+
+func TestResolveWithParam(t *testing.T) {
+	woc := newWoc()
+	scope := &wfScope{scope: make(map[string]interface{})}
+	scope.addArtifactToScope("steps.generate.outputs.artifacts.items", wfv1.Artifact{
+		Name:             "items",
+		ArtifactLocation: wfv1.ArtifactLocation{Raw: &wfv1.RawArtifact{Data: `["a", "b"]`}},
+	})
+
+	_, err := woc.resolveWithParam("{{steps.generate.outputs.artifacts.items}}", scope)
+	assert.Equal(t, errWithParamLoading, err)
+	waitForWithParamArtifacts(t, woc.controller)
+	withParam, err := woc.resolveWithParam("{{steps.generate.outputs.artifacts.items}}", scope)
+	assert.NoError(t, err)
+	assert.Equal(t, `["a", "b"]`, withParam)
+
+	// parameters are left as is
+	withParam, err = woc.resolveWithParam(`["c"]`, scope)
+	assert.NoError(t, err)
+	assert.Equal(t, `["c"]`, withParam)
+
+	_, err = woc.resolveWithParam("{{steps.missing.outputs.artifacts.items}}", scope)
+	assert.Error(t, err)
+
+	// the controller does not run the drivers of other artifacts
+	scope.addArtifactToScope("steps.clone.outputs.artifacts.repo", wfv1.Artifact{
+		Name:             "repo",
+		ArtifactLocation: wfv1.ArtifactLocation{Git: &wfv1.GitArtifact{Repo: "https://github.com/argoproj/argo.git"}},
+	})
+	_, err = woc.resolveWithParam("{{steps.clone.outputs.artifacts.repo}}", scope)
+	assert.Equal(t, errWithParamLoading, err)
+	waitForWithParamArtifacts(t, woc.controller)
+	_, err = woc.resolveWithParam("{{steps.clone.outputs.artifacts.repo}}", scope)
+	assert.EqualError(t, err, "withParam artifact {{steps.clone.outputs.artifacts.repo}} could not be loaded: artifact repo must be an s3, artifactory or raw artifact")
+}
@@ -13,6 +13,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/argoproj/argo/errors"
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 	"github.com/argoproj/argo/workflow/common"
 	"github.com/argoproj/argo/workflow/config"
 	log "github.com/sirupsen/logrus"
@@ -30,6 +31,12 @@ func (wfc *WorkflowController) ResyncConfig() error {
 }
 
 func (wfc *WorkflowController) updateConfig(cm *apiv1.ConfigMap) error {
+	err := wfc.applyConfig(cm)
+	wfc.health.setConfigResult(err)
+	return err
+}
+
+func (wfc *WorkflowController) applyConfig(cm *apiv1.ConfigMap) error {
 	configStr, ok := cm.Data[common.WorkflowControllerConfigMapKey]
 	if !ok {
 		log.Warnf("ConfigMap '%s' does not have key '%s'", wfc.configMap, common.WorkflowControllerConfigMapKey)
@@ -44,6 +51,10 @@ func (wfc *WorkflowController) updateConfig(cm *apiv1.ConfigMap) error {
 	if wfc.cliExecutorImage == "" && config.ExecutorImage == "" {
 		return errors.Errorf(errors.CodeBadRequest, "ConfigMap '%s' does not have executorImage", wfc.configMap)
 	}
+	err = common.MergeWorkflowDefaults(&wfv1.Workflow{}, config.WorkflowDefaults)
+	if err != nil {
+		return errors.Errorf(errors.CodeBadRequest, "ConfigMap '%s' has invalid workflowDefaults: %v", wfc.configMap, err)
+	}
 	wfc.Config = config
 
 	if wfc.Config.Persistence != nil {
@@ -59,6 +70,10 @@ func (wfc *WorkflowController) updateConfig(cm *apiv1.ConfigMap) error {
 		wfc.wfDBctx = nil
 	}
 	wfc.throttler.SetParallelism(config.Parallelism)
+	wfc.podCreationLimiter.set(config.PodCreationRateLimit)
+	if wfc.requeueRateLimiter != nil {
+		wfc.requeueRateLimiter.setBackoff(config.WorkflowRequeueBackoff)
+	}
 	return nil
 }
 
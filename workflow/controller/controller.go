@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
@@ -23,6 +25,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/argoproj/argo"
@@ -62,9 +65,41 @@ type WorkflowController struct {
 	wfQueue        workqueue.RateLimitingInterface
 	podQueue       workqueue.RateLimitingInterface
 	completedPods  chan string
-	gcPods         chan string // pods to be deleted depend on GC strategy
+	gcPods         workqueue.DelayingInterface // pods to be deleted, after the delay of their GC strategy
 	throttler      Throttler
 	wfDBctx        sqldb.DBRepository
+	// httpRequests are the requests of HTTP templates sent by the controller
+	httpRequests httpRequests
+	// withParamArtifacts are the lists loaded from the artifacts referenced by withParam
+	withParamArtifacts withParamArtifacts
+
+	// leader is 1 while this replica is running the controller, i.e. while it is the leader
+	leader int32
+	// leaderHealthz reports a leader which failed to renew its lease as unhealthy
+	leaderHealthz *leaderelection.HealthzAdaptor
+	// health is the state of the controller reported by the health server
+	health healthState
+
+	// namespaceConfigs are the overrides of the configuration per namespace
+	namespaceConfigs namespaceConfigs
+
+	// podCreationLimiter limits the rate at which pods are created
+	podCreationLimiter podCreationLimiter
+	// requeueRateLimiter is the rate limiter of wfQueue
+	requeueRateLimiter *requeueRateLimiter
+
+	// shuttingDown is 1 once the controller started shutting down
+	shuttingDown int32
+	// shutdownTimeout bounds how long the controller waits for in-flight work on shutdown
+	shutdownTimeout time.Duration
+	// inFlight are the keys of the workflows which are being operated on
+	inFlight keySet
+	// gcPodsPending are the keys of the pods on the gcPods queue, including the delayed ones
+	gcPodsPending keySet
+
+	// shard is the shard of the workflows the controller operates on, out of shards
+	shard  int
+	shards int
 }
 
 const (
@@ -72,6 +107,8 @@ const (
 	workflowTemplateResyncPeriod = 20 * time.Minute
 	workflowMetricsResyncPeriod  = 1 * time.Minute
 	podResyncPeriod              = 30 * time.Minute
+	// leaderHealthzTimeout is how long the lease of a leader may be expired before it is unhealthy
+	leaderHealthzTimeout = 20 * time.Second
 )
 
 // NewWorkflowController instantiates a new WorkflowController
@@ -92,11 +129,14 @@ func NewWorkflowController(
 		namespace:                  namespace,
 		cliExecutorImage:           executorImage,
 		cliExecutorImagePullPolicy: executorImagePullPolicy,
-		wfQueue:                    workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
-		podQueue:                   workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		requeueRateLimiter:         newRequeueRateLimiter(),
+		podQueue:                   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "pod-queue"),
 		completedPods:              make(chan string, 512),
-		gcPods:                     make(chan string, 512),
+		gcPods:                     workqueue.NewNamedDelayingQueue("pod-gc-queue"),
+		leaderHealthz:              leaderelection.NewLeaderHealthzAdaptor(leaderHealthzTimeout),
+		shutdownTimeout:            defaultShutdownTimeout,
 	}
+	wfc.wfQueue = workqueue.NewNamedRateLimitingQueue(wfc.requeueRateLimiter, "workflow-queue")
 	wfc.throttler = NewThrottler(0, wfc.wfQueue)
 	return &wfc
 }
@@ -114,7 +154,7 @@ func (wfc *WorkflowController) MetricsServer(ctx context.Context) {
 // TelemetryServer starts a prometheus telemetry server if enabled in the configmap
 func (wfc *WorkflowController) TelemetryServer(ctx context.Context) {
 	if wfc.Config.TelemetryConfig.Enabled {
-		registry := metrics.NewTelemetryRegistry()
+		registry := metrics.NewTelemetryRegistry(wfc.IsLeader)
 		metrics.RunServer(ctx, wfc.Config.TelemetryConfig, registry)
 	}
 }
@@ -126,6 +166,7 @@ func (wfc *WorkflowController) RunTTLController(ctx context.Context) {
 		wfc.wfclientset,
 		wfc.Config.Namespace,
 		wfc.Config.InstanceID,
+		wfc.Config.TTLStrategy,
 	)
 	err := ttlCtrl.Run(ctx.Done())
 	if err != nil {
@@ -137,6 +178,8 @@ func (wfc *WorkflowController) RunTTLController(ctx context.Context) {
 func (wfc *WorkflowController) Run(ctx context.Context, wfWorkers, podWorkers int) {
 	defer wfc.wfQueue.ShutDown()
 	defer wfc.podQueue.ShutDown()
+	atomic.StoreInt32(&wfc.leader, 1)
+	defer atomic.StoreInt32(&wfc.leader, 0)
 
 	log.Infof("Workflow Controller (version: %s) starting", argo.GetVersion())
 	log.Infof("Workers: workflow: %d, pod: %d", wfWorkers, podWorkers)
@@ -146,6 +189,7 @@ func (wfc *WorkflowController) Run(ctx context.Context, wfWorkers, podWorkers in
 		log.Errorf("Failed to register watch for controller config map: %v", err)
 		return
 	}
+	nsConfigController := wfc.watchNamespaceConfigMaps(ctx)
 
 	wfc.wfInformer = util.NewWorkflowInformer(wfc.restConfig, wfc.Config.Namespace, workflowResyncPeriod, wfc.tweakWorkflowlist)
 	wfc.wftmplInformer = wfc.newWorkflowTemplateInformer()
@@ -156,8 +200,6 @@ func (wfc *WorkflowController) Run(ctx context.Context, wfWorkers, podWorkers in
 	go wfc.wfInformer.Run(ctx.Done())
 	go wfc.wftmplInformer.Informer().Run(ctx.Done())
 	go wfc.podInformer.Run(ctx.Done())
-	go wfc.podLabeler(ctx.Done())
-	go wfc.podGarbageCollector(ctx.Done())
 
 	// Wait for all involved caches to be synced, before processing items from the queue is started
 	for _, informer := range []cache.SharedIndexInformer{wfc.wfInformer, wfc.wftmplInformer.Informer(), wfc.podInformer} {
@@ -166,14 +208,47 @@ func (wfc *WorkflowController) Run(ctx context.Context, wfWorkers, podWorkers in
 			return
 		}
 	}
-
+	// Wait for the overrides of the namespaces, so their parallelism applies from the start
+	if !cache.WaitForCacheSync(ctx.Done(), nsConfigController.HasSynced) {
+		log.Error("Timed out waiting for the namespace configmaps to sync")
+		return
+	}
+	wfc.health.setCachesSynced(true)
+	defer wfc.health.setCachesSynced(false)
+
+	// The pod labeler and garbage collector keep running after the context is done, until the
+	// workers stopped and the pods they queued were flushed
+	stopBackground := make(chan struct{})
+	var background sync.WaitGroup
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		wfc.podLabeler(stopBackground)
+	}()
+	go func() {
+		defer background.Done()
+		wfc.podGarbageCollector(stopBackground)
+	}()
+	// resume the deletion of the pods which a previous controller did not delete
+	wfc.queuePodsAnnotatedForGC()
+
+	wfc.health.setExpectedWorkers(wfWorkers + podWorkers)
+	var workers sync.WaitGroup
+	workers.Add(wfWorkers + podWorkers)
 	for i := 0; i < wfWorkers; i++ {
-		go wait.Until(wfc.runWorker, time.Second, ctx.Done())
+		go func() {
+			defer workers.Done()
+			wait.Until(wfc.runWorker, time.Second, ctx.Done())
+		}()
 	}
 	for i := 0; i < podWorkers; i++ {
-		go wait.Until(wfc.podWorker, time.Second, ctx.Done())
+		go func() {
+			defer workers.Done()
+			wait.Until(wfc.podWorker, time.Second, ctx.Done())
+		}()
 	}
 	<-ctx.Done()
+	wfc.shutdown(&workers, stopBackground, &background)
 }
 
 // podLabeler will label all pods on the controllers completedPod channel as completed
@@ -181,52 +256,79 @@ func (wfc *WorkflowController) podLabeler(stopCh <-chan struct{}) {
 	for {
 		select {
 		case <-stopCh:
-			return
-		case pod := <-wfc.completedPods:
-			parts := strings.Split(pod, "/")
-			if len(parts) != 2 {
-				log.Warnf("Unexpected item on completed pod channel: %s", pod)
-				continue
-			}
-			namespace := parts[0]
-			podName := parts[1]
-			err := common.AddPodLabel(wfc.kubeclientset, podName, namespace, common.LabelKeyCompleted, "true")
-			if err != nil {
-				if !apierr.IsNotFound(err) {
-					log.Errorf("Failed to label pod %s/%s completed: %+v", namespace, podName, err)
+			// flush the pods which are still waiting to be labeled
+			for {
+				select {
+				case pod := <-wfc.completedPods:
+					wfc.labelPodCompleted(pod)
+				default:
+					return
 				}
-			} else {
-				log.Infof("Labeled pod %s/%s completed", namespace, podName)
 			}
+		case pod := <-wfc.completedPods:
+			wfc.labelPodCompleted(pod)
 		}
 	}
 }
 
-// podGarbageCollector will delete all pods on the controllers gcPods channel as completed
-func (wfc *WorkflowController) podGarbageCollector(stopCh <-chan struct{}) {
-	for {
-		select {
-		case <-stopCh:
-			return
-		case pod := <-wfc.gcPods:
-			parts := strings.Split(pod, "/")
-			if len(parts) != 2 {
-				log.Warnf("Unexpected item on gcPods channel: %s", pod)
-				continue
-			}
-			namespace := parts[0]
-			podName := parts[1]
-			err := common.DeletePod(wfc.kubeclientset, podName, namespace)
-			if err != nil {
-				log.Errorf("Failed to delete pod %s/%s for gc: %+v", namespace, podName, err)
-			} else {
-				log.Infof("Delete pod %s/%s for gc successfully", namespace, podName)
-			}
+// labelPodCompleted labels a pod from the completedPods channel as completed
+func (wfc *WorkflowController) labelPodCompleted(pod string) {
+	parts := strings.Split(pod, "/")
+	if len(parts) != 2 {
+		log.Warnf("Unexpected item on completed pod channel: %s", pod)
+		return
+	}
+	namespace := parts[0]
+	podName := parts[1]
+	err := common.AddPodLabel(wfc.kubeclientset, podName, namespace, common.LabelKeyCompleted, "true")
+	if err != nil {
+		if !apierr.IsNotFound(err) {
+			log.Errorf("Failed to label pod %s/%s completed: %+v", namespace, podName, err)
 		}
+	} else {
+		log.Infof("Labeled pod %s/%s completed", namespace, podName)
+	}
+}
+
+// podGarbageCollector will delete all pods on the controllers gcPods queue once their delay passed.
+// Once stopped, it deletes the pods whose delay already passed, and abandons the others.
+func (wfc *WorkflowController) podGarbageCollector(stopCh <-chan struct{}) {
+	go func() {
+		<-stopCh
+		wfc.gcPods.ShutDown()
+	}()
+	for wfc.processNextGCPod() {
 	}
 }
 
+// processNextGCPod deletes the next pod from the gcPods queue
+func (wfc *WorkflowController) processNextGCPod() bool {
+	key, quit := wfc.gcPods.Get()
+	if quit {
+		return false
+	}
+	defer wfc.gcPods.Done(key)
+	pod := key.(string)
+	defer wfc.gcPodsPending.remove(pod)
+	parts := strings.Split(pod, "/")
+	if len(parts) != 2 {
+		log.Warnf("Unexpected item on gcPods queue: %s", pod)
+		return true
+	}
+	namespace := parts[0]
+	podName := parts[1]
+	err := common.DeletePod(wfc.kubeclientset, podName, namespace)
+	if err != nil {
+		log.Errorf("Failed to delete pod %s/%s for gc: %+v", namespace, podName, err)
+	} else {
+		metrics.PodDeleted()
+		log.Infof("Delete pod %s/%s for gc successfully", namespace, podName)
+	}
+	return true
+}
+
 func (wfc *WorkflowController) runWorker() {
+	defer wfc.health.workerStarted()()
 	for wfc.processNextItem() {
 	}
 }
@@ -238,6 +340,12 @@ func (wfc *WorkflowController) processNextItem() bool {
 		return false
 	}
 	defer wfc.wfQueue.Done(key)
+	if wfc.isShuttingDown() {
+		// the workflow is left to the next controller
+		return false
+	}
+	wfc.inFlight.add(key.(string))
+	defer wfc.inFlight.remove(key.(string))
 
 	obj, exists, err := wfc.wfInformer.GetIndexer().GetByKey(key.(string))
 	if err != nil {
@@ -257,10 +365,18 @@ func (wfc *WorkflowController) processNextItem() bool {
 		return true
 	}
 
-	if key, ok = wfc.throttler.Next(key); !ok {
+	next, ok := wfc.throttler.Next(key)
+	if !ok {
 		log.Warnf("Workflow %s processing has been postponed due to max parallelism limit", key)
 		return true
 	}
+	if next != key {
+		// a pending workflow of higher priority was started instead, which is operated on once it is
+		// processed, and this workflow is retried
+		wfc.wfQueue.Add(next)
+		wfc.wfQueue.Add(key)
+		return true
+	}
 
 	wf, err := util.FromUnstructured(un)
 	if err != nil {
@@ -279,6 +395,17 @@ func (wfc *WorkflowController) processNextItem() bool {
 		return true
 	}
 
+	owned, err := wfc.reconcileShard(wf)
+	if err != nil {
+		log.Warnf("Failed to assign workflow '%s' to a shard: %v", key, err)
+		wfc.wfQueue.AddRateLimited(key)
+		return true
+	}
+	if !owned {
+		wfc.throttler.Remove(key)
+		return true
+	}
+
 	// Loading running workflow from persistence storage if NodeStatusOffload enabled
 	if wfc.wfDBctx != nil && wfc.wfDBctx.IsNodeStatusOffload() {
 		wfDB, err := wfc.wfDBctx.Get(string(wf.UID))
@@ -290,6 +417,13 @@ func (wfc *WorkflowController) processNextItem() bool {
 		}
 	}
 
+	err = wfc.applyWorkflowDefaults(wf)
+	if err != nil {
+		log.Warnf("Failed to apply the workflow defaults to '%s': %v", key, err)
+		wfc.wfQueue.AddRateLimited(key)
+		return true
+	}
+
 	woc := newWorkflowOperationCtx(wf, wfc)
 
 	// Decompress the node if it is compressed
@@ -304,10 +438,10 @@ func (wfc *WorkflowController) processNextItem() bool {
 	woc.operate()
 	if woc.wf.Status.Completed() {
 		wfc.throttler.Remove(key)
-		// Send all completed pods to gcPods channel to delete it later depend on the PodGCStrategy.
+		// Queue all completed pods to be deleted later depending on the PodGCStrategy.
 		var doPodGC bool
-		if woc.wf.Spec.PodGC != nil {
-			switch woc.wf.Spec.PodGC.Strategy {
+		if podGC := woc.podGC(); podGC != nil {
+			switch podGC.Strategy {
 			case wfv1.PodGCOnWorkflowCompletion:
 				doPodGC = true
 			case wfv1.PodGCOnWorkflowSuccess:
@@ -317,10 +451,7 @@ func (wfc *WorkflowController) processNextItem() bool {
 			}
 		}
 		if doPodGC {
-			for podName := range woc.completedPods {
-				pod := fmt.Sprintf("%s/%s", woc.wf.ObjectMeta.Namespace, podName)
-				woc.controller.gcPods <- pod
-			}
+			woc.queuePodsForGC(woc.completedPods)
 		}
 	}
 
@@ -331,7 +462,18 @@ func (wfc *WorkflowController) processNextItem() bool {
 	return true
 }
 
+// applyWorkflowDefaults merges the workflow defaults of the namespace into a workflow which has not started
+// yet. The defaults are persisted in the workflow along with its status, so they are applied only once, and
+// a change of the defaults does not affect the workflows which are running.
+func (wfc *WorkflowController) applyWorkflowDefaults(wf *wfv1.Workflow) error {
+	if wf.Status.Phase != "" {
+		return nil
+	}
+	return common.MergeWorkflowDefaults(wf, wfc.configForNamespace(wf.Namespace).WorkflowDefaults)
+}
+
 func (wfc *WorkflowController) podWorker() {
+	defer wfc.health.workerStarted()()
 	for wfc.processNextPodItem() {
 	}
 }
@@ -345,6 +487,9 @@ func (wfc *WorkflowController) processNextPodItem() bool {
 		return false
 	}
 	defer wfc.podQueue.Done(key)
+	if wfc.isShuttingDown() {
+		return false
+	}
 
 	obj, exists, err := wfc.podInformer.GetIndexer().GetByKey(key.(string))
 	if err != nil {
@@ -389,12 +534,14 @@ func (wfc *WorkflowController) tweakWorkflowlist(options *metav1.ListOptions) {
 	labelSelector := labels.NewSelector().
 		Add(*incompleteReq).
 		Add(util.InstanceIDRequirement(wfc.Config.InstanceID))
+	labelSelector = wfc.addShardRequirement(labelSelector)
 	options.LabelSelector = labelSelector.String()
 }
 
 func (wfc *WorkflowController) tweakWorkflowMetricslist(options *metav1.ListOptions) {
 	options.FieldSelector = fields.Everything().String()
 	labelSelector := labels.NewSelector().Add(util.InstanceIDRequirement(wfc.Config.InstanceID))
+	labelSelector = wfc.addShardRequirement(labelSelector)
 	options.LabelSelector = labelSelector.String()
 }
 
@@ -455,6 +602,7 @@ func (wfc *WorkflowController) newWorkflowPodWatch() *cache.ListWatch {
 	labelSelector := labels.NewSelector().
 		Add(*incompleteReq).
 		Add(util.InstanceIDRequirement(wfc.Config.InstanceID))
+	labelSelector = wfc.addShardRequirement(labelSelector)
 
 	listFunc := func(options metav1.ListOptions) (runtime.Object, error) {
 		options.LabelSelector = labelSelector.String()
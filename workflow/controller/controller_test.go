@@ -16,6 +16,8 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/util/workqueue"
 
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 	fakewfclientset "github.com/argoproj/argo/pkg/client/clientset/versioned/fake"
@@ -54,15 +56,20 @@ func newController() *WorkflowController {
 	if !cache.WaitForCacheSync(ctx.Done(), wftmplInformer.Informer().HasSynced) {
 		panic("Timed out waiting for caches to sync")
 	}
-	return &WorkflowController{
+	wfc := &WorkflowController{
 		Config: config.WorkflowControllerConfig{
 			ExecutorImage: "executor:latest",
 		},
 		kubeclientset:  fake.NewSimpleClientset(),
 		wfclientset:    wfclientset,
 		completedPods:  make(chan string, 512),
+		gcPods:         workqueue.NewDelayingQueue(),
 		wftmplInformer: wftmplInformer,
+		leaderHealthz:  leaderelection.NewLeaderHealthzAdaptor(leaderHealthzTimeout),
 	}
+	wfc.wfQueue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	wfc.throttler = NewThrottler(0, wfc.wfQueue)
+	return wfc
 }
 
 func marshallBody(b interface{}) io.ReadCloser {
@@ -92,3 +99,18 @@ func makePodsRunning(t *testing.T, kubeclientset kubernetes.Interface, namespace
 		_, _ = podcs.Update(&pod)
 	}
 }
+
+// makePodsPhase acts like a pod controller and simulates the transition of all pods which have not
+// completed yet into the given phase
+func makePodsPhase(t *testing.T, kubeclientset kubernetes.Interface, namespace string, phase apiv1.PodPhase) {
+	podcs := kubeclientset.CoreV1().Pods(namespace)
+	pods, err := podcs.List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == apiv1.PodSucceeded || pod.Status.Phase == apiv1.PodFailed {
+			continue
+		}
+		pod.Status.Phase = phase
+		_, _ = podcs.Update(&pod)
+	}
+}
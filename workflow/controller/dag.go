@@ -356,12 +356,31 @@ func (woc *wfOperationCtx) executeDAGTask(dagCtx *dagContext, taskName string) {
 
 	// First resolve/substitute params/artifacts from our dependencies
 	newTask, err := woc.resolveDependencyReferences(dagCtx, task)
+	if err == errWithParamLoading {
+		return
+	}
 	if err != nil {
 		woc.initializeNode(nodeName, wfv1.NodeTypeSkipped, task, dagCtx.boundaryID, wfv1.NodeError, err.Error())
 		connectDependencies(nodeName)
 		return
 	}
 
+	// A task with a map strategy is a single Map node which starts the items as it progresses
+	if newTask.MapStrategy != nil {
+		if woc.getNodeByName(nodeName) == nil {
+			connectDependencies(nodeName)
+		}
+		_, err = woc.executeMapTask(dagCtx, *newTask)
+		if err != nil && err != ErrDeadlineExceeded {
+			if woc.getNodeByName(nodeName) == nil {
+				woc.initializeNode(nodeName, wfv1.NodeTypeSkipped, task, dagCtx.boundaryID, wfv1.NodeError, err.Error())
+			} else {
+				woc.markNodeError(nodeName, err)
+			}
+		}
+		return
+	}
+
 	// Next, expand the DAG's withItems/withParams/withSequence (if any). If there was none, then
 	// expandedTasks will be a single element list of the same task
 	expandedTasks, err := woc.expandTask(*newTask)
@@ -494,6 +513,12 @@ func (woc *wfOperationCtx) resolveDependencyReferences(dagCtx *dagContext, task
 		resolvedArt.Name = art.Name
 		newTask.Arguments.Artifacts[j] = *resolvedArt
 	}
+
+	// load withParam from an artifact reference
+	newTask.WithParam, err = woc.resolveWithParam(newTask.WithParam, &scope)
+	if err != nil {
+		return nil, err
+	}
 	return &newTask, nil
 }
 
@@ -524,20 +549,11 @@ func (woc *wfOperationCtx) expandTask(task wfv1.DAGTask) ([]wfv1.DAGTask, error)
 	if err != nil {
 		return nil, errors.InternalWrapError(err)
 	}
-	var items []wfv1.Item
-	if len(task.WithItems) > 0 {
-		items = task.WithItems
-	} else if task.WithParam != "" {
-		err = json.Unmarshal([]byte(task.WithParam), &items)
-		if err != nil {
-			return nil, errors.Errorf(errors.CodeBadRequest, "withParam value could not be parsed as a JSON list: %s", strings.TrimSpace(task.WithParam))
-		}
-	} else if task.WithSequence != nil {
-		items, err = expandSequence(task.WithSequence)
-		if err != nil {
-			return nil, err
-		}
-	} else {
+	items, err := resolveItems(task.WithItems, task.WithParam, task.WithSequence)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
 		return []wfv1.DAGTask{task}, nil
 	}
 
@@ -1,12 +1,16 @@
 package controller
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 	"github.com/argoproj/argo/test"
+	"github.com/argoproj/argo/workflow/common"
 )
 
 // TestDagXfail verifies a DAG can fail properly
@@ -40,3 +44,106 @@ func TestDagDisableFailFast(t *testing.T) {
 	woc.operate()
 	assert.Equal(t, string(wfv1.NodeFailed), string(woc.wf.Status.Phase))
 }
+
+var mapTaskWithArtifactParam = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: map-task
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    dag:
+      tasks:
+      - name: generate
+        template: generate
+      - name: process
+        dependencies: [generate]
+        template: echo
+        arguments:
+          parameters:
+          - name: message
+            value: "{{item}}"
+        withParam: "{{tasks.generate.outputs.artifacts.items}}"
+        mapStrategy:
+          parallelism: 1
+  - name: generate
+    container:
+      image: alpine:latest
+      command: [sh, -c, 'echo "[\"a\", \"b\"]" > /tmp/items.json']
+    outputs:
+      artifacts:
+      - name: items
+        path: /tmp/items.json
+        # the location is replaced by the outputs of the pod
+        raw:
+          data: ""
+  - name: echo
+    inputs:
+      parameters:
+      - name: message
+    container:
+      image: alpine:latest
+      command: [echo, "{{inputs.parameters.message}}"]
+`
+
+// TestMapTaskWithArtifactParam verifies a map task can expand the JSON list of an artifact
+func TestMapTaskWithArtifactParam(t *testing.T) {
+	controller := newController()
+	wfcset := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+	wf, err := wfcset.Create(unmarshalWF(mapTaskWithArtifactParam))
+	assert.NoError(t, err)
+	woc := newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+
+	// complete the generate pod, which outputs a raw artifact holding the items
+	outputs := wfv1.Outputs{Artifacts: []wfv1.Artifact{{
+		Name:             "items",
+		ArtifactLocation: wfv1.ArtifactLocation{Raw: &wfv1.RawArtifact{Data: `["a", "b"]`}},
+	}}}
+	outputsBytes, err := json.Marshal(outputs)
+	assert.NoError(t, err)
+	podcs := controller.kubeclientset.CoreV1().Pods(wf.ObjectMeta.Namespace)
+	pods, err := podcs.List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, pods.Items, 1) {
+		pod := pods.Items[0]
+		pod.Status.Phase = apiv1.PodSucceeded
+		pod.Annotations[common.AnnotationKeyOutputs] = string(outputsBytes)
+		_, err = podcs.Update(&pod)
+		assert.NoError(t, err)
+	}
+
+	// the artifact is loaded in the background, so the task waits for it
+	woc = newWorkflowOperationCtx(woc.wf, controller)
+	woc.operate()
+	assert.Nil(t, woc.getNodeByName("map-task.process"))
+	waitForWithParamArtifacts(t, controller)
+
+	woc = newWorkflowOperationCtx(woc.wf, controller)
+	woc.operate()
+	mapNode := woc.getNodeByName("map-task.process")
+	if assert.NotNil(t, mapNode) {
+		assert.Equal(t, wfv1.NodeTypeMap, mapNode.Type)
+		assert.Equal(t, wfv1.MapStatus{Total: 2, Started: 1}, *mapNode.MapStatus)
+	}
+	assert.NotNil(t, woc.getNodeByName("map-task.process(0:a)"))
+
+	// a restarted controller loads the artifact again, without starting more items meanwhile
+	controller.withParamArtifacts.entries = nil
+	makePodsPhase(t, controller.kubeclientset, wf.ObjectMeta.Namespace, apiv1.PodSucceeded)
+	woc = newWorkflowOperationCtx(woc.wf, controller)
+	woc.operate()
+	assert.Equal(t, wfv1.MapStatus{Total: 2, Started: 1}, *woc.getNodeByName("map-task.process").MapStatus)
+	waitForWithParamArtifacts(t, controller)
+
+	for i := 0; i < 2; i++ {
+		makePodsPhase(t, controller.kubeclientset, wf.ObjectMeta.Namespace, apiv1.PodSucceeded)
+		woc = newWorkflowOperationCtx(woc.wf, controller)
+		woc.operate()
+	}
+	mapNode = woc.getNodeByName("map-task.process")
+	assert.Equal(t, wfv1.MapStatus{Total: 2, Started: 2, Succeeded: 2}, *mapNode.MapStatus)
+	assert.Equal(t, wfv1.NodeSucceeded, woc.wf.Status.Phase)
+}
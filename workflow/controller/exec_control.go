@@ -12,14 +12,24 @@ import (
 	"github.com/argoproj/argo/errors"
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 	"github.com/argoproj/argo/workflow/common"
+	"github.com/argoproj/argo/workflow/metrics"
+	"github.com/argoproj/argo/workflow/util"
 )
 
 // applyExecutionControl will ensure a pod's execution control annotation is up-to-date
-// kills any pending pods when workflow has reached it's deadline
+// kills any pending pods when workflow has reached it's deadline or was stopped
 func (woc *wfOperationCtx) applyExecutionControl(pod *apiv1.Pod, wfNodesLock *sync.RWMutex) error {
 	if pod == nil {
 		return nil
 	}
+	// Pods of a stopped workflow are signaled to terminate right away, except for the exit handler's
+	deadline := woc.workflowDeadline
+	wfNodesLock.RLock()
+	stopping := woc.isStopping(woc.wf.Status.Nodes[pod.Name].Name)
+	wfNodesLock.RUnlock()
+	if stopping {
+		deadline = &time.Time{}
+	}
 	switch pod.Status.Phase {
 	case apiv1.PodSucceeded, apiv1.PodFailed:
 		// Skip any pod which are already completed
@@ -27,18 +37,21 @@ func (woc *wfOperationCtx) applyExecutionControl(pod *apiv1.Pod, wfNodesLock *sy
 	case apiv1.PodPending:
 		// Check if we are past the workflow deadline. If we are, and the pod is still pending
 		// then we should simply delete it and mark the pod as Failed
-		if woc.workflowDeadline != nil && time.Now().UTC().After(*woc.workflowDeadline) {
-			woc.log.Infof("Deleting Pending pod %s/%s which has exceeded workflow deadline %s", pod.Namespace, pod.Name, woc.workflowDeadline)
+		if deadline != nil && time.Now().UTC().After(*deadline) {
+			woc.log.Infof("Deleting Pending pod %s/%s which has exceeded workflow deadline %s", pod.Namespace, pod.Name, deadline)
 			err := woc.controller.kubeclientset.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{})
 			if err == nil {
+				metrics.PodDeleted()
 				wfNodesLock.Lock()
 				defer wfNodesLock.Unlock()
 				node := woc.wf.Status.Nodes[pod.Name]
 				var message string
-				if woc.workflowDeadline.IsZero() {
+				if stopping {
+					message = util.StopMessage(woc.wf)
+				} else if deadline.IsZero() {
 					message = "terminated"
 				} else {
-					message = fmt.Sprintf("step exceeded workflow deadline %s", *woc.workflowDeadline)
+					message = fmt.Sprintf("step exceeded workflow deadline %s", *deadline)
 				}
 				woc.markNodePhase(node.Name, wfv1.NodeFailed, message)
 				return nil
@@ -55,10 +68,10 @@ func (woc *wfOperationCtx) applyExecutionControl(pod *apiv1.Pod, wfNodesLock *sy
 			woc.log.Warnf("Failed to unmarshal execution control from pod %s", pod.Name)
 		}
 	}
-	if podExecCtl.Deadline == nil && woc.workflowDeadline == nil {
+	if podExecCtl.Deadline == nil && deadline == nil {
 		return nil
-	} else if podExecCtl.Deadline != nil && woc.workflowDeadline != nil {
-		if podExecCtl.Deadline.Equal(*woc.workflowDeadline) {
+	} else if podExecCtl.Deadline != nil && deadline != nil {
+		if podExecCtl.Deadline.Equal(*deadline) {
 			return nil
 		}
 	}
@@ -71,9 +84,9 @@ func (woc *wfOperationCtx) applyExecutionControl(pod *apiv1.Pod, wfNodesLock *sy
 	}
 
 	// Assign new deadline value to PodExeCtl
-	podExecCtl.Deadline = woc.workflowDeadline
+	podExecCtl.Deadline = deadline
 
-	woc.log.Infof("Execution control for pod %s out-of-sync desired: %v, actual: %v", pod.Name, woc.workflowDeadline, podExecCtl.Deadline)
+	woc.log.Infof("Execution control for pod %s out-of-sync desired: %v, actual: %v", pod.Name, deadline, podExecCtl.Deadline)
 	return woc.updateExecutionControl(pod.Name, podExecCtl)
 }
 
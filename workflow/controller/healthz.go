@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultHealthPort is the port of the health server, unless configured otherwise
+const defaultHealthPort = "6060"
+
+// healthState tracks the state of the controller which is reported by its health endpoints
+type healthState struct {
+	lock sync.Mutex
+	// configLoaded is whether the controller configmap was loaded successfully at least once
+	configLoaded bool
+	// configErr is the error of the last update of the controller configmap, if it failed
+	configErr error
+	// cachesSynced is whether the informer caches of the leader have synced
+	cachesSynced bool
+	// expectedWorkers is the number of workflow and pod workers the leader started
+	expectedWorkers int32
+	// runningWorkers is the number of workers currently processing their queue
+	runningWorkers int32
+}
+
+func (h *healthState) setConfigResult(err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.configErr = err
+	if err == nil {
+		h.configLoaded = true
+	}
+}
+
+func (h *healthState) setCachesSynced(synced bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.cachesSynced = synced
+}
+
+func (h *healthState) setExpectedWorkers(workers int) {
+	atomic.StoreInt32(&h.expectedWorkers, int32(workers))
+}
+
+// workerStarted records a worker starting to process its queue and returns a func to record it stopping
+func (h *healthState) workerStarted() func() {
+	atomic.AddInt32(&h.runningWorkers, 1)
+	return func() { atomic.AddInt32(&h.runningWorkers, -1) }
+}
+
+// healthCheck is a named check of the state of the controller
+type healthCheck struct {
+	name  string
+	check func() error
+}
+
+// checkConfig verifies the controller configmap was loaded. If strict, the last update of the
+// configmap must have succeeded as well.
+func (wfc *WorkflowController) checkConfig(strict bool) error {
+	wfc.health.lock.Lock()
+	defer wfc.health.lock.Unlock()
+	if !wfc.health.configLoaded {
+		return fmt.Errorf("configmap %s was not loaded", wfc.configMap)
+	}
+	if strict && wfc.health.configErr != nil {
+		return fmt.Errorf("update of configmap %s failed: %v", wfc.configMap, wfc.health.configErr)
+	}
+	return nil
+}
+
+// checkInformers verifies the informer caches of the leader synced. Followers do not run informers.
+func (wfc *WorkflowController) checkInformers() error {
+	if !wfc.IsLeader() {
+		return nil
+	}
+	wfc.health.lock.Lock()
+	defer wfc.health.lock.Unlock()
+	if !wfc.health.cachesSynced {
+		return fmt.Errorf("waiting for informer caches to sync")
+	}
+	return nil
+}
+
+// checkWorkers verifies all the workers of the leader are processing their queues, unless it is
+// shutting down
+func (wfc *WorkflowController) checkWorkers() error {
+	if !wfc.IsLeader() || wfc.isShuttingDown() {
+		return nil
+	}
+	wfc.health.lock.Lock()
+	synced := wfc.health.cachesSynced
+	wfc.health.lock.Unlock()
+	if !synced {
+		// workers are only started once the caches synced
+		return nil
+	}
+	running := atomic.LoadInt32(&wfc.health.runningWorkers)
+	expected := atomic.LoadInt32(&wfc.health.expectedWorkers)
+	if running < expected {
+		return fmt.Errorf("%d of %d workers are running", running, expected)
+	}
+	return nil
+}
+
+// livenessChecks are the checks served at /healthz
+func (wfc *WorkflowController) livenessChecks() []healthCheck {
+	return []healthCheck{
+		{name: "config", check: func() error { return wfc.checkConfig(false) }},
+		{name: "workers", check: wfc.checkWorkers},
+		{name: "leader-election", check: func() error { return wfc.leaderHealthz.Check(nil) }},
+	}
+}
+
+// readinessChecks are the checks served at /readyz
+func (wfc *WorkflowController) readinessChecks() []healthCheck {
+	return []healthCheck{
+		{name: "config", check: func() error { return wfc.checkConfig(true) }},
+		{name: "informers", check: wfc.checkInformers},
+	}
+}
+
+// serveHealthChecks responds with the failed checks, or ok if all checks passed
+func serveHealthChecks(checks []healthCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var failed []string
+		for _, c := range checks {
+			if err := c.check(); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", c.name, err))
+			}
+		}
+		if len(failed) > 0 {
+			http.Error(w, strings.Join(failed, "\n"), http.StatusInternalServerError)
+			return
+		}
+		_, _ = fmt.Fprintln(w, "ok")
+	}
+}
+
+// HealthServer starts the server for the health endpoints, unless it is disabled in the configmap
+func (wfc *WorkflowController) HealthServer(ctx context.Context) {
+	config := wfc.Config.HealthConfig
+	if config.Disabled {
+		return
+	}
+	port := config.Port
+	if port == "" {
+		port = defaultHealthPort
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", serveHealthChecks(wfc.livenessChecks()))
+	mux.HandleFunc("/readyz", serveHealthChecks(wfc.readinessChecks()))
+	mux.HandleFunc("/healthz/leader-election", wfc.LeaderElectionHealthz)
+	if config.Pprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	srv := &http.Server{Addr: fmt.Sprintf(":%s", port), Handler: mux}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	log.Infof("Starting health server at 0.0.0.0:%s", port)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Health server failed: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj/argo/errors"
+)
+
+func getHealth(handler http.HandlerFunc) (int, string) {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/", nil))
+	return rec.Code, rec.Body.String()
+}
+
+// TestHealthChecks verifies the liveness and readiness checks of the controller
+func TestHealthChecks(t *testing.T) {
+	controller := newController()
+	controller.configMap = "workflow-controller-configmap"
+	healthz := serveHealthChecks(controller.livenessChecks())
+	readyz := serveHealthChecks(controller.readinessChecks())
+
+	code, body := getHealth(healthz)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Contains(t, body, "config: configmap workflow-controller-configmap was not loaded")
+
+	controller.health.setConfigResult(nil)
+	code, body = getHealth(healthz)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok\n", body)
+	code, _ = getHealth(readyz)
+	assert.Equal(t, http.StatusOK, code)
+
+	// a failed update of the configmap only affects readiness
+	controller.health.setConfigResult(errors.Errorf(errors.CodeBadRequest, "invalid config"))
+	code, _ = getHealth(healthz)
+	assert.Equal(t, http.StatusOK, code)
+	code, body = getHealth(readyz)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Contains(t, body, "invalid config")
+	controller.health.setConfigResult(nil)
+
+	// the leader is not ready until its caches synced
+	atomic.StoreInt32(&controller.leader, 1)
+	code, body = getHealth(readyz)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Contains(t, body, "informers: waiting for informer caches to sync")
+
+	// the leader is not alive unless all of its workers are running
+	controller.health.setCachesSynced(true)
+	controller.health.setExpectedWorkers(2)
+	stopped1 := controller.health.workerStarted()
+	code, body = getHealth(healthz)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Contains(t, body, "workers: 1 of 2 workers are running")
+	stopped2 := controller.health.workerStarted()
+	code, _ = getHealth(healthz)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = getHealth(readyz)
+	assert.Equal(t, http.StatusOK, code)
+	stopped1()
+	stopped2()
+	assert.Equal(t, int32(0), controller.health.runningWorkers)
+}
@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/argo/errors"
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
+)
+
+const (
+	// defaultHTTPTimeout is the request timeout used when the template does not specify one
+	defaultHTTPTimeout = 30 * time.Second
+	// maxHTTPResponseBytes limits the size of a response body read by the controller
+	maxHTTPResponseBytes = 256 * 1024
+	// httpResultRetention is how long the result of a request is kept after it was persisted in the workflow,
+	// so that an operation on a stale copy of the workflow from the informer records it again
+	httpResultRetention = 1 * time.Minute
+)
+
+// httpSend is a request of an HTTP template which is sent once the node was persisted as running
+type httpSend struct {
+	key      string
+	wfKey    string
+	req      *http.Request
+	timeout  time.Duration
+	keepBody bool
+}
+
+// httpResult is the outcome of the request of an HTTP template
+type httpResult struct {
+	statusCode int
+	status     string
+	body       string
+	err        error
+	// recordedAt is when the result was persisted in the workflow
+	recordedAt time.Time
+}
+
+// httpRequests tracks the requests of HTTP templates, which are sent in the background so that a slow
+// endpoint does not block the workers. It is keyed by the UID of the workflow and the ID of the node.
+type httpRequests struct {
+	lock     sync.Mutex
+	inFlight map[string]bool
+	results  map[string]*httpResult
+}
+
+// get returns the result of a request, and whether the request is still in flight
+func (r *httpRequests) get(key string) (*httpResult, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.results[key], r.inFlight[key]
+}
+
+// send sends a request in the background. The workflow is requeued once the result is available.
+func (r *httpRequests) send(wfc *WorkflowController, s *httpSend) {
+	r.lock.Lock()
+	if r.inFlight == nil {
+		r.inFlight = make(map[string]bool)
+	}
+	r.inFlight[s.key] = true
+	delete(r.results, s.key)
+	r.lock.Unlock()
+
+	go func() {
+		result := doHTTPRequest(s.req, s.timeout, s.keepBody)
+		r.lock.Lock()
+		if r.results == nil {
+			r.results = make(map[string]*httpResult)
+		}
+		r.results[s.key] = result
+		delete(r.inFlight, s.key)
+		r.lock.Unlock()
+		wfc.wfQueue.Add(s.wfKey)
+	}()
+}
+
+// recorded notes the results were persisted in their workflows, and forgets the results which were
+// persisted long enough ago
+func (r *httpRequests) recorded(keys []string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := time.Now()
+	for _, key := range keys {
+		if result, ok := r.results[key]; ok && result.recordedAt.IsZero() {
+			result.recordedAt = now
+		}
+	}
+	for key, result := range r.results {
+		if !result.recordedAt.IsZero() && now.Sub(result.recordedAt) > httpResultRetention {
+			delete(r.results, key)
+		}
+	}
+}
+
+// doHTTPRequest makes a request, reading the body of the response only if keepBody is set
+func doHTTPRequest(req *http.Request, timeout time.Duration, keepBody bool) *httpResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return &httpResult{err: err}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	result := &httpResult{statusCode: resp.StatusCode, status: resp.Status}
+	if keepBody {
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseBytes))
+		if err != nil {
+			return &httpResult{err: err}
+		}
+		result.body = string(body)
+	}
+	return result
+}
+
+// executeHTTP executes an HTTP template. The request is made by the controller in the background, after
+// the node was persisted as running, so that it is sent at most once. The response body becomes the
+// node's outputs.result if it is referenced, and the node succeeds or fails according to the template's
+// success condition.
+func (woc *wfOperationCtx) executeHTTP(nodeName string, tmpl *wfv1.Template, boundaryID string) error {
+	node := woc.getNodeByName(nodeName)
+	if node == nil || node.Completed() {
+		return nil
+	}
+	key := httpRequestKey(woc.wf, node.ID)
+	result, inFlight := woc.controller.httpRequests.get(key)
+	// The result and the request in flight are checked before the phase, since a stale copy of the
+	// workflow from the informer may still have the node pending after its request was sent.
+	switch {
+	case result != nil:
+		woc.httpResults = append(woc.httpResults, key)
+		return woc.recordHTTPResult(node, tmpl, result, boundaryID)
+	case inFlight:
+		return nil
+	case node.Phase == wfv1.NodePending:
+		req, err := woc.newHTTPRequest(tmpl.HTTP)
+		if err != nil {
+			return err
+		}
+		timeout := defaultHTTPTimeout
+		if tmpl.HTTP.TimeoutSeconds != nil {
+			timeout = time.Duration(*tmpl.HTTP.TimeoutSeconds) * time.Second
+		}
+		if timeout > common.MaxHTTPTimeoutSeconds*time.Second {
+			timeout = common.MaxHTTPTimeoutSeconds * time.Second
+		}
+		keepBody, err := woc.includeHTTPResult(node.Name, tmpl, boundaryID)
+		if err != nil {
+			return err
+		}
+		keepBody = keepBody || strings.Contains(tmpl.HTTP.SuccessCondition, "response.body")
+		woc.httpSends = append(woc.httpSends, &httpSend{key: key, wfKey: woc.key(), req: req, timeout: timeout, keepBody: keepBody})
+		woc.markNodePhase(nodeName, wfv1.NodeRunning)
+		return nil
+	default:
+		// The node was persisted as running, but the result of its request is unknown, e.g. because the
+		// controller restarted. The request may not be idempotent, so it is not sent again.
+		woc.markNodePhase(nodeName, wfv1.NodeFailed, "the result of the request is unknown, e.g. because the controller restarted")
+		return nil
+	}
+}
+
+// httpRequestKey returns the key of the request of the HTTP template of a node
+func httpRequestKey(wf *wfv1.Workflow, nodeID string) string {
+	return fmt.Sprintf("%s/%s", wf.ObjectMeta.UID, nodeID)
+}
+
+// recordHTTPResult completes the node of an HTTP template with the result of its request
+func (woc *wfOperationCtx) recordHTTPResult(node *wfv1.NodeStatus, tmpl *wfv1.Template, result *httpResult, boundaryID string) error {
+	if result.err != nil {
+		woc.markNodePhase(node.Name, wfv1.NodeFailed, result.err.Error())
+		return nil
+	}
+	succeeded, err := evaluateHTTPSuccessCondition(tmpl.HTTP.SuccessCondition, result.statusCode, result.body)
+	if err != nil {
+		return err
+	}
+	includeResult, err := woc.includeHTTPResult(node.Name, tmpl, boundaryID)
+	if err != nil {
+		return err
+	}
+	if includeResult {
+		body := result.body
+		node.Outputs = &wfv1.Outputs{Result: &body}
+	}
+	node.FinishedAt = metav1.Time{Time: time.Now().UTC()}
+	woc.wf.Status.Nodes[node.ID] = *node
+	woc.updated = true
+	if !succeeded {
+		woc.markNodePhase(node.Name, wfv1.NodeFailed, fmt.Sprintf("request to %s returned %s", tmpl.HTTP.URL, result.status))
+		return nil
+	}
+	woc.markNodePhase(node.Name, wfv1.NodeSucceeded)
+	return nil
+}
+
+// includeHTTPResult returns whether the response body of an HTTP template is referenced as its outputs.result,
+// which is the same rule which decides whether the output of a script is included
+func (woc *wfOperationCtx) includeHTTPResult(nodeName string, tmpl *wfv1.Template, boundaryID string) (bool, error) {
+	boundaryNode, ok := woc.wf.Status.Nodes[boundaryID]
+	if !ok {
+		return false, nil
+	}
+	parentTemplate := woc.wf.GetStoredOrLocalTemplate(&boundaryNode)
+	if parentTemplate == nil {
+		return false, errors.InternalError("parent node template not found")
+	}
+	name := getStepOrDAGTaskName(nodeName, tmpl.RetryStrategy != nil)
+	return hasOutputResultRef(name, parentTemplate), nil
+}
+
+// sendHTTPRequests sends the requests of the HTTP templates which were persisted as running
+func (woc *wfOperationCtx) sendHTTPRequests() {
+	woc.controller.httpRequests.recorded(woc.httpResults)
+	for _, s := range woc.httpSends {
+		woc.log.Infof("%s %s", s.req.Method, s.req.URL)
+		woc.controller.httpRequests.send(woc.controller, s)
+	}
+}
+
+// newHTTPRequest builds the request for an HTTP template, resolving header values from secrets
+func (woc *wfOperationCtx) newHTTPRequest(tmpl *wfv1.HTTPTemplate) (*http.Request, error) {
+	method := tmpl.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if tmpl.Body != "" {
+		body = strings.NewReader(tmpl.Body)
+	}
+	req, err := http.NewRequest(method, tmpl.URL, body)
+	if err != nil {
+		return nil, errors.Errorf(errors.CodeBadRequest, "invalid http request: %v", err)
+	}
+	for _, header := range tmpl.Headers {
+		value := header.Value
+		if header.ValueFrom != nil && header.ValueFrom.SecretKeyRef != nil {
+			value, err = woc.getSecretValue(header.ValueFrom.SecretKeyRef.Name, header.ValueFrom.SecretKeyRef.Key)
+			if err != nil {
+				return nil, err
+			}
+		}
+		req.Header.Add(header.Name, value)
+	}
+	return req, nil
+}
+
+// getSecretValue returns the value of a key of a secret in the workflow's namespace
+func (woc *wfOperationCtx) getSecretValue(name, key string) (string, error) {
+	secret, err := woc.controller.kubeclientset.CoreV1().Secrets(woc.wf.ObjectMeta.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.InternalWrapError(err)
+	}
+	val, ok := secret.Data[key]
+	if !ok {
+		return "", errors.Errorf(errors.CodeBadRequest, "secret '%s' does not have the key '%s'", name, key)
+	}
+	return string(val), nil
+}
+
+// evaluateHTTPSuccessCondition evaluates the success condition of an HTTP template, with the response
+// available as {{response.statusCode}} and {{response.body}}. An empty condition succeeds on any 2xx
+// status code.
+func evaluateHTTPSuccessCondition(condition string, statusCode int, body string) (bool, error) {
+	if condition == "" {
+		return statusCode >= 200 && statusCode < 300, nil
+	}
+	return common.EvaluateBoolExpression(condition, map[string]string{
+		"response.statusCode": strconv.Itoa(statusCode),
+		"response.body":       body,
+	})
+}
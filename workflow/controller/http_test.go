@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+)
+
+var httpTemplate = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: http-template
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    steps:
+    - - name: request
+        template: request
+    - - name: print
+        template: whalesay
+        arguments:
+          parameters:
+          - name: message
+            value: "{{steps.request.outputs.result}}"
+
+  - name: request
+    http:
+      url: %s
+      method: POST
+      body: hello
+      headers:
+      - name: Authorization
+        valueFrom:
+          secretKeyRef:
+            name: http-token
+            key: token
+      successCondition: "{{response.statusCode}} == %d"
+
+  - name: whalesay
+    inputs:
+      parameters:
+      - name: message
+    container:
+      image: docker/whalesay
+      command: [cowsay]
+      args: ["{{inputs.parameters.message}}"]
+`
+
+func newHTTPTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer my-token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}))
+}
+
+func newHTTPTestController(t *testing.T) *WorkflowController {
+	controller := newController()
+	_, err := controller.kubeclientset.CoreV1().Secrets("").Create(&apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "http-token"},
+		Data:       map[string][]byte{"token": []byte("Bearer my-token")},
+	})
+	assert.Nil(t, err)
+	return controller
+}
+
+// operateHTTPTemplate operates on a workflow until its request was sent, and again once the response arrived
+func operateHTTPTemplate(t *testing.T, controller *WorkflowController, wf *wfv1.Workflow) *wfOperationCtx {
+	wfcset := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+	woc := newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+	node := woc.getNodeByName("http-template[0].request")
+	if assert.NotNil(t, node) {
+		assert.Equal(t, wfv1.NodeTypeHTTP, node.Type)
+		assert.Equal(t, wfv1.NodeRunning, node.Phase)
+	}
+
+	// the request is sent in the background, once the node was persisted as running
+	waitForHTTPResult(controller, httpRequestKey(wf, node.ID))
+	wf, err := wfcset.Get(wf.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	woc = newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+	return woc
+}
+
+// waitForHTTPResult waits for the result of a request sent in the background
+func waitForHTTPResult(controller *WorkflowController, key string) {
+	for i := 0; i < 100; i++ {
+		if result, _ := controller.httpRequests.get(key); result != nil {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestHTTPTemplate(t *testing.T) {
+	server := newHTTPTestServer(t)
+	defer server.Close()
+	controller := newHTTPTestController(t)
+	wfcset := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+
+	wf := unmarshalWF(fmt.Sprintf(httpTemplate, server.URL, http.StatusCreated))
+	wf, err := wfcset.Create(wf)
+	assert.Nil(t, err)
+	woc := operateHTTPTemplate(t, controller, wf)
+
+	node := woc.getNodeByName("http-template[0].request")
+	if assert.NotNil(t, node) {
+		assert.Equal(t, wfv1.NodeSucceeded, node.Phase)
+		if assert.NotNil(t, node.Outputs) && assert.NotNil(t, node.Outputs.Result) {
+			assert.Equal(t, "created", *node.Outputs.Result)
+		}
+	}
+
+	// the next step should have been scheduled with the response body as its argument
+	pods, err := controller.kubeclientset.CoreV1().Pods("").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(pods.Items)) {
+		assert.Contains(t, pods.Items[0].Spec.Containers[1].Args, "created")
+	}
+}
+
+func TestHTTPTemplateFailedCondition(t *testing.T) {
+	server := newHTTPTestServer(t)
+	defer server.Close()
+	controller := newHTTPTestController(t)
+	wfcset := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+
+	wf := unmarshalWF(fmt.Sprintf(httpTemplate, server.URL, http.StatusOK))
+	wf, err := wfcset.Create(wf)
+	assert.Nil(t, err)
+	woc := operateHTTPTemplate(t, controller, wf)
+
+	node := woc.getNodeByName("http-template[0].request")
+	if assert.NotNil(t, node) {
+		assert.Equal(t, wfv1.NodeFailed, node.Phase)
+	}
+	assert.Equal(t, wfv1.NodeFailed, woc.wf.Status.Phase)
+}
+
+// TestHTTPTemplateUnknownResult verifies a request is not sent again when its result was lost
+func TestHTTPTemplateUnknownResult(t *testing.T) {
+	server := newHTTPTestServer(t)
+	defer server.Close()
+	controller := newHTTPTestController(t)
+	wfcset := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+
+	wf := unmarshalWF(fmt.Sprintf(httpTemplate, server.URL, http.StatusCreated))
+	wf, err := wfcset.Create(wf)
+	assert.Nil(t, err)
+	woc := newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+
+	// a controller which restarted knows nothing of the request sent by its predecessor
+	wf, err = wfcset.Get(wf.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	restarted := newHTTPTestController(t)
+	restarted.wfclientset = controller.wfclientset
+	woc = newWorkflowOperationCtx(wf, restarted)
+	woc.operate()
+	node := woc.getNodeByName("http-template[0].request")
+	if assert.NotNil(t, node) {
+		assert.Equal(t, wfv1.NodeFailed, node.Phase)
+		assert.Nil(t, node.Outputs)
+	}
+}
+
+func TestEvaluateHTTPSuccessCondition(t *testing.T) {
+	ok, err := evaluateHTTPSuccessCondition("", 204, "")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = evaluateHTTPSuccessCondition("", 404, "")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = evaluateHTTPSuccessCondition("{{response.statusCode}} < 500 && {{response.body}} == ready", 404, "ready")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// the body is a parameter of the expression, so its content cannot change the expression
+	ok, err = evaluateHTTPSuccessCondition("{{response.body}} == ready", 200, "' || true || '")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, err = evaluateHTTPSuccessCondition("{{response.statusCode}} +", 200, "")
+	assert.NotNil(t, err)
+}
+
+// TestHTTPTemplateStaleWorkflow verifies a request is not sent again when operating on a stale copy of the
+// workflow, which does not have the node running yet
+func TestHTTPTemplateStaleWorkflow(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	controller := newHTTPTestController(t)
+	wfcset := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+
+	wf := unmarshalWF(fmt.Sprintf(httpTemplate, server.URL, http.StatusCreated))
+	wf.ObjectMeta.UID = "http-template-uid"
+	wf, err := wfcset.Create(wf)
+	assert.Nil(t, err)
+	stale := wf.DeepCopy()
+	woc := newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+	node := woc.getNodeByName("http-template[0].request")
+	if !assert.NotNil(t, node) {
+		return
+	}
+
+	// operate on the stale copy while the request is in flight, and once its result arrived
+	woc = newWorkflowOperationCtx(stale.DeepCopy(), controller)
+	woc.operate()
+	assert.Empty(t, woc.httpSends)
+	waitForHTTPResult(controller, httpRequestKey(wf, node.ID))
+	woc = newWorkflowOperationCtx(stale.DeepCopy(), controller)
+	woc.operate()
+	assert.Empty(t, woc.httpSends)
+	node = woc.getNodeByName("http-template[0].request")
+	if assert.NotNil(t, node) {
+		assert.Equal(t, wfv1.NodeSucceeded, node.Phase)
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
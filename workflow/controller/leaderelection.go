@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/argoproj/argo/errors"
+)
+
+// LeaderElectionConfig configures the election of a leader among the replicas of the workflow controller
+type LeaderElectionConfig struct {
+	// LeaseName is the name of the lease in the controller's namespace which is used as the lock
+	LeaseName string
+	// Identity is the identity of this replica. Defaults to the hostname, i.e. the pod name.
+	Identity string
+	// LeaseDuration is how long followers wait before attempting to acquire an unrenewed lease
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the leader retries renewing the lease before giving up the leadership
+	RenewDeadline time.Duration
+	// RetryPeriod is how long replicas wait between attempts to acquire or renew the lease
+	RetryPeriod time.Duration
+}
+
+// RunWithLeaderElection runs the controller, along with the TTL controller, only while this replica
+// holds the lease. Followers wait until the leader stops renewing the lease. Once a leader loses its
+// lease the process exits, since the queues and informers of the controller cannot be restarted.
+// Once ctx is done, it returns after the controller shut down. The leader stops renewing the lease once
+// ctx is done, so the shutdown timeout must end before another replica may acquire the lease, which is
+// the lease duration after the last renewal, at most a retry period earlier.
+func (wfc *WorkflowController) RunWithLeaderElection(ctx context.Context, wfWorkers, podWorkers int, config LeaderElectionConfig) error {
+	if wfc.shutdownTimeout >= config.LeaseDuration-config.RetryPeriod {
+		return errors.Errorf(errors.CodeBadRequest, "shutdown timeout %v must be less than the lease duration %v less the retry period %v", wfc.shutdownTimeout, config.LeaseDuration, config.RetryPeriod)
+	}
+	identity := config.Identity
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return errors.InternalWrapError(err)
+		}
+		identity = hostname
+	}
+	lock, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		wfc.namespace,
+		config.LeaseName,
+		wfc.kubeclientset.CoreV1(),
+		wfc.kubeclientset.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: identity},
+	)
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
+	// stopped is closed once the controller stopped, after leading
+	var started int32
+	stopped := make(chan struct{})
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: config.LeaseDuration,
+		RenewDeadline: config.RenewDeadline,
+		RetryPeriod:   config.RetryPeriod,
+		WatchDog:      wfc.leaderHealthz,
+		Name:          config.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.Infof("%s acquired the lease %s and is now the leader", identity, config.LeaseName)
+				atomic.StoreInt32(&started, 1)
+				defer close(stopped)
+				go wfc.RunTTLController(ctx)
+				wfc.Run(ctx, wfWorkers, podWorkers)
+			},
+			OnStoppedLeading: func() {
+				atomic.StoreInt32(&wfc.leader, 0)
+				if ctx.Err() != nil {
+					log.Infof("%s stopped leading", identity)
+					return
+				}
+				log.Fatalf("%s lost the lease %s", identity, config.LeaseName)
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					log.Infof("%s is the leader", leader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
+	log.Infof("%s is waiting to acquire the lease %s", identity, config.LeaseName)
+	elector.Run(ctx)
+	if atomic.LoadInt32(&started) == 1 {
+		// wait for the in-flight operations to complete before giving up the process
+		<-stopped
+	}
+	return nil
+}
+
+// IsLeader returns whether this replica is the leader, i.e. whether it is operating on workflows
+func (wfc *WorkflowController) IsLeader() bool {
+	return atomic.LoadInt32(&wfc.leader) == 1
+}
+
+// LeaderElectionHealthz serves the leadership state of this replica. It responds with an error if
+// this replica is the leader but failed to renew its lease for longer than the lease allows.
+func (wfc *WorkflowController) LeaderElectionHealthz(w http.ResponseWriter, r *http.Request) {
+	if err := wfc.leaderHealthz.Check(r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if wfc.IsLeader() {
+		_, _ = fmt.Fprintln(w, "leader")
+	} else {
+		_, _ = fmt.Fprintln(w, "follower")
+	}
+}
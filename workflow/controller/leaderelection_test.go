@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLeaderElectionHealthz verifies the leadership state of a replica is served
+func TestLeaderElectionHealthz(t *testing.T) {
+	controller := newController()
+	assert.False(t, controller.IsLeader())
+
+	rec := httptest.NewRecorder()
+	controller.LeaderElectionHealthz(rec, httptest.NewRequest("GET", "/healthz/leader-election", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "follower\n", rec.Body.String())
+
+	atomic.StoreInt32(&controller.leader, 1)
+	assert.True(t, controller.IsLeader())
+	rec = httptest.NewRecorder()
+	controller.LeaderElectionHealthz(rec, httptest.NewRequest("GET", "/healthz/leader-election", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "leader\n", rec.Body.String())
+}
+
+// TestRunWithLeaderElectionShutdownTimeout verifies a shutdown timeout which outlasts the lease is rejected
+func TestRunWithLeaderElectionShutdownTimeout(t *testing.T) {
+	controller := newController()
+	controller.SetShutdownTimeout(25 * time.Second)
+	err := controller.RunWithLeaderElection(context.Background(), 1, 1, LeaderElectionConfig{
+		LeaseName:     "workflow-controller",
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+	})
+	assert.EqualError(t, err, "shutdown timeout 25s must be less than the lease duration 15s less the retry period 2s")
+}
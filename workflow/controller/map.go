@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasttemplate"
+
+	"github.com/argoproj/argo/errors"
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/templateresolution"
+)
+
+// defaultMapParallelism is the number of items of a map which run at the same time, when the map
+// strategy does not specify a parallelism
+const defaultMapParallelism = 100
+
+// mapItem is a step or task with the value of a single item substituted
+type mapItem struct {
+	nodeName  string
+	holder    wfv1.TemplateHolder
+	arguments wfv1.Arguments
+	when      string
+}
+
+// expandMapItemFunc substitutes the item with the given index into a step or task
+type expandMapItemFunc func(index int, item wfv1.Item) (*mapItem, error)
+
+// executeMapStep executes a step which has a map strategy
+func (woc *wfOperationCtx) executeMapStep(nodeName string, step wfv1.WorkflowStep, stepsCtx *stepsContext) (*wfv1.NodeStatus, error) {
+	items, err := resolveItems(step.WithItems, step.WithParam, step.WithSequence)
+	if err != nil {
+		return nil, err
+	}
+	strategy := step.MapStrategy
+	step.WithItems, step.WithParam, step.WithSequence, step.MapStrategy = nil, "", nil, nil
+	stepBytes, err := json.Marshal(step)
+	if err != nil {
+		return nil, errors.InternalWrapError(err)
+	}
+	fstTmpl := fasttemplate.New(string(stepBytes), "{{", "}}")
+	expand := func(index int, item wfv1.Item) (*mapItem, error) {
+		var newStep wfv1.WorkflowStep
+		newStepName, err := processItem(fstTmpl, nodeName, index, item, &newStep)
+		if err != nil {
+			return nil, err
+		}
+		newStep.Template = step.Template
+		return &mapItem{nodeName: newStepName, holder: &newStep, arguments: newStep.Arguments, when: newStep.When}, nil
+	}
+	return woc.executeMap(nodeName, &step, strategy, items, expand, stepsCtx.tmplCtx, stepsCtx.boundaryID)
+}
+
+// executeMapTask executes a DAG task which has a map strategy
+func (woc *wfOperationCtx) executeMapTask(dagCtx *dagContext, task wfv1.DAGTask) (*wfv1.NodeStatus, error) {
+	nodeName := dagCtx.taskNodeName(task.Name)
+	items, err := resolveItems(task.WithItems, task.WithParam, task.WithSequence)
+	if err != nil {
+		return nil, err
+	}
+	strategy := task.MapStrategy
+	task.WithItems, task.WithParam, task.WithSequence, task.MapStrategy = nil, "", nil, nil
+	taskBytes, err := json.Marshal(task)
+	if err != nil {
+		return nil, errors.InternalWrapError(err)
+	}
+	fstTmpl := fasttemplate.New(string(taskBytes), "{{", "}}")
+	expand := func(index int, item wfv1.Item) (*mapItem, error) {
+		var newTask wfv1.DAGTask
+		newTaskName, err := processItem(fstTmpl, nodeName, index, item, &newTask)
+		if err != nil {
+			return nil, err
+		}
+		newTask.Template = task.Template
+		return &mapItem{nodeName: newTaskName, holder: &newTask, arguments: newTask.Arguments, when: newTask.When}, nil
+	}
+	return woc.executeMap(nodeName, &task, strategy, items, expand, dagCtx.tmplCtx, dagCtx.boundaryID)
+}
+
+// executeMap advances a Map node. Rather than creating a node for every item up front, at most
+// parallelism item nodes are running at a time, and new items are started as running ones complete.
+// Item nodes which succeed are pruned from the workflow status and only counted in the MapStatus of
+// the Map node, whereas failed item nodes are kept as its children. The Map node fails if any of
+// the items failed.
+func (woc *wfOperationCtx) executeMap(nodeName string, orgTmpl wfv1.TemplateHolder, strategy *wfv1.MapStrategy, items []wfv1.Item, expand expandMapItemFunc, tmplCtx *templateresolution.Context, boundaryID string) (*wfv1.NodeStatus, error) {
+	node := woc.getNodeByName(nodeName)
+	if node == nil {
+		node = woc.initializeNode(nodeName, wfv1.NodeTypeMap, orgTmpl, boundaryID, wfv1.NodeRunning)
+		node.MapStatus = &wfv1.MapStatus{Total: int64(len(items))}
+		woc.wf.Status.Nodes[node.ID] = *node
+	}
+	if node.Completed() {
+		return node, nil
+	}
+	status := *node.MapStatus
+	if int64(len(items)) != status.Total {
+		return woc.markNodeError(nodeName, errors.Errorf(errors.CodeBadRequest, "number of items changed from %d to %d", status.Total, len(items))), nil
+	}
+	parallelism := int64(defaultMapParallelism)
+	if strategy != nil && strategy.Parallelism != nil && *strategy.Parallelism > 0 {
+		parallelism = *strategy.Parallelism
+	}
+
+	// Advance the running items
+	running := int64(0)
+	for _, childID := range node.Children {
+		child := woc.wf.Status.Nodes[childID]
+		if child.Completed() {
+			continue
+		}
+		index, ok := mapItemIndex(nodeName, child.Name)
+		if !ok || index >= len(items) {
+			continue
+		}
+		item, err := expand(index, items[index])
+		if err != nil {
+			return woc.markNodeError(nodeName, err), nil
+		}
+		_, err = woc.executeTemplate(item.nodeName, item.holder, tmplCtx, item.arguments, boundaryID)
+		if err == ErrDeadlineExceeded {
+			return woc.getNodeByName(nodeName), err
+		}
+		if !woc.wf.Status.Nodes[childID].Completed() {
+			running++
+		}
+	}
+
+	// Start new items, up to the parallelism of the map
+	for running < parallelism && status.Started < status.Total {
+		index := int(status.Started)
+		item, err := expand(index, items[index])
+		if err != nil {
+			return woc.markNodeError(nodeName, err), nil
+		}
+		proceed, err := shouldExecute(item.when)
+		if err != nil {
+			woc.initializeNode(item.nodeName, wfv1.NodeTypeSkipped, item.holder, boundaryID, wfv1.NodeError, err.Error())
+			woc.addChildNode(nodeName, item.nodeName)
+			status.Started++
+			continue
+		}
+		if !proceed {
+			status.Started++
+			status.Skipped++
+			continue
+		}
+		childNode, err := woc.executeTemplate(item.nodeName, item.holder, tmplCtx, item.arguments, boundaryID)
+		if childNode == nil {
+			// the item could not be started, e.g. the parallelism of the workflow was reached
+			break
+		}
+		status.Started++
+		woc.addChildNode(nodeName, item.nodeName)
+		if err == ErrDeadlineExceeded {
+			break
+		}
+		if !childNode.Completed() {
+			running++
+		}
+	}
+
+	// Prune the items which succeeded, and count the ones which failed
+	node = woc.getNodeByName(nodeName)
+	children := make([]string, 0)
+	status.Failed = 0
+	for _, childID := range node.Children {
+		child := woc.wf.Status.Nodes[childID]
+		if _, ok := mapItemIndex(nodeName, child.Name); !ok {
+			children = append(children, childID)
+			continue
+		}
+		switch {
+		case !child.Completed():
+			children = append(children, childID)
+		case child.Successful():
+			status.Succeeded++
+			woc.pruneNode(childID)
+		default:
+			status.Failed++
+			children = append(children, childID)
+		}
+	}
+	message := fmt.Sprintf("%d/%d items succeeded, %d failed, %d skipped", status.Succeeded, status.Total, status.Failed, status.Skipped)
+	if status != *node.MapStatus || len(children) != len(node.Children) || message != node.Message {
+		node.Children = children
+		node.MapStatus = &status
+		node.Message = message
+		woc.wf.Status.Nodes[node.ID] = *node
+		woc.updated = true
+	}
+
+	if status.Started < status.Total || status.Succeeded+status.Failed+status.Skipped < status.Started {
+		return node, nil
+	}
+	if status.Failed > 0 {
+		return woc.markNodePhase(nodeName, wfv1.NodeFailed, node.Message), nil
+	}
+	return woc.markNodePhase(nodeName, wfv1.NodeSucceeded, node.Message), nil
+}
+
+// mapItemIndex parses the index of an item from the name of an item node of a Map node, e.g.
+// "main[0].process(12:foo)"
+func mapItemIndex(mapNodeName, itemNodeName string) (int, bool) {
+	if !strings.HasPrefix(itemNodeName, mapNodeName+"(") {
+		return 0, false
+	}
+	rest := strings.TrimPrefix(itemNodeName, mapNodeName+"(")
+	colon := strings.Index(rest, ":")
+	if colon < 0 {
+		return 0, false
+	}
+	index, err := strconv.Atoi(rest[:colon])
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
+
+// pruneNode removes a node, along with all the nodes reachable through its children, from the
+// workflow status
+func (woc *wfOperationCtx) pruneNode(nodeID string) {
+	node, ok := woc.wf.Status.Nodes[nodeID]
+	if !ok {
+		return
+	}
+	delete(woc.wf.Status.Nodes, nodeID)
+	for _, childID := range node.Children {
+		woc.pruneNode(childID)
+	}
+	woc.updated = true
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj/argo/workflow/common"
+	"github.com/argoproj/argo/workflow/config"
+)
+
+// namespaceConfigs holds the overrides of the controller configuration per namespace
+type namespaceConfigs struct {
+	lock    sync.RWMutex
+	configs map[string]*config.NamespaceConfig
+}
+
+func (n *namespaceConfigs) get(namespace string) *config.NamespaceConfig {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	return n.configs[namespace]
+}
+
+func (n *namespaceConfigs) set(namespace string, nc *config.NamespaceConfig) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.configs == nil {
+		n.configs = make(map[string]*config.NamespaceConfig)
+	}
+	if nc == nil {
+		delete(n.configs, namespace)
+	} else {
+		n.configs[namespace] = nc
+	}
+}
+
+// configForNamespace returns the controller configuration in effect for the workflows of a namespace
+func (wfc *WorkflowController) configForNamespace(namespace string) config.WorkflowControllerConfig {
+	return wfc.Config.ForNamespace(wfc.namespaceConfigs.get(namespace))
+}
+
+// updateNamespaceConfig applies the overrides of the configmap of a namespace
+func (wfc *WorkflowController) updateNamespaceConfig(cm *apiv1.ConfigMap) error {
+	nc, err := config.UnmarshalNamespaceConfig(cm)
+	if err != nil {
+		return err
+	}
+	log.Infof("Updating the controller config of namespace %s from %s", cm.Namespace, cm.Name)
+	wfc.namespaceConfigs.set(cm.Namespace, nc)
+	wfc.throttler.SetNamespaceParallelism(cm.Namespace, nc.Parallelism)
+	return nil
+}
+
+// deleteNamespaceConfig removes the overrides of a namespace whose configmap was deleted
+func (wfc *WorkflowController) deleteNamespaceConfig(namespace string) {
+	log.Infof("Removing the controller config of namespace %s", namespace)
+	wfc.namespaceConfigs.set(namespace, nil)
+	wfc.throttler.SetNamespaceParallelism(namespace, 0)
+}
+
+// watchNamespaceConfigMaps watches the configmaps labeled with the Parameter configmap type, which
+// override the controller configuration for the workflows of their namespace. A namespace is
+// expected to have at most one such configmap.
+func (wfc *WorkflowController) watchNamespaceConfigMaps(ctx context.Context) cache.Controller {
+	_, controller := cache.NewInformer(
+		wfc.newNamespaceConfigMapWatch(),
+		&apiv1.ConfigMap{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				if cm, ok := obj.(*apiv1.ConfigMap); ok {
+					if err := wfc.updateNamespaceConfig(cm); err != nil {
+						log.Errorf("Update of the config of namespace %s failed due to: %v", cm.Namespace, err)
+					}
+				}
+			},
+			UpdateFunc: func(old, new interface{}) {
+				oldCM := old.(*apiv1.ConfigMap)
+				newCM := new.(*apiv1.ConfigMap)
+				if oldCM.ResourceVersion == newCM.ResourceVersion {
+					return
+				}
+				if err := wfc.updateNamespaceConfig(newCM); err != nil {
+					log.Errorf("Update of the config of namespace %s failed due to: %v", newCM.Namespace, err)
+				}
+			},
+			DeleteFunc: func(obj interface{}) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				if cm, ok := obj.(*apiv1.ConfigMap); ok {
+					wfc.deleteNamespaceConfig(cm.Namespace)
+				}
+			},
+		})
+	go controller.Run(ctx.Done())
+	return controller
+}
+
+func (wfc *WorkflowController) newNamespaceConfigMapWatch() *cache.ListWatch {
+	cmClient := wfc.kubeclientset.CoreV1().ConfigMaps(wfc.Config.Namespace)
+	labelSelector := labels.SelectorFromSet(labels.Set{common.LabelKeyConfigMapType: common.LabelValueConfigMapTypeParameter}).String()
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = labelSelector
+			return cmClient.List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.Watch = true
+			options.LabelSelector = labelSelector
+			return cmClient.Watch(options)
+		},
+	}
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
+)
+
+var namespaceConfigMap = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: argo-parameters
+  namespace: team-a
+  labels:
+    workflows.argoproj.io/configmap-type: Parameter
+data:
+  config: |
+    serviceAccountName: team-a-workflows
+    parallelism: 3
+    executorResources:
+      limits:
+        cpu: 200m
+    artifactRepository:
+      s3:
+        bucket: team-a
+        endpoint: s3.amazonaws.com
+`
+
+func unmarshalConfigMap(yamlStr string) *apiv1.ConfigMap {
+	var cm apiv1.ConfigMap
+	err := yaml.Unmarshal([]byte(yamlStr), &cm)
+	if err != nil {
+		panic(err)
+	}
+	return &cm
+}
+
+// TestNamespaceConfig verifies the configmap of a namespace overrides the controller configuration
+// for the workflows of the namespace only
+func TestNamespaceConfig(t *testing.T) {
+	controller := newController()
+	controller.Config.Parallelism = 10
+	err := controller.updateNamespaceConfig(unmarshalConfigMap(namespaceConfigMap))
+	assert.NoError(t, err)
+
+	nsConfig := controller.configForNamespace("team-a")
+	assert.Equal(t, 3, nsConfig.Parallelism)
+	assert.Equal(t, "team-a", nsConfig.ArtifactRepository.S3.Bucket)
+	assert.Equal(t, "team-a-workflows", nsConfig.WorkflowDefaults.Spec.ServiceAccountName)
+	assert.Equal(t, resource.MustParse("200m"), nsConfig.Executor.Resources.Limits[apiv1.ResourceCPU])
+
+	otherConfig := controller.configForNamespace("team-b")
+	assert.Equal(t, 10, otherConfig.Parallelism)
+	assert.Nil(t, otherConfig.ArtifactRepository.S3)
+	assert.Nil(t, otherConfig.WorkflowDefaults)
+	assert.Nil(t, otherConfig.Executor)
+
+	controller.deleteNamespaceConfig("team-a")
+	assert.Nil(t, controller.configForNamespace("team-a").ArtifactRepository.S3)
+}
+
+// TestNamespaceExecutorResources verifies the executor of the pods of a namespace gets the resources
+// of the namespace
+func TestNamespaceExecutorResources(t *testing.T) {
+	controller := newController()
+	cm := unmarshalConfigMap(namespaceConfigMap)
+	// the hello world workflow has no namespace
+	cm.Namespace = ""
+	err := controller.updateNamespaceConfig(cm)
+	assert.NoError(t, err)
+
+	wf := unmarshalWF(helloWorldWf)
+	wf, err = controller.wfclientset.ArgoprojV1alpha1().Workflows("").Create(wf)
+	assert.NoError(t, err)
+	woc := newWorkflowOperationCtx(wf, controller)
+	assert.Equal(t, "team-a", woc.artifactRepository.S3.Bucket)
+	woc.operate()
+	pods, err := controller.kubeclientset.CoreV1().Pods(wf.ObjectMeta.Namespace).List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, pods.Items, 1) {
+		for _, ctr := range pods.Items[0].Spec.Containers {
+			if ctr.Name == common.WaitContainerName {
+				assert.Equal(t, resource.MustParse("200m"), ctr.Resources.Limits[apiv1.ResourceCPU])
+			}
+		}
+	}
+}
+
+// TestApplyWorkflowDefaults verifies the workflow defaults of a namespace are only applied to workflows
+// which have not started yet
+func TestApplyWorkflowDefaults(t *testing.T) {
+	controller := newController()
+	err := controller.updateNamespaceConfig(unmarshalConfigMap(namespaceConfigMap))
+	assert.NoError(t, err)
+
+	wf := unmarshalWF(helloWorldWf)
+	wf.Namespace = "team-a"
+	assert.NoError(t, controller.applyWorkflowDefaults(wf))
+	assert.Equal(t, "team-a-workflows", wf.Spec.ServiceAccountName)
+
+	wf = unmarshalWF(helloWorldWf)
+	wf.Namespace = "team-a"
+	wf.Status.Phase = wfv1.NodeRunning
+	assert.NoError(t, controller.applyWorkflowDefaults(wf))
+	assert.Equal(t, "", wf.Spec.ServiceAccountName)
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"reflect"
 	"regexp"
 	"runtime/debug"
@@ -34,6 +35,7 @@ import (
 	"github.com/argoproj/argo/util/retry"
 	"github.com/argoproj/argo/workflow/common"
 	"github.com/argoproj/argo/workflow/config"
+	"github.com/argoproj/argo/workflow/metrics"
 	"github.com/argoproj/argo/workflow/templateresolution"
 	"github.com/argoproj/argo/workflow/util"
 	"github.com/argoproj/argo/workflow/validate"
@@ -72,12 +74,20 @@ type wfOperationCtx struct {
 	// activePods tracks the number of active (Running/Pending) pods for controlling
 	// parallelism
 	activePods int64
+	// podCreationThrottled is set once a pod was not created due to the pod creation rate limit, after
+	// which the operation creates no more pods
+	podCreationThrottled bool
 	// workflowDeadline is the deadline which the workflow is expected to complete before we
 	// terminate the workflow.
 	workflowDeadline *time.Time
 
 	// tmplCtx is the context of template search.
 	tmplCtx *templateresolution.Context
+
+	// httpSends are the requests of HTTP templates to send once the workflow was persisted
+	httpSends []*httpSend
+	// httpResults are the keys of the results of HTTP template requests recorded in the workflow
+	httpResults []string
 }
 
 var (
@@ -99,6 +109,7 @@ func newWorkflowOperationCtx(wf *wfv1.Workflow, wfc *WorkflowController) *wfOper
 	// NEVER modify objects from the store. It's a read-only, local cache.
 	// You can use DeepCopy() to make a deep copy of original object and modify this copy
 	// Or create a copy manually for better performance
+	nsConfig := wfc.configForNamespace(wf.Namespace)
 	woc := wfOperationCtx{
 		wf:      wf.DeepCopyObject().(*wfv1.Workflow),
 		orig:    wf,
@@ -110,7 +121,7 @@ func newWorkflowOperationCtx(wf *wfv1.Workflow, wfc *WorkflowController) *wfOper
 		controller:         wfc,
 		globalParams:       make(map[string]string),
 		volumes:            wf.Spec.DeepCopy().Volumes,
-		artifactRepository: &wfc.Config.ArtifactRepository,
+		artifactRepository: &nsConfig.ArtifactRepository,
 		completedPods:      make(map[string]bool),
 		succeededPods:      make(map[string]bool),
 		deadline:           time.Now().UTC().Add(maxOperationTime),
@@ -133,6 +144,9 @@ func newWorkflowOperationCtx(wf *wfv1.Workflow, wfc *WorkflowController) *wfOper
 // TODO: an error returned by this method should result in requeuing the workflow to be retried at a
 // later time
 func (woc *wfOperationCtx) operate() {
+	defer func(start time.Time) {
+		metrics.OperationCompleted(time.Since(start))
+	}(time.Now())
 	defer func() {
 		if woc.wf.Status.Completed() {
 			_ = woc.killDaemonedChildren("")
@@ -172,7 +186,7 @@ func (woc *wfOperationCtx) operate() {
 		}
 	}
 
-	if woc.wf.Spec.Suspend != nil && *woc.wf.Spec.Suspend {
+	if woc.wf.Spec.Suspend != nil && *woc.wf.Spec.Suspend && !util.IsWorkflowStopped(woc.wf) {
 		woc.log.Infof("workflow suspended")
 		return
 	}
@@ -223,6 +237,8 @@ func (woc *wfOperationCtx) operate() {
 	workflowStatus = node.Phase
 	if !node.Successful() && util.IsWorkflowTerminated(woc.wf) {
 		workflowMessage = "terminated"
+	} else if !node.Successful() && util.IsWorkflowStopped(woc.wf) {
+		workflowMessage = util.StopMessage(woc.wf)
 	} else {
 		workflowMessage = node.Message
 	}
@@ -299,6 +315,26 @@ func (woc *wfOperationCtx) getWorkflowDeadline() *time.Time {
 	return &deadline
 }
 
+// isStopping returns whether the workflow was stopped and the node has to stop with it. Nodes of the
+// exit handler keep running after a stop.
+func (woc *wfOperationCtx) isStopping(nodeName string) bool {
+	if !util.IsWorkflowStopped(woc.wf) {
+		return false
+	}
+	onExitNodeName := woc.wf.ObjectMeta.Name + ".onExit"
+	if nodeName == onExitNodeName {
+		return false
+	}
+	if strings.HasPrefix(nodeName, onExitNodeName) {
+		// children of the exit handler are named e.g. "<wf>.onExit[0].step" or "<wf>.onExit.task"
+		switch nodeName[len(onExitNodeName)] {
+		case '.', '[', '(':
+			return false
+		}
+	}
+	return true
+}
+
 // setGlobalParameters sets the globalParam map with global parameters
 func (woc *wfOperationCtx) setGlobalParameters() {
 	woc.globalParams[common.GlobalVarWorkflowName] = woc.wf.ObjectMeta.Name
@@ -402,16 +438,28 @@ func (woc *wfOperationCtx) persistUpdates() {
 			woc.persistWorkflowSizeLimitErr(wfClient, err)
 			return
 		}
+		if apierr.IsNotFound(err) {
+			return
+		}
 		if !apierr.IsConflict(err) {
+			woc.requeueWithBackoff()
 			return
 		}
+		metrics.PersistConflict()
 		woc.log.Info("Re-appying updates on latest version and retrying update")
 		err = woc.reapplyUpdate(wfClient)
 		if err != nil {
 			woc.log.Infof("Failed to re-apply update: %+v", err)
+			woc.requeueWithBackoff()
 			return
 		}
 	}
+	woc.controller.wfQueue.Forget(woc.key())
+
+	// Requests of HTTP templates are only sent once their nodes were persisted as running, so that
+	// they are not sent again if the update fails. They are sent before the workflow is saved in the
+	// database, which must not keep the requests from being sent once the update succeeded.
+	woc.sendHTTPRequests()
 
 	if woc.controller.wfDBctx != nil {
 		err = woc.controller.wfDBctx.Save(wfDB)
@@ -437,19 +485,15 @@ func (woc *wfOperationCtx) persistUpdates() {
 	// It is important that we *never* label pods as completed until we successfully updated the workflow
 	// Failing to do so means we can have inconsistent state.
 	// TODO: The completedPods will be labeled multiple times. I think it would be improved in the future.
-	// Send succeeded pods or completed pods to gcPods channel to delete it later depend on the PodGCStrategy.
+	// Queue succeeded pods or completed pods to be deleted later depending on the PodGCStrategy.
 	// Notice we do not need to label the pod if we will delete it later for GC. Otherwise, that may even result in
 	// errors if we label a pod that was deleted already.
-	if woc.wf.Spec.PodGC != nil {
-		switch woc.wf.Spec.PodGC.Strategy {
+	if podGC := woc.podGC(); podGC != nil {
+		switch podGC.Strategy {
 		case wfv1.PodGCOnPodSuccess:
-			for podName := range woc.succeededPods {
-				woc.controller.gcPods <- fmt.Sprintf("%s/%s", woc.wf.ObjectMeta.Namespace, podName)
-			}
+			woc.queuePodsForGC(woc.succeededPods)
 		case wfv1.PodGCOnPodCompletion:
-			for podName := range woc.completedPods {
-				woc.controller.gcPods <- fmt.Sprintf("%s/%s", woc.wf.ObjectMeta.Namespace, podName)
-			}
+			woc.queuePodsForGC(woc.completedPods)
 		}
 	} else {
 		// label pods which will not be deleted
@@ -490,6 +534,7 @@ func (woc *wfOperationCtx) reapplyUpdate(wfClient v1alpha1.WorkflowInterface) er
 	// Next get latest version of the workflow, apply the patch and retyr the Update
 	attempt := 1
 	for {
+		metrics.ReapplyUpdateRetry()
 		currWf, err := wfClient.Get(woc.wf.ObjectMeta.Name, metav1.GetOptions{})
 		if !retry.IsRetryableKubeAPIError(err) {
 			return errors.InternalWrapError(err)
@@ -530,6 +575,19 @@ func (woc *wfOperationCtx) requeue() {
 	woc.controller.wfQueue.Add(key)
 }
 
+// requeueWithBackoff requeues this workflow after the backoff of the workflow queue, which grows
+// with each consecutive failure to persist the workflow
+func (woc *wfOperationCtx) requeueWithBackoff() {
+	metrics.WorkflowRequeued()
+	woc.controller.wfQueue.AddRateLimited(woc.key())
+}
+
+// key returns the key of this workflow in the workqueue
+func (woc *wfOperationCtx) key() string {
+	key, _ := cache.MetaNamespaceKeyFunc(woc.wf)
+	return key
+}
+
 func (woc *wfOperationCtx) processNodeRetries(node *wfv1.NodeStatus, retryStrategy wfv1.RetryStrategy) (*wfv1.NodeStatus, error) {
 	if node.Completed() {
 		return node, nil
@@ -1127,6 +1185,16 @@ func (woc *wfOperationCtx) executeTemplate(nodeName string, orgTmpl wfv1.Templat
 		return node, ErrDeadlineExceeded
 	}
 
+	// Do not schedule new nodes of a stopped workflow and fail its suspended nodes
+	if woc.isStopping(nodeName) {
+		if node == nil {
+			return woc.initializeNode(nodeName, wfv1.NodeTypeSkipped, orgTmpl, boundaryID, wfv1.NodeFailed, util.StopMessage(woc.wf)), nil
+		}
+		if node.Type == wfv1.NodeTypeSuspend {
+			return woc.markNodePhase(nodeName, wfv1.NodeFailed, util.StopMessage(woc.wf)), nil
+		}
+	}
+
 	newTmplCtx, basedTmpl, err := woc.getResolvedTemplate(node, orgTmpl, tmplCtx, args)
 	if err != nil {
 		return woc.initializeNodeOrMarkError(node, nodeName, wfv1.NodeTypeSkipped, orgTmpl, boundaryID, err), err
@@ -1177,6 +1245,9 @@ func (woc *wfOperationCtx) executeTemplate(nodeName string, orgTmpl wfv1.Templat
 			// Last child node is still running.
 			return retryParentNode, nil
 		}
+		if woc.isStopping(retryNodeName) {
+			return woc.markNodePhase(retryNodeName, wfv1.NodeFailed, util.StopMessage(woc.wf)), nil
+		}
 		// All work is done in a child
 		childNodeName := fmt.Sprintf("%s(%d)", retryNodeName, len(retryParentNode.Children))
 		workNodeName = childNodeName
@@ -1187,7 +1258,7 @@ func (woc *wfOperationCtx) executeTemplate(nodeName string, orgTmpl wfv1.Templat
 	if node == nil {
 		var nodeType wfv1.NodeType
 		switch basedTmpl.GetType() {
-		case wfv1.TemplateTypeContainer, wfv1.TemplateTypeScript, wfv1.TemplateTypeResource:
+		case wfv1.TemplateTypeContainer, wfv1.TemplateTypeScript, wfv1.TemplateTypeResource, wfv1.TemplateTypeData:
 			nodeType = wfv1.NodeTypePod
 		case wfv1.TemplateTypeSteps:
 			nodeType = wfv1.NodeTypeSteps
@@ -1195,6 +1266,8 @@ func (woc *wfOperationCtx) executeTemplate(nodeName string, orgTmpl wfv1.Templat
 			nodeType = wfv1.NodeTypeDAG
 		case wfv1.TemplateTypeSuspend:
 			nodeType = wfv1.NodeTypeSuspend
+		case wfv1.TemplateTypeHTTP:
+			nodeType = wfv1.NodeTypeHTTP
 		default:
 			err := errors.InternalErrorf("Template '%s' has unknown node type", basedTmpl.Name)
 			return woc.initializeNode(workNodeName, wfv1.NodeTypeSkipped, orgTmpl, boundaryID, wfv1.NodeError, err.Error()), err
@@ -1215,6 +1288,10 @@ func (woc *wfOperationCtx) executeTemplate(nodeName string, orgTmpl wfv1.Templat
 		err = woc.executeDAG(node.Name, newTmplCtx, processedTmpl, boundaryID)
 	case wfv1.TemplateTypeSuspend:
 		err = woc.executeSuspend(node.Name, processedTmpl, boundaryID)
+	case wfv1.TemplateTypeHTTP:
+		err = woc.executeHTTP(node.Name, processedTmpl, boundaryID)
+	case wfv1.TemplateTypeData:
+		err = woc.executeData(node.Name, processedTmpl, boundaryID)
 	default:
 		err = errors.Errorf(errors.CodeBadRequest, "Template '%s' missing specification", processedTmpl.Name)
 	}
@@ -1291,6 +1368,7 @@ func (woc *wfOperationCtx) markWorkflowFailed(message string) {
 }
 
 func (woc *wfOperationCtx) markWorkflowError(err error, markCompleted bool) {
+	metrics.Error(err)
 	woc.markWorkflowPhase(wfv1.NodeError, markCompleted, err.Error())
 }
 
@@ -1416,6 +1494,7 @@ func (woc *wfOperationCtx) markNodePhase(nodeName string, phase wfv1.NodePhase,
 
 // markNodeError is a convenience method to mark a node with an error and set the message from the error
 func (woc *wfOperationCtx) markNodeError(nodeName string, err error) *wfv1.NodeStatus {
+	metrics.Error(err)
 	return woc.markNodePhase(nodeName, wfv1.NodeError, err.Error())
 }
 
@@ -1467,7 +1546,7 @@ func (woc *wfOperationCtx) executeContainer(nodeName string, tmpl *wfv1.Template
 func (woc *wfOperationCtx) getOutboundNodes(nodeID string) []string {
 	node := woc.wf.Status.Nodes[nodeID]
 	switch node.Type {
-	case wfv1.NodeTypePod, wfv1.NodeTypeSkipped, wfv1.NodeTypeSuspend:
+	case wfv1.NodeTypePod, wfv1.NodeTypeSkipped, wfv1.NodeTypeSuspend, wfv1.NodeTypeHTTP, wfv1.NodeTypeMap:
 		return []string{node.ID}
 	case wfv1.NodeTypeTaskGroup:
 		if len(node.Children) == 0 {
@@ -1506,7 +1585,7 @@ func getTemplateOutputsFromScope(tmpl *wfv1.Template, scope *wfScope) (*wfv1.Out
 	if len(tmpl.Outputs.Parameters) > 0 {
 		outputs.Parameters = make([]wfv1.Parameter, 0)
 		for _, param := range tmpl.Outputs.Parameters {
-			val, err := scope.resolveParameter(param.ValueFrom.Parameter)
+			val, err := resolveOutputParameter(param.ValueFrom, scope)
 			if err != nil {
 				return nil, err
 			}
@@ -1529,6 +1608,59 @@ func getTemplateOutputsFromScope(tmpl *wfv1.Template, scope *wfScope) (*wfv1.Out
 	return &outputs, nil
 }
 
+// resolveOutputParameter resolves the value of an output parameter of a steps or dag template. The
+// value is the first non-empty of the referenced parameter or the expression, and the default. The
+// parameter may only be unresolved (e.g. the step was skipped) when a default is supplied.
+func resolveOutputParameter(valueFrom *wfv1.ValueFrom, scope *wfScope) (string, error) {
+	var val string
+	if valueFrom.Expression != "" {
+		var err error
+		val, err = evaluateOutputExpression(valueFrom.Expression, scope)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		var err error
+		val, err = scope.resolveParameter(valueFrom.Parameter)
+		if err != nil && valueFrom.Default == nil {
+			return "", err
+		}
+	}
+	if val == "" && valueFrom.Default != nil {
+		return *valueFrom.Default, nil
+	}
+	return val, nil
+}
+
+// evaluateOutputExpression evaluates the expression with the outputs in scope. References to the outputs
+// of skipped steps or tasks are resolved as empty strings, so that an expression can choose between the
+// outputs of conditionally executed steps. Any other unresolved reference is an error.
+func evaluateOutputExpression(expr string, scope *wfScope) (string, error) {
+	values := scope.replaceMap()
+	fstTmpl := fasttemplate.New(expr, "{{", "}}")
+	_ = fstTmpl.ExecuteFuncString(func(w io.Writer, tag string) (int, error) {
+		tag = strings.TrimSpace(tag)
+		if _, ok := values[tag]; !ok && scope.isSkipped(tag) {
+			values[tag] = ""
+		}
+		return 0, nil
+	})
+	result, err := common.EvaluateExpression(expr, values)
+	if err != nil {
+		return "", err
+	}
+	switch val := result.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	default:
+		return fmt.Sprintf("%v", val), nil
+	}
+}
+
 // hasOutputResultRef will check given template output has any reference
 func hasOutputResultRef(name string, parentTmpl *wfv1.Template) bool {
 
@@ -1589,6 +1721,9 @@ func (woc *wfOperationCtx) processNodeOutputs(scope *wfScope, prefix string, nod
 		key := fmt.Sprintf("%s.ip", prefix)
 		scope.addParamToScope(key, node.PodIP)
 	}
+	if node.Phase == wfv1.NodeSkipped {
+		scope.addSkippedToScope(prefix)
+	}
 	woc.addOutputsToScope(prefix, node.Outputs, scope)
 }
 
@@ -1675,7 +1810,7 @@ func (woc *wfOperationCtx) processAggregateNodeOutputs(tmpl *wfv1.Template, scop
 			}
 		}
 	}
-	if tmpl.GetType() == wfv1.TemplateTypeScript {
+	if tmpl.GetType() == wfv1.TemplateTypeScript || tmpl.GetType() == wfv1.TemplateTypeHTTP || tmpl.GetType() == wfv1.TemplateTypeData {
 		resultsJSON, err := json.Marshal(resultsList)
 		if err != nil {
 			return err
@@ -1789,14 +1924,16 @@ func (woc *wfOperationCtx) addChildNode(parent string, child string) {
 func (woc *wfOperationCtx) executeResource(nodeName string, tmpl *wfv1.Template, boundaryID string) error {
 	tmpl = tmpl.DeepCopy()
 
-	// Try to unmarshal the given manifest.
-	obj := unstructured.Unstructured{}
-	err := yaml.Unmarshal([]byte(tmpl.Resource.Manifest), &obj)
-	if err != nil {
-		return err
-	}
-
 	if tmpl.Resource.SetOwnerReference {
+		if tmpl.Resource.Action == "patch" && tmpl.Resource.MergeStrategy == "json" {
+			return errors.Errorf(errors.CodeBadRequest, "setOwnerReference is not supported for json patches")
+		}
+		// Try to unmarshal the given manifest.
+		obj := unstructured.Unstructured{}
+		err := yaml.Unmarshal([]byte(tmpl.Resource.Manifest), &obj)
+		if err != nil {
+			return err
+		}
 		ownerReferences := obj.GetOwnerReferences()
 		obj.SetOwnerReferences(append(ownerReferences, *metav1.NewControllerRef(woc.wf, wfv1.SchemeGroupVersion.WithKind(workflow.WorkflowKind))))
 		bytes, err := yaml.Marshal(obj.Object)
@@ -1808,13 +1945,20 @@ func (woc *wfOperationCtx) executeResource(nodeName string, tmpl *wfv1.Template,
 
 	mainCtr := woc.newExecContainer(common.MainContainerName, tmpl)
 	mainCtr.Command = []string{"argoexec", "resource", tmpl.Resource.Action}
-	_, err = woc.createWorkflowPod(nodeName, *mainCtr, tmpl, false)
+	_, err := woc.createWorkflowPod(nodeName, *mainCtr, tmpl, false)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func (woc *wfOperationCtx) executeData(nodeName string, tmpl *wfv1.Template, boundaryID string) error {
+	mainCtr := woc.newExecContainer(common.MainContainerName, tmpl)
+	mainCtr.Command = []string{"argoexec", "data"}
+	_, err := woc.createWorkflowPod(nodeName, *mainCtr, tmpl, false)
+	return err
+}
+
 func (woc *wfOperationCtx) executeSuspend(nodeName string, tmpl *wfv1.Template, boundaryID string) error {
 	woc.log.Infof("node %s suspended", nodeName)
 	_ = woc.markNodePhase(nodeName, wfv1.NodeRunning)
@@ -1868,6 +2012,26 @@ func processItem(fstTmpl *fasttemplate.Template, name string, index int, item wf
 	return newName, nil
 }
 
+// resolveItems returns the items a step or task is expanded with, from either withItems, withParam
+// or withSequence
+func resolveItems(withItems []wfv1.Item, withParam string, withSequence *wfv1.Sequence) ([]wfv1.Item, error) {
+	if len(withItems) > 0 {
+		return withItems, nil
+	}
+	if withParam != "" {
+		var items []wfv1.Item
+		err := json.Unmarshal([]byte(withParam), &items)
+		if err != nil {
+			return nil, errors.Errorf(errors.CodeBadRequest, "withParam value could not be parsed as a JSON list: %s", strings.TrimSpace(withParam))
+		}
+		return items, nil
+	}
+	if withSequence != nil {
+		return expandSequence(withSequence)
+	}
+	return nil, nil
+}
+
 func expandSequence(seq *wfv1.Sequence) ([]wfv1.Item, error) {
 	var start, end int
 	var err error
@@ -504,6 +504,79 @@ func TestSuspendResume(t *testing.T) {
 	assert.Equal(t, 2, len(pods.Items))
 }
 
+var stepsWithExitHandler = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: steps-with-exit-handler
+spec:
+  entrypoint: steps
+  onExit: exit-handler
+  templates:
+  - name: steps
+    steps:
+    - - name: step1
+        template: whalesay
+    - - name: step2
+        template: whalesay
+  - name: exit-handler
+    container:
+      image: docker/whalesay:latest
+      command: [cowsay]
+  - name: whalesay
+    container:
+      image: docker/whalesay:latest
+      command: [cowsay]
+`
+
+// TestStopWorkflow verifies a stopped workflow signals its running pods and schedules no new steps,
+// but still runs its exit handler
+func TestStopWorkflow(t *testing.T) {
+	controller := newController()
+	wfcset := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+	wf := unmarshalWF(stepsWithExitHandler)
+	wf, err := wfcset.Create(wf)
+	assert.Nil(t, err)
+	woc := newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+	makePodsPhase(t, controller.kubeclientset, "", apiv1.PodPending)
+
+	err = util.StopWorkflow(wfcset, wf.ObjectMeta.Name, "alice")
+	assert.Nil(t, err)
+	wf, err = wfcset.Get(wf.ObjectMeta.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.True(t, util.IsWorkflowStopped(wf))
+
+	// the pending pod is deleted, step2 is not scheduled, but the exit handler is
+	woc = newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+	step1 := woc.getNodeByName(wf.ObjectMeta.Name + "[0].step1")
+	if assert.NotNil(t, step1) {
+		assert.Equal(t, wfv1.NodeFailed, step1.Phase)
+		assert.Equal(t, "Stopped by alice", step1.Message)
+	}
+	assert.Nil(t, woc.getNodeByName(wf.ObjectMeta.Name+"[1].step2"))
+	onExitNode := woc.getNodeByName(wf.ObjectMeta.Name + ".onExit")
+	if assert.NotNil(t, onExitNode) {
+		assert.Equal(t, wfv1.NodePending, onExitNode.Phase)
+	}
+	pods, err := controller.kubeclientset.CoreV1().Pods("").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(pods.Items)) {
+		assert.Equal(t, onExitNode.ID, pods.Items[0].Name)
+	}
+
+	// the workflow fails with the stop message once the exit handler completed
+	makePodsPhase(t, controller.kubeclientset, "", apiv1.PodSucceeded)
+	wf, err = wfcset.Get(wf.ObjectMeta.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	woc = newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+	assert.Equal(t, wfv1.NodeFailed, woc.wf.Status.Phase)
+	assert.Equal(t, "Stopped by alice", woc.wf.Status.Message)
+	assert.Equal(t, wfv1.NodeSucceeded, woc.getNodeByName(wf.ObjectMeta.Name+".onExit").Phase)
+}
+
 var inputParametersAsJson = `
 apiVersion: argoproj.io/v1alpha1
 kind: Workflow
@@ -1479,3 +1552,47 @@ func TestWithParamAsJsonList(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 4, len(pods.Items))
 }
+
+// TestConditionalTemplateOutputs verifies the outputs of a steps template can be chosen from the outputs
+// of conditionally executed steps
+func TestConditionalTemplateOutputs(t *testing.T) {
+	defaultValue := "unknown"
+	tmpl := &wfv1.Template{
+		Outputs: wfv1.Outputs{
+			Parameters: []wfv1.Parameter{
+				{Name: "result", ValueFrom: &wfv1.ValueFrom{
+					Expression: "'{{steps.flip.outputs.result}}' == 'heads' ? '{{steps.heads.outputs.result}}' : '{{steps.tails.outputs.result}}'",
+				}},
+				{Name: "tails", ValueFrom: &wfv1.ValueFrom{Parameter: "{{steps.tails.outputs.result}}", Default: &defaultValue}},
+				{Name: "count", ValueFrom: &wfv1.ValueFrom{Expression: "{{steps.count.outputs.result}} + 1"}},
+			},
+		},
+	}
+	scope := &wfScope{tmpl: tmpl, scope: make(map[string]interface{})}
+	scope.addParamToScope("steps.flip.outputs.result", "heads")
+	scope.addParamToScope("steps.heads.outputs.result", "it was heads")
+	scope.addParamToScope("steps.count.outputs.result", "2")
+	scope.addSkippedToScope("steps.tails")
+
+	outputs, err := getTemplateOutputsFromScope(tmpl, scope)
+	assert.NoError(t, err)
+	assert.Equal(t, "it was heads", *outputs.Parameters[0].Value)
+	assert.Equal(t, "unknown", *outputs.Parameters[1].Value)
+	assert.Equal(t, "3", *outputs.Parameters[2].Value)
+
+	// an unresolved parameter without a default is an error
+	tmpl.Outputs.Parameters[1].ValueFrom.Default = nil
+	_, err = getTemplateOutputsFromScope(tmpl, scope)
+	assert.Error(t, err)
+
+	// only the outputs of skipped steps are resolved as empty strings in expressions
+	scope.skipped = nil
+	_, err = evaluateOutputExpression(tmpl.Outputs.Parameters[0].ValueFrom.Expression, scope)
+	assert.EqualError(t, err, "Unable to resolve {{steps.tails.outputs.result}} in expression '"+tmpl.Outputs.Parameters[0].ValueFrom.Expression+"'")
+
+	// values are passed as parameters, so quotes in them cannot change the expression
+	scope.addParamToScope("steps.heads.outputs.result", "it's heads")
+	val, err := evaluateOutputExpression("'{{steps.heads.outputs.result}}'", scope)
+	assert.NoError(t, err)
+	assert.Equal(t, "it's heads", val)
+}
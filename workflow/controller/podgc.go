@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
+)
+
+// podGC returns the strategy to delete the completed pods of the workflow, which defaults to the one
+// of the controller
+func (woc *wfOperationCtx) podGC() *wfv1.PodGC {
+	if woc.wf.Spec.PodGC != nil {
+		return woc.wf.Spec.PodGC
+	}
+	return woc.controller.Config.PodGC
+}
+
+// queuePodsForGC queues the given pods to be deleted once the delay of the pod GC strategy passed.
+// The time a pod is due is persisted in an annotation of the pod, so that the deletion is resumed
+// if the controller restarts in the meantime. Pods which do not match the label selector of the
+// strategy, or which cannot be checked against it, are labeled completed instead.
+func (woc *wfOperationCtx) queuePodsForGC(podNames map[string]bool) {
+	podGC := woc.podGC()
+	var delay time.Duration
+	if podGC.DeleteDelayDuration != nil {
+		delay = podGC.DeleteDelayDuration.Duration
+	}
+	selector := labels.Everything()
+	if podGC.LabelSelector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(podGC.LabelSelector)
+		if err != nil {
+			woc.log.Warnf("Invalid pod GC label selector, no pods are deleted: %v", err)
+			selector = labels.Nothing()
+		}
+	}
+	for podName := range podNames {
+		key := fmt.Sprintf("%s/%s", woc.wf.ObjectMeta.Namespace, podName)
+		pod, err := woc.getPod(podName)
+		if err != nil {
+			woc.log.Warnf("Failed to get pod %s for gc, labeling it completed instead: %v", key, err)
+			woc.controller.completedPods <- key
+			continue
+		}
+		if pod == nil {
+			continue
+		}
+		if !selector.Matches(labels.Set(pod.ObjectMeta.Labels)) {
+			// label pods which will not be deleted
+			woc.controller.completedPods <- key
+			continue
+		}
+		deleteAfter, ok := podDeleteAfter(pod)
+		if !ok {
+			deleteAfter = time.Now().Add(delay)
+			err = common.AddPodAnnotation(woc.controller.kubeclientset, podName, pod.ObjectMeta.Namespace, common.AnnotationKeyPodGCDeleteAfter, deleteAfter.UTC().Format(time.RFC3339))
+			if err != nil {
+				if apierr.IsNotFound(err) {
+					continue
+				}
+				woc.log.Warnf("Failed to annotate pod %s for gc, its deletion is not resumed after a restart: %v", key, err)
+			}
+		}
+		woc.controller.queuePodForGC(key, deleteAfter)
+	}
+}
+
+// queuePodForGC queues a pod to be deleted at the given time
+func (wfc *WorkflowController) queuePodForGC(key string, deleteAfter time.Time) {
+	wfc.gcPodsPending.add(key)
+	wfc.gcPods.AddAfter(key, time.Until(deleteAfter))
+}
+
+// queuePodsAnnotatedForGC queues the pods in the informer which were annotated to be deleted, e.g. by
+// a controller which stopped before it deleted them
+func (wfc *WorkflowController) queuePodsAnnotatedForGC() {
+	for _, obj := range wfc.podInformer.GetIndexer().List() {
+		pod, ok := obj.(*apiv1.Pod)
+		if !ok {
+			continue
+		}
+		if deleteAfter, ok := podDeleteAfter(pod); ok {
+			wfc.queuePodForGC(pod.ObjectMeta.Namespace+"/"+pod.ObjectMeta.Name, deleteAfter)
+		}
+	}
+}
+
+// podDeleteAfter returns the time after which a pod is to be deleted, and whether it is annotated with one
+func podDeleteAfter(pod *apiv1.Pod) (time.Time, bool) {
+	value, ok := pod.ObjectMeta.Annotations[common.AnnotationKeyPodGCDeleteAfter]
+	if !ok {
+		return time.Time{}, false
+	}
+	deleteAfter, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Warnf("Invalid %s annotation on pod %s/%s, deleting it now: %v", common.AnnotationKeyPodGCDeleteAfter, pod.ObjectMeta.Namespace, pod.ObjectMeta.Name, err)
+		return time.Now(), true
+	}
+	return deleteAfter, true
+}
+
+// getCachedPod returns a pod of the workflow from the informer of the controller, or nil if the informer
+// does not have it
+func (woc *wfOperationCtx) getCachedPod(podName string) *apiv1.Pod {
+	if woc.controller.podInformer == nil {
+		return nil
+	}
+	obj, exists, err := woc.controller.podInformer.GetIndexer().GetByKey(woc.wf.ObjectMeta.Namespace + "/" + podName)
+	if err != nil || !exists {
+		return nil
+	}
+	pod, _ := obj.(*apiv1.Pod)
+	return pod
+}
+
+// getPod returns a pod of the workflow from the informer of the controller, or from the API server if
+// the informer does not have it. It returns nil if the pod no longer exists.
+func (woc *wfOperationCtx) getPod(podName string) (*apiv1.Pod, error) {
+	if pod := woc.getCachedPod(podName); pod != nil {
+		return pod, nil
+	}
+	pod, err := woc.controller.kubeclientset.CoreV1().Pods(woc.wf.ObjectMeta.Namespace).Get(podName, metav1.GetOptions{})
+	if err != nil {
+		if apierr.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return pod, nil
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
+)
+
+var podGCWorkflow = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: pod-gc
+spec:
+  entrypoint: main
+  podGC:
+    strategy: OnPodCompletion
+    labelSelector:
+      matchLabels:
+        evict: "true"
+  templates:
+  - name: main
+    container:
+      image: docker/whalesay:latest
+`
+
+// TestQueuePodsForGC verifies only pods matching the label selector are queued for deletion
+func TestQueuePodsForGC(t *testing.T) {
+	controller := newController()
+	wf := unmarshalWF(podGCWorkflow)
+	woc := newWorkflowOperationCtx(wf, controller)
+	for name, evict := range map[string]string{"pod-a": "true", "pod-b": "false"} {
+		_, err := controller.kubeclientset.CoreV1().Pods("").Create(&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"evict": evict},
+		}})
+		assert.NoError(t, err)
+	}
+
+	woc.queuePodsForGC(map[string]bool{"pod-a": true, "pod-b": true, "pod-deleted": true})
+	assert.Equal(t, 1, controller.gcPods.Len())
+	key, _ := controller.gcPods.Get()
+	assert.Equal(t, "/pod-a", key)
+	controller.gcPods.Done(key)
+	assert.Equal(t, 1, len(controller.completedPods))
+	assert.Equal(t, "/pod-b", <-controller.completedPods)
+
+	// the pod to be deleted is annotated with when it is due
+	pod, err := controller.kubeclientset.CoreV1().Pods("").Get("pod-a", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, ok := podDeleteAfter(pod)
+	assert.True(t, ok)
+	pod, err = controller.kubeclientset.CoreV1().Pods("").Get("pod-b", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, pod.ObjectMeta.Annotations, common.AnnotationKeyPodGCDeleteAfter)
+}
+
+// TestQueuePodsForGCGetError verifies a pod which cannot be checked against the label selector is labeled completed
+func TestQueuePodsForGCGetError(t *testing.T) {
+	controller := newController()
+	controller.kubeclientset.(*fake.Clientset).PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierr.NewInternalError(fmt.Errorf("unavailable"))
+	})
+	woc := newWorkflowOperationCtx(unmarshalWF(podGCWorkflow), controller)
+
+	woc.queuePodsForGC(map[string]bool{"pod-a": true})
+	assert.Equal(t, 0, controller.gcPods.Len())
+	assert.Equal(t, "/pod-a", <-controller.completedPods)
+}
+
+// TestQueuePodsForGCWithDelay verifies pods are only queued for deletion once their delay passed
+func TestQueuePodsForGCWithDelay(t *testing.T) {
+	controller := newController()
+	wf := unmarshalWF(podGCWorkflow)
+	wf.Spec.PodGC.LabelSelector = nil
+	wf.Spec.PodGC.DeleteDelayDuration = &metav1.Duration{Duration: 200 * time.Millisecond}
+	woc := newWorkflowOperationCtx(wf, controller)
+	_, err := controller.kubeclientset.CoreV1().Pods("").Create(&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-a"}})
+	assert.NoError(t, err)
+
+	woc.queuePodsForGC(map[string]bool{"pod-a": true})
+	assert.Equal(t, 0, controller.gcPods.Len())
+	time.Sleep(500 * time.Millisecond)
+	assert.Equal(t, 1, controller.gcPods.Len())
+}
+
+// TestQueuePodsAnnotatedForGC verifies the deletion of the pods annotated by a previous controller is resumed
+func TestQueuePodsAnnotatedForGC(t *testing.T) {
+	controller := newController()
+	controller.podInformer = cache.NewSharedIndexInformer(&cache.ListWatch{}, &apiv1.Pod{}, 0, cache.Indexers{})
+	pods := []*apiv1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "due", Namespace: "ns", Annotations: map[string]string{
+			common.AnnotationKeyPodGCDeleteAfter: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "later", Namespace: "ns", Annotations: map[string]string{
+			common.AnnotationKeyPodGCDeleteAfter: time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "kept", Namespace: "ns"}},
+	}
+	for _, pod := range pods {
+		assert.NoError(t, controller.podInformer.GetIndexer().Add(pod))
+	}
+
+	controller.queuePodsAnnotatedForGC()
+	assert.Equal(t, []string{"ns/due", "ns/later"}, controller.gcPodsPending.list())
+	assert.Equal(t, 1, controller.gcPods.Len())
+	key, _ := controller.gcPods.Get()
+	assert.Equal(t, "ns/due", key)
+	controller.gcPods.Done(key)
+}
+
+// TestPodGCDefault verifies the pod GC strategy of the controller applies to workflows without one
+func TestPodGCDefault(t *testing.T) {
+	controller := newController()
+	controller.Config.PodGC = &wfv1.PodGC{Strategy: wfv1.PodGCOnWorkflowSuccess}
+	wf := unmarshalWF(podGCWorkflow)
+	woc := newWorkflowOperationCtx(wf, controller)
+	assert.Equal(t, wfv1.PodGCOnPodCompletion, woc.podGC().Strategy)
+
+	wf.Spec.PodGC = nil
+	woc = newWorkflowOperationCtx(wf, controller)
+	assert.Equal(t, wfv1.PodGCOnWorkflowSuccess, woc.podGC().Strategy)
+}
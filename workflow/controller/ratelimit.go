@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj/argo/workflow/config"
+	"github.com/argoproj/argo/workflow/metrics"
+)
+
+const (
+	// defaultRequeueBaseDelay and defaultRequeueMaxDelay are the backoff of the default rate limiter
+	// of controller workqueues
+	defaultRequeueBaseDelay = 5 * time.Millisecond
+	defaultRequeueMaxDelay  = 1000 * time.Second
+)
+
+// throttleCountingRateLimiter is a rate limiter of API requests which counts the requests it delays
+type throttleCountingRateLimiter struct {
+	flowcontrol.RateLimiter
+}
+
+// NewThrottleCountingRateLimiter returns a token bucket rate limiter for the client of the controller,
+// which records the requests it delays in the controller metrics
+func NewThrottleCountingRateLimiter(qps float32, burst int) flowcontrol.RateLimiter {
+	return &throttleCountingRateLimiter{RateLimiter: flowcontrol.NewTokenBucketRateLimiter(qps, burst)}
+}
+
+func (r *throttleCountingRateLimiter) Accept() {
+	if r.TryAccept() {
+		return
+	}
+	metrics.Throttled(metrics.ThrottleKindClient)
+	r.RateLimiter.Accept()
+}
+
+// podCreationLimiter limits the rate at which the controller creates pods, if configured
+type podCreationLimiter struct {
+	lock    sync.Mutex
+	limit   config.RateLimit
+	limiter *rate.Limiter
+}
+
+// set updates the rate limit. A nil rate limit removes the limit.
+func (p *podCreationLimiter) set(limit *config.RateLimit) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if limit == nil || limit.Limit <= 0 {
+		p.limit = config.RateLimit{}
+		p.limiter = nil
+		return
+	}
+	if p.limiter != nil && *limit == p.limit {
+		return
+	}
+	burst := limit.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	p.limit = *limit
+	p.limiter = rate.NewLimiter(rate.Limit(limit.Limit), burst)
+}
+
+// reserve takes the token to create a pod if one is available, and returns zero. Otherwise it takes
+// nothing, and returns how long it takes for a token to become available.
+func (p *podCreationLimiter) reserve() time.Duration {
+	p.lock.Lock()
+	limiter := p.limiter
+	p.lock.Unlock()
+	if limiter == nil {
+		return 0
+	}
+	r := limiter.Reserve()
+	delay := r.Delay()
+	if delay > 0 {
+		r.Cancel()
+		metrics.Throttled(metrics.ThrottleKindPodCreation)
+	}
+	return delay
+}
+
+// requeueRateLimiter is the rate limiter of the workflow queue, whose backoff per workflow is set
+// by the controller configmap
+type requeueRateLimiter struct {
+	lock    sync.RWMutex
+	backoff config.RequeueBackoff
+	limiter workqueue.RateLimiter
+}
+
+func newRequeueRateLimiter() *requeueRateLimiter {
+	r := &requeueRateLimiter{}
+	r.setBackoff(nil)
+	return r
+}
+
+// setBackoff updates the backoff of the rate limiter, which resets the backoff of all workflows.
+// A nil backoff restores the default backoff.
+func (r *requeueRateLimiter) setBackoff(backoff *config.RequeueBackoff) {
+	b := config.RequeueBackoff{}
+	if backoff != nil {
+		b = *backoff
+	}
+	if b.BaseDelay.Duration <= 0 {
+		b.BaseDelay.Duration = defaultRequeueBaseDelay
+	}
+	if b.MaxDelay.Duration <= 0 {
+		b.MaxDelay.Duration = defaultRequeueMaxDelay
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.limiter != nil && b == r.backoff {
+		return
+	}
+	r.backoff = b
+	// the overall rate limit is the same as the one of the default controller rate limiter
+	r.limiter = workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(b.BaseDelay.Duration, b.MaxDelay.Duration),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
+
+func (r *requeueRateLimiter) When(item interface{}) time.Duration {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.limiter.When(item)
+}
+
+func (r *requeueRateLimiter) Forget(item interface{}) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	r.limiter.Forget(item)
+}
+
+func (r *requeueRateLimiter) NumRequeues(item interface{}) int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.limiter.NumRequeues(item)
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/config"
+)
+
+// TestPodCreationLimiter verifies pod creations beyond the burst are refused until a token is available
+func TestPodCreationLimiter(t *testing.T) {
+	var limiter podCreationLimiter
+	limiter.set(&config.RateLimit{Limit: 10, Burst: 2})
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	delay := limiter.reserve()
+	assert.True(t, delay > 0 && delay <= 100*time.Millisecond)
+	// a refused creation does not take a token
+	assert.True(t, limiter.reserve() <= delay)
+	time.Sleep(delay)
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+
+	limiter.set(nil)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, time.Duration(0), limiter.reserve())
+	}
+}
+
+var parallelStepsWf = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: parallel-steps
+  namespace: default
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    steps:
+    - - name: a
+        template: whalesay
+      - name: b
+        template: whalesay
+      - name: c
+        template: whalesay
+  - name: whalesay
+    container:
+      image: docker/whalesay:latest
+`
+
+// TestPodCreationRateLimited verifies an operation stops creating pods once the pod creation rate limit
+// is reached, and leaves the remaining nodes pending to be retried
+func TestPodCreationRateLimited(t *testing.T) {
+	controller := newController()
+	controller.podCreationLimiter.set(&config.RateLimit{Limit: 1, Burst: 1})
+	wfcs := controller.wfclientset.ArgoprojV1alpha1().Workflows("default")
+	wf, err := wfcs.Create(unmarshalWF(parallelStepsWf))
+	assert.NoError(t, err)
+
+	woc := newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+	pods, err := controller.kubeclientset.CoreV1().Pods("").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, pods.Items, 1)
+	assert.True(t, woc.podCreationThrottled)
+	pending := 0
+	for _, node := range woc.wf.Status.Nodes {
+		if node.Type == wfv1.NodeTypePod && node.StartedAt.IsZero() {
+			assert.Equal(t, wfv1.NodePending, node.Phase)
+			pending++
+		}
+	}
+	assert.Equal(t, 2, pending)
+
+	// the nodes are retried once a pod may be created, and the pod which exists is not created again
+	makePodsRunning(t, controller.kubeclientset, "default")
+	controller.podInformer = cache.NewSharedIndexInformer(&cache.ListWatch{}, &apiv1.Pod{}, 0, cache.Indexers{})
+	pods, err = controller.kubeclientset.CoreV1().Pods("").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, controller.podInformer.GetIndexer().Add(&pods.Items[0]))
+	time.Sleep(time.Second)
+	wf, err = wfcs.Get(wf.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	woc = newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+	pods, err = controller.kubeclientset.CoreV1().Pods("").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, pods.Items, 2)
+	for _, node := range woc.wf.Status.Nodes {
+		assert.NotEqual(t, wfv1.NodeError, node.Phase, node.Name)
+	}
+}
+
+// TestRequeueRateLimiter verifies the backoff of a workflow grows with its requeues, and is reset
+// by forgetting the workflow or configuring another backoff
+func TestRequeueRateLimiter(t *testing.T) {
+	limiter := newRequeueRateLimiter()
+	limiter.setBackoff(&config.RequeueBackoff{
+		BaseDelay: metav1.Duration{Duration: time.Second},
+		MaxDelay:  metav1.Duration{Duration: 3 * time.Second},
+	})
+	assert.Equal(t, time.Second, limiter.When("default/wf"))
+	assert.Equal(t, 2*time.Second, limiter.When("default/wf"))
+	assert.Equal(t, 3*time.Second, limiter.When("default/wf"))
+	assert.Equal(t, 3, limiter.NumRequeues("default/wf"))
+
+	limiter.Forget("default/wf")
+	assert.Equal(t, time.Second, limiter.When("default/wf"))
+
+	// the same backoff keeps the requeues of the workflows
+	limiter.setBackoff(&config.RequeueBackoff{
+		BaseDelay: metav1.Duration{Duration: time.Second},
+		MaxDelay:  metav1.Duration{Duration: 3 * time.Second},
+	})
+	assert.Equal(t, 1, limiter.NumRequeues("default/wf"))
+
+	limiter.setBackoff(nil)
+	assert.Equal(t, 0, limiter.NumRequeues("default/wf"))
+	assert.Equal(t, defaultRequeueBaseDelay, limiter.When("default/wf"))
+}
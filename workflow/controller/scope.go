@@ -11,6 +11,8 @@ import (
 type wfScope struct {
 	tmpl  *wfv1.Template
 	scope map[string]interface{}
+	// skipped holds the prefixes (e.g. steps.foo) of the steps and tasks which were skipped
+	skipped map[string]bool
 }
 
 // replaceMap returns a replacement map of strings intended to be used simple string substitution
@@ -33,6 +35,19 @@ func (s *wfScope) addArtifactToScope(key string, artifact wfv1.Artifact) {
 	s.scope[key] = artifact
 }
 
+func (s *wfScope) addSkippedToScope(prefix string) {
+	if s.skipped == nil {
+		s.skipped = make(map[string]bool)
+	}
+	s.skipped[prefix] = true
+}
+
+// isSkipped returns whether a variable refers to the outputs of a skipped step or task
+func (s *wfScope) isSkipped(v string) bool {
+	parts := strings.SplitN(v, ".", 3)
+	return len(parts) == 3 && s.skipped[parts[0]+"."+parts[1]]
+}
+
 // resolveVar resolves a parameter or artifact
 func (s *wfScope) resolveVar(v string) (interface{}, error) {
 	v = strings.TrimPrefix(v, "{{")
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/argoproj/argo/errors"
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
+)
+
+// SetShard configures the controller to only operate on the workflows of the given shard, out of
+// the given number of shards. Workflows are assigned to a shard by the hash of their UID.
+func (wfc *WorkflowController) SetShard(shard, shards int) error {
+	if shards < 1 {
+		return errors.Errorf(errors.CodeBadRequest, "number of shards must be at least 1, got %d", shards)
+	}
+	if shard < 0 || shard >= shards {
+		return errors.Errorf(errors.CodeBadRequest, "shard must be between 0 and %d, got %d", shards-1, shard)
+	}
+	wfc.shard = shard
+	wfc.shards = shards
+	return nil
+}
+
+// sharded returns whether the workflows are split across multiple controllers
+func (wfc *WorkflowController) sharded() bool {
+	return wfc.shards > 1
+}
+
+// shardOf returns the shard a workflow belongs to, out of the given number of shards
+func shardOf(uid types.UID, shards int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(uid))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// addShardRequirement restricts a selector of workflows or pods to the shard of the controller. Since
+// workflows are only labeled once a controller sees them, the selector excludes the other shards
+// rather than requiring the shard of the controller, which also matches workflows which are not
+// assigned a shard yet or were assigned a shard which no longer exists.
+func (wfc *WorkflowController) addShardRequirement(selector labels.Selector) labels.Selector {
+	if !wfc.sharded() {
+		return selector
+	}
+	others := make([]string, 0, wfc.shards-1)
+	for i := 0; i < wfc.shards; i++ {
+		if i != wfc.shard {
+			others = append(others, strconv.Itoa(i))
+		}
+	}
+	shardReq, err := labels.NewRequirement(common.LabelKeyShard, selection.NotIn, others)
+	if err != nil {
+		panic(err)
+	}
+	return selector.Add(*shardReq)
+}
+
+// reconcileShard assigns a workflow to a shard and returns whether that is the shard of this controller.
+// A workflow keeps the shard it was assigned to for as long as that shard exists, so that controllers
+// which disagree on the number of shards, e.g. while the number is being changed, never relabel a
+// workflow back and forth. A workflow which is not assigned a shard, or was assigned a shard which no
+// longer exists, is assigned by the controller of the shard it belongs to by the hash of its UID. A
+// workflow which was relabeled is operated on once the informer of its new shard sees the label.
+// Hence only shrinking the number of shards reassigns existing workflows. Growing it leaves them,
+// including those which have not started yet, on their shards, and only new workflows are assigned
+// to the new shards: moving them would make a controller which still runs with the smaller number
+// assign them back.
+func (wfc *WorkflowController) reconcileShard(wf *wfv1.Workflow) (bool, error) {
+	if !wfc.sharded() {
+		return true, nil
+	}
+	current, err := strconv.Atoi(wf.ObjectMeta.Labels[common.LabelKeyShard])
+	if err == nil && current >= 0 && current < wfc.shards {
+		return current == wfc.shard, nil
+	}
+	if shardOf(wf.UID, wfc.shards) != wfc.shard {
+		// the workflow is assigned by the controller of the shard it belongs to
+		return false, nil
+	}
+	shard := strconv.Itoa(wfc.shard)
+	// relabel the pods first, so that the controller of the new shard sees them along with the workflow
+	pods, err := wfc.kubeclientset.CoreV1().Pods(wf.ObjectMeta.Namespace).List(metav1.ListOptions{
+		LabelSelector: common.LabelKeyWorkflow + "=" + wf.ObjectMeta.Name,
+	})
+	if err != nil {
+		return false, errors.InternalWrapError(err)
+	}
+	for _, pod := range pods.Items {
+		err = common.AddPodLabel(wfc.kubeclientset, pod.Name, pod.Namespace, common.LabelKeyShard, shard)
+		if err != nil {
+			return false, errors.InternalWrapError(err)
+		}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{
+				common.LabelKeyShard: shard,
+			},
+		},
+	})
+	if err != nil {
+		return false, errors.InternalWrapError(err)
+	}
+	_, err = wfc.wfclientset.ArgoprojV1alpha1().Workflows(wf.ObjectMeta.Namespace).Patch(wf.ObjectMeta.Name, types.MergePatchType, patch)
+	if err != nil {
+		return false, errors.InternalWrapError(err)
+	}
+	log.Infof("Assigned workflow %s/%s to shard %s", wf.ObjectMeta.Namespace, wf.ObjectMeta.Name, shard)
+	return false, nil
+}
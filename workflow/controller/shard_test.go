@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
+)
+
+func TestSetShard(t *testing.T) {
+	controller := newController()
+	assert.Error(t, controller.SetShard(0, 0))
+	assert.Error(t, controller.SetShard(3, 3))
+	assert.Error(t, controller.SetShard(-1, 3))
+	assert.NoError(t, controller.SetShard(2, 3))
+	assert.True(t, controller.sharded())
+}
+
+func TestShardOf(t *testing.T) {
+	counts := make([]int, 3)
+	for i := 0; i < 300; i++ {
+		shard := shardOf(types.UID(strconv.Itoa(i)), 3)
+		assert.Equal(t, shard, shardOf(types.UID(strconv.Itoa(i)), 3))
+		counts[shard]++
+	}
+	for _, count := range counts {
+		assert.True(t, count > 50)
+	}
+}
+
+func TestAddShardRequirement(t *testing.T) {
+	controller := newController()
+	assert.Equal(t, "", controller.addShardRequirement(labels.NewSelector()).String())
+	assert.NoError(t, controller.SetShard(1, 3))
+	selector := controller.addShardRequirement(labels.NewSelector())
+	assert.Equal(t, common.LabelKeyShard+" notin (0,2)", selector.String())
+	assert.True(t, selector.Matches(labels.Set{}))
+	assert.True(t, selector.Matches(labels.Set{common.LabelKeyShard: "1"}))
+	assert.True(t, selector.Matches(labels.Set{common.LabelKeyShard: "5"}))
+	assert.False(t, selector.Matches(labels.Set{common.LabelKeyShard: "2"}))
+}
+
+// uidOfShard returns a UID which belongs to the given shard
+func uidOfShard(shard, shards int) types.UID {
+	for i := 0; ; i++ {
+		uid := types.UID(strconv.Itoa(i))
+		if shardOf(uid, shards) == shard {
+			return uid
+		}
+	}
+}
+
+func TestReconcileShard(t *testing.T) {
+	controller := newController()
+	assert.NoError(t, controller.SetShard(1, 3))
+	wfcs := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+	createWf := func(name string, uid types.UID, shard string) *wfv1.Workflow {
+		wf := &wfv1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: name, UID: uid, Labels: map[string]string{}}}
+		if shard != "" {
+			wf.ObjectMeta.Labels[common.LabelKeyShard] = shard
+		}
+		wf, err := wfcs.Create(wf)
+		assert.NoError(t, err)
+		return wf
+	}
+	shardLabel := func(name string) string {
+		wf, err := wfcs.Get(name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return wf.ObjectMeta.Labels[common.LabelKeyShard]
+	}
+
+	// a workflow assigned to the shard of the controller is operated on
+	owned, err := controller.reconcileShard(createWf("owned", uidOfShard(1, 3), "1"))
+	assert.NoError(t, err)
+	assert.True(t, owned)
+
+	// an unassigned workflow is labeled by the controller of its shard, along with its pods
+	_, err = controller.kubeclientset.CoreV1().Pods("").Create(&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:   "unassigned-1",
+		Labels: map[string]string{common.LabelKeyWorkflow: "unassigned"},
+	}})
+	assert.NoError(t, err)
+	owned, err = controller.reconcileShard(createWf("unassigned", uidOfShard(1, 3), ""))
+	assert.NoError(t, err)
+	assert.False(t, owned)
+	assert.Equal(t, "1", shardLabel("unassigned"))
+	pod, err := controller.kubeclientset.CoreV1().Pods("").Get("unassigned-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", pod.ObjectMeta.Labels[common.LabelKeyShard])
+
+	// an unassigned workflow of another shard is left to the controller of that shard
+	owned, err = controller.reconcileShard(createWf("other", uidOfShard(2, 3), ""))
+	assert.NoError(t, err)
+	assert.False(t, owned)
+	assert.Equal(t, "", shardLabel("other"))
+
+	// a workflow keeps the shard it was assigned to, even if it belongs to another shard by its UID,
+	// e.g. after the number of shards grew, and even if it has not started yet
+	owned, err = controller.reconcileShard(createWf("kept", uidOfShard(0, 3), "1"))
+	assert.NoError(t, err)
+	assert.True(t, owned)
+	assert.Equal(t, "1", shardLabel("kept"))
+	owned, err = controller.reconcileShard(createWf("kept-other", uidOfShard(1, 3), "2"))
+	assert.NoError(t, err)
+	assert.False(t, owned)
+	assert.Equal(t, "2", shardLabel("kept-other"))
+
+	// a workflow assigned to a shard which no longer exists is reassigned
+	owned, err = controller.reconcileShard(createWf("stale", uidOfShard(1, 3), "4"))
+	assert.NoError(t, err)
+	assert.False(t, owned)
+	assert.Equal(t, "1", shardLabel("stale"))
+	owned, err = controller.reconcileShard(createWf("stale-other", uidOfShard(0, 3), "4"))
+	assert.NoError(t, err)
+	assert.False(t, owned)
+	assert.Equal(t, "4", shardLabel("stale-other"))
+}
+
+// TestReconcileShardCountChange verifies controllers which disagree on the number of shards, as while
+// the number is changed, do not relabel a workflow back and forth
+func TestReconcileShardCountChange(t *testing.T) {
+	controller := newController()
+	wfcs := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+	controllers := []*WorkflowController{controller}
+	for i := 1; i < 5; i++ {
+		c := newController()
+		c.wfclientset = controller.wfclientset
+		c.kubeclientset = controller.kubeclientset
+		controllers = append(controllers, c)
+	}
+	// shards 0 and 1 of 2, and shards 0, 1 and 2 of 3
+	assert.NoError(t, controllers[0].SetShard(0, 2))
+	assert.NoError(t, controllers[1].SetShard(1, 2))
+	assert.NoError(t, controllers[2].SetShard(0, 3))
+	assert.NoError(t, controllers[3].SetShard(1, 3))
+	assert.NoError(t, controllers[4].SetShard(2, 3))
+
+	for i := 0; i < 20; i++ {
+		name := "wf-" + strconv.Itoa(i)
+		_, err := wfcs.Create(&wfv1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(strconv.Itoa(i))}})
+		assert.NoError(t, err)
+		// reconcile until no controller relabels the workflow
+		var history []string
+		for round := 0; round < 4; round++ {
+			for _, c := range controllers {
+				wf, err := wfcs.Get(name, metav1.GetOptions{})
+				assert.NoError(t, err)
+				_, err = c.reconcileShard(wf)
+				assert.NoError(t, err)
+			}
+			wf, err := wfcs.Get(name, metav1.GetOptions{})
+			assert.NoError(t, err)
+			history = append(history, wf.ObjectMeta.Labels[common.LabelKeyShard])
+		}
+		assert.Equal(t, history[1], history[2], name)
+		assert.Equal(t, history[2], history[3], name)
+
+		// the workflow is operated on by exactly one controller of each number of shards
+		wf, err := wfcs.Get(name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		owners := map[int]int{}
+		for _, c := range controllers {
+			owned, err := c.reconcileShard(wf)
+			assert.NoError(t, err)
+			if owned {
+				owners[c.shards]++
+			}
+		}
+		assert.Equal(t, map[int]int{2: 1, 3: 1}, owners, name)
+	}
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultShutdownTimeout is how long the controller waits for in-flight work on shutdown, which is
+// less than the default termination grace period of pods, and less than the default lease duration
+// less the retry period of the leader election
+const defaultShutdownTimeout = 10 * time.Second
+
+// keySet is a set of keys which is safe for concurrent use
+type keySet struct {
+	lock sync.Mutex
+	keys map[string]bool
+}
+
+func (s *keySet) add(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.keys == nil {
+		s.keys = make(map[string]bool)
+	}
+	s.keys[key] = true
+}
+
+func (s *keySet) remove(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.keys, key)
+}
+
+// list returns the keys of the set in order
+func (s *keySet) list() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetShutdownTimeout sets how long the controller waits for in-flight operations to persist, and
+// for completed pods to be labeled and deleted, when it is stopped
+func (wfc *WorkflowController) SetShutdownTimeout(timeout time.Duration) {
+	wfc.shutdownTimeout = timeout
+}
+
+// isShuttingDown returns whether the controller is shutting down, in which case the workers stop
+// taking items off their queues
+func (wfc *WorkflowController) isShuttingDown() bool {
+	return atomic.LoadInt32(&wfc.shuttingDown) == 1
+}
+
+// shutdown stops the workers once their current operation persisted, then flushes the pods waiting
+// to be labeled completed or deleted. Both steps are bounded by the shutdown timeout, and whatever
+// is left undone is logged.
+func (wfc *WorkflowController) shutdown(workers *sync.WaitGroup, stopBackground chan struct{}, background *sync.WaitGroup) {
+	deadline := time.Now().Add(wfc.shutdownTimeout)
+	atomic.StoreInt32(&wfc.shuttingDown, 1)
+	log.Infof("Shutting down, leaving %d queued workflows to the next controller", wfc.wfQueue.Len())
+	wfc.wfQueue.ShutDown()
+	wfc.podQueue.ShutDown()
+
+	if !waitUntil(workers, deadline) {
+		log.Warnf("Abandoned the operations on workflows %v, which did not complete within %v", wfc.inFlight.list(), wfc.shutdownTimeout)
+	} else {
+		log.Info("Workers stopped")
+	}
+
+	close(stopBackground)
+	if !waitUntil(background, deadline) {
+		log.Warnf("Abandoned labeling %d pods completed, which did not complete within %v", len(wfc.completedPods), wfc.shutdownTimeout)
+	}
+	if pods := wfc.gcPodsPending.list(); len(pods) > 0 {
+		log.Warnf("Abandoned the deletion of pods %v", pods)
+	}
+	log.Info("Shutdown complete")
+}
+
+// waitUntil waits for the wait group until the deadline, and returns whether it is done
+func waitUntil(wg *sync.WaitGroup, deadline time.Time) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(time.Until(deadline)):
+		return false
+	}
+}
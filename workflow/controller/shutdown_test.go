@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj/argo/workflow/common"
+)
+
+// TestShutdown verifies the controller stops taking workflows off its queue, and flushes the pods
+// waiting to be labeled or deleted, except for the ones whose deletion is delayed
+func TestShutdown(t *testing.T) {
+	controller := newController()
+	controller.podQueue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	controller.shutdownTimeout = 5 * time.Second
+	for _, name := range []string{"labeled", "deleted", "delayed"} {
+		_, err := controller.kubeclientset.CoreV1().Pods("default").Create(&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		assert.NoError(t, err)
+	}
+	controller.completedPods <- "default/labeled"
+	controller.gcPodsPending.add("default/deleted")
+	controller.gcPods.Add("default/deleted")
+	controller.gcPodsPending.add("default/delayed")
+	controller.gcPods.AddAfter("default/delayed", time.Hour)
+	controller.wfQueue.Add("default/queued")
+
+	stopBackground := make(chan struct{})
+	var background sync.WaitGroup
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		controller.podLabeler(stopBackground)
+	}()
+	go func() {
+		defer background.Done()
+		controller.podGarbageCollector(stopBackground)
+	}()
+	var workers sync.WaitGroup
+	controller.shutdown(&workers, stopBackground, &background)
+
+	assert.False(t, controller.processNextItem())
+	assert.False(t, controller.processNextPodItem())
+
+	pod, err := controller.kubeclientset.CoreV1().Pods("default").Get("labeled", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "true", pod.Labels[common.LabelKeyCompleted])
+	_, err = controller.kubeclientset.CoreV1().Pods("default").Get("deleted", metav1.GetOptions{})
+	assert.Error(t, err)
+	_, err = controller.kubeclientset.CoreV1().Pods("default").Get("delayed", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"default/delayed"}, controller.gcPodsPending.list())
+}
+
+// TestShutdownTimeout verifies the controller gives up on workers which do not stop in time
+func TestShutdownTimeout(t *testing.T) {
+	controller := newController()
+	controller.podQueue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	controller.shutdownTimeout = 100 * time.Millisecond
+	controller.inFlight.add("default/stuck")
+
+	var workers, background sync.WaitGroup
+	workers.Add(1)
+	defer workers.Done()
+	start := time.Now()
+	controller.shutdown(&workers, make(chan struct{}), &background)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.True(t, controller.isShuttingDown())
+}
@@ -168,7 +168,10 @@ func (woc *wfOperationCtx) executeStepGroup(stepGroup []wfv1.WorkflowStep, sgNod
 	}
 
 	// First, resolve any references to outputs from previous steps, and perform substitution
-	stepGroup, err := woc.resolveReferences(stepGroup, stepsCtx.scope)
+	stepGroup, err := woc.resolveReferences(stepGroup, stepsCtx.scope, stepsCtx.boundaryID)
+	if err == errWithParamLoading {
+		return node
+	}
 	if err != nil {
 		return woc.markNodeError(sgNodeName, err)
 	}
@@ -186,6 +189,20 @@ func (woc *wfOperationCtx) executeStepGroup(stepGroup []wfv1.WorkflowStep, sgNod
 	for _, step := range stepGroup {
 		childNodeName := fmt.Sprintf("%s.%s", sgNodeName, step.Name)
 
+		if step.MapStrategy != nil {
+			// The when clause of a map step is evaluated for each of its items
+			childNode, err := woc.executeMapStep(childNodeName, step, stepsCtx)
+			if err != nil {
+				if err == ErrDeadlineExceeded {
+					return node
+				}
+				return woc.markNodeError(sgNodeName, err)
+			}
+			nodeSteps[childNodeName] = step
+			woc.addChildNode(sgNodeName, childNode.Name)
+			continue
+		}
+
 		// Check the step's when clause to decide if it should execute
 		proceed, err := shouldExecute(step.When)
 		if err != nil {
@@ -286,7 +303,8 @@ func shouldExecute(when string) (bool, error) {
 // 2) dereferencing output.result from previous steps
 // 2) dereferencing artifacts from previous steps
 // 3) dereferencing artifacts from inputs
-func (woc *wfOperationCtx) resolveReferences(stepGroup []wfv1.WorkflowStep, scope *wfScope) ([]wfv1.WorkflowStep, error) {
+// 4) loading withParam from a referenced artifact
+func (woc *wfOperationCtx) resolveReferences(stepGroup []wfv1.WorkflowStep, scope *wfScope, boundaryID string) ([]wfv1.WorkflowStep, error) {
 	newStepGroup := make([]wfv1.WorkflowStep, len(stepGroup))
 
 	// Step 0: replace all parameter scope references for volumes
@@ -326,16 +344,23 @@ func (woc *wfOperationCtx) resolveReferences(stepGroup []wfv1.WorkflowStep, scop
 			newStep.Arguments.Artifacts[j] = *resolvedArt
 		}
 
+		// Step 3: load withParam from an artifact reference
+		newStep.WithParam, err = woc.resolveWithParam(newStep.WithParam, scope)
+		if err != nil {
+			return nil, err
+		}
+
 		newStepGroup[i] = newStep
 	}
 	return newStepGroup, nil
 }
 
-// expandStepGroup looks at each step in a collection of parallel steps, and expands all steps using withItems/withParam
+// expandStepGroup looks at each step in a collection of parallel steps, and expands all steps using withItems/withParam.
+// Steps with a map strategy are not expanded, since their items are started as the map progresses.
 func (woc *wfOperationCtx) expandStepGroup(stepGroup []wfv1.WorkflowStep) ([]wfv1.WorkflowStep, error) {
 	newStepGroup := make([]wfv1.WorkflowStep, 0)
 	for _, step := range stepGroup {
-		if len(step.WithItems) == 0 && step.WithParam == "" && step.WithSequence == nil {
+		if step.MapStrategy != nil || len(step.WithItems) == 0 && step.WithParam == "" && step.WithSequence == nil {
 			newStepGroup = append(newStepGroup, step)
 			continue
 		}
@@ -356,20 +381,11 @@ func (woc *wfOperationCtx) expandStep(step wfv1.WorkflowStep) ([]wfv1.WorkflowSt
 	}
 	fstTmpl := fasttemplate.New(string(stepBytes), "{{", "}}")
 	expandedStep := make([]wfv1.WorkflowStep, 0)
-	var items []wfv1.Item
-	if len(step.WithItems) > 0 {
-		items = step.WithItems
-	} else if step.WithParam != "" {
-		err = json.Unmarshal([]byte(step.WithParam), &items)
-		if err != nil {
-			return nil, errors.Errorf(errors.CodeBadRequest, "withParam value could not be parsed as a JSON list: %s", strings.TrimSpace(step.WithParam))
-		}
-	} else if step.WithSequence != nil {
-		items, err = expandSequence(step.WithSequence)
-		if err != nil {
-			return nil, err
-		}
-	} else {
+	items, err := resolveItems(step.WithItems, step.WithParam, step.WithSequence)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
 		// this should have been prevented in expandStepGroup()
 		return nil, errors.InternalError("expandStep() was called with withItems and withParam empty")
 	}
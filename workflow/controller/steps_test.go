@@ -1,12 +1,16 @@
 package controller
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 	"github.com/argoproj/argo/test"
+	"github.com/argoproj/argo/workflow/common"
 )
 
 // TestStepsFailedRetries ensures a steps template will recognize exhausted retries
@@ -16,3 +20,161 @@ func TestStepsFailedRetries(t *testing.T) {
 	woc.operate()
 	assert.Equal(t, string(wfv1.NodeFailed), string(woc.wf.Status.Phase))
 }
+
+var mapSteps = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: map-steps
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    steps:
+    - - name: process
+        template: echo
+        arguments:
+          parameters:
+          - name: message
+            value: "{{item}}"
+        withItems: [a, b, c, d, e]
+        when: "{{item}} != c"
+        mapStrategy:
+          parallelism: 2
+  - name: echo
+    inputs:
+      parameters:
+      - name: message
+    container:
+      image: alpine:latest
+      command: [echo, "{{inputs.parameters.message}}"]
+`
+
+// TestMapStep verifies the items of a map step are started as running items complete, and only
+// their counters are kept in the status once they succeed
+func TestMapStep(t *testing.T) {
+	controller := newController()
+	wfcset := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+	wf, err := wfcset.Create(unmarshalWF(mapSteps))
+	assert.NoError(t, err)
+
+	woc := newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+	mapNode := woc.getNodeByName("map-steps[0].process")
+	if assert.NotNil(t, mapNode) {
+		assert.Equal(t, wfv1.NodeTypeMap, mapNode.Type)
+		assert.Equal(t, wfv1.MapStatus{Total: 5, Started: 2}, *mapNode.MapStatus)
+		assert.Len(t, mapNode.Children, 2)
+	}
+
+	// item c is skipped, so the next operation starts items d and e
+	makePodsPhase(t, controller.kubeclientset, wf.ObjectMeta.Namespace, apiv1.PodSucceeded)
+	woc = newWorkflowOperationCtx(woc.wf, controller)
+	woc.operate()
+	mapNode = woc.getNodeByName("map-steps[0].process")
+	assert.Equal(t, wfv1.MapStatus{Total: 5, Started: 5, Succeeded: 2, Skipped: 1}, *mapNode.MapStatus)
+	assert.Nil(t, woc.getNodeByName("map-steps[0].process(0:a)"))
+	assert.NotNil(t, woc.getNodeByName("map-steps[0].process(3:d)"))
+
+	makePodsPhase(t, controller.kubeclientset, wf.ObjectMeta.Namespace, apiv1.PodSucceeded)
+	woc = newWorkflowOperationCtx(woc.wf, controller)
+	woc.operate()
+	mapNode = woc.getNodeByName("map-steps[0].process")
+	assert.Equal(t, wfv1.MapStatus{Total: 5, Started: 5, Succeeded: 4, Skipped: 1}, *mapNode.MapStatus)
+	assert.Empty(t, mapNode.Children)
+	assert.Equal(t, wfv1.NodeSucceeded, woc.wf.Status.Phase)
+}
+
+// TestMapStepFailed verifies failed items are kept in the status, and fail the map
+func TestMapStepFailed(t *testing.T) {
+	controller := newController()
+	wfcset := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+	wf := unmarshalWF(mapSteps)
+	wf.Spec.Templates[0].Steps[0][0].WithItems = []wfv1.Item{{Value: "a"}}
+	wf, err := wfcset.Create(wf)
+	assert.NoError(t, err)
+
+	woc := newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+	podcs := controller.kubeclientset.CoreV1().Pods(wf.ObjectMeta.Namespace)
+	pods, err := podcs.List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	for _, pod := range pods.Items {
+		pod.Status.Phase = apiv1.PodFailed
+		pod.Status.ContainerStatuses = []apiv1.ContainerStatus{{
+			Name:  common.MainContainerName,
+			State: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{ExitCode: 1}},
+		}}
+		_, err = podcs.Update(&pod)
+		assert.NoError(t, err)
+	}
+	woc = newWorkflowOperationCtx(woc.wf, controller)
+	woc.operate()
+	mapNode := woc.getNodeByName("map-steps[0].process")
+	assert.Equal(t, wfv1.NodeFailed, mapNode.Phase)
+	assert.Equal(t, wfv1.MapStatus{Total: 1, Started: 1, Failed: 1}, *mapNode.MapStatus)
+	failedNode := woc.getNodeByName("map-steps[0].process(0:a)")
+	if assert.NotNil(t, failedNode) {
+		assert.Equal(t, []string{failedNode.ID}, mapNode.Children)
+	}
+	assert.Equal(t, wfv1.NodeFailed, woc.wf.Status.Phase)
+}
+
+var whenOutputWithBraces = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: when-braces
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    steps:
+    - - name: gen
+        template: gen
+    - - name: print
+        template: echo
+        when: "'{{steps.gen.outputs.result}}' != ''"
+  - name: gen
+    script:
+      image: alpine:latest
+      command: [sh]
+      source: echo hello
+  - name: echo
+    container:
+      image: alpine:latest
+      command: [echo, hello]
+`
+
+// TestWhenOutputWithBraces verifies a when expression is evaluated after the substitution of the
+// outputs, even when they contain braces
+func TestWhenOutputWithBraces(t *testing.T) {
+	controller := newController()
+	wfcset := controller.wfclientset.ArgoprojV1alpha1().Workflows("")
+	wf, err := wfcset.Create(unmarshalWF(whenOutputWithBraces))
+	assert.NoError(t, err)
+
+	woc := newWorkflowOperationCtx(wf, controller)
+	woc.operate()
+	result := "{a: {{b}}}"
+	outputsBytes, err := json.Marshal(wfv1.Outputs{Result: &result})
+	assert.NoError(t, err)
+	podcs := controller.kubeclientset.CoreV1().Pods(wf.ObjectMeta.Namespace)
+	pods, err := podcs.List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, pods.Items, 1) {
+		pod := pods.Items[0]
+		pod.Status.Phase = apiv1.PodSucceeded
+		pod.Annotations[common.AnnotationKeyOutputs] = string(outputsBytes)
+		_, err = podcs.Update(&pod)
+		assert.NoError(t, err)
+	}
+
+	woc = newWorkflowOperationCtx(woc.wf, controller)
+	woc.operate()
+	node := woc.getNodeByName("when-braces[1].print")
+	if assert.NotNil(t, node) {
+		assert.Equal(t, wfv1.NodePending, node.Phase)
+	}
+	assert.Equal(t, wfv1.NodeRunning, woc.wf.Status.Phase)
+}
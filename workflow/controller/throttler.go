@@ -5,18 +5,23 @@ import (
 	"sync"
 	"time"
 
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 )
 
 // Throttler allows CRD controller to limit number of items it is processing in parallel.
 type Throttler interface {
 	Add(key interface{}, priority int32, creationTime time.Time)
-	// Next returns true if item should be processed by controller now or return false.
+	// Next returns true if item should be processed by controller now or return false. The item which is
+	// processed may be another, pending item of higher priority, which is returned instead.
 	Next(key interface{}) (interface{}, bool)
 	// Remove notifies throttler that item processing is done. In responses the throttler triggers processing of previously throttled items.
 	Remove(key interface{})
 	// SetParallelism update throttler parallelism limit.
 	SetParallelism(parallelism int)
+	// SetNamespaceParallelism updates the parallelism limit of the items of a namespace, in addition
+	// to the overall limit. A parallelism less than 1 removes the limit of the namespace.
+	SetNamespaceParallelism(namespace string, parallelism int)
 }
 
 type throttler struct {
@@ -25,15 +30,21 @@ type throttler struct {
 	pending     *priorityQueue
 	lock        *sync.Mutex
 	parallelism int
+	// namespaceParallelism is the parallelism limit of the namespaces which have one
+	namespaceParallelism map[string]int
+	// namespaceInProgress is the number of items in progress per namespace
+	namespaceInProgress map[string]int
 }
 
 func NewThrottler(parallelism int, queue workqueue.RateLimitingInterface) Throttler {
 	return &throttler{
-		queue:       queue,
-		inProgress:  make(map[interface{}]bool),
-		lock:        &sync.Mutex{},
-		parallelism: parallelism,
-		pending:     &priorityQueue{itemByKey: make(map[interface{}]*item)},
+		queue:                queue,
+		inProgress:           make(map[interface{}]bool),
+		lock:                 &sync.Mutex{},
+		parallelism:          parallelism,
+		pending:              &priorityQueue{itemByKey: make(map[interface{}]*item)},
+		namespaceParallelism: make(map[string]int),
+		namespaceInProgress:  make(map[string]int),
 	}
 }
 
@@ -46,6 +57,17 @@ func (t *throttler) SetParallelism(parallelism int) {
 	}
 }
 
+func (t *throttler) SetNamespaceParallelism(namespace string, parallelism int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if parallelism < 1 {
+		delete(t.namespaceParallelism, namespace)
+	} else {
+		t.namespaceParallelism[namespace] = parallelism
+	}
+	t.queueThrottled()
+}
+
 func (t *throttler) Add(key interface{}, priority int32, creationTime time.Time) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -59,10 +81,16 @@ func (t *throttler) Next(key interface{}) (interface{}, bool) {
 	if _, isInProgress := t.inProgress[key]; isInProgress || t.pending.Len() == 0 {
 		return key, true
 	}
+	// an item whose namespace is at its limit waits, rather than starting an item of another namespace
+	namespace := keyNamespace(key)
+	if limit, ok := t.namespaceParallelism[namespace]; ok && t.namespaceInProgress[namespace] >= limit {
+		return key, false
+	}
 	if t.parallelism < 1 || t.parallelism > len(t.inProgress) {
-		next := t.pending.pop()
-		t.inProgress[next.key] = true
-		return next.key, true
+		if next := t.popNext(); next != nil {
+			t.start(next.key)
+			return next.key, true
+		}
 	}
 	return key, false
 
@@ -71,7 +99,14 @@ func (t *throttler) Next(key interface{}) (interface{}, bool) {
 func (t *throttler) Remove(key interface{}) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
-	delete(t.inProgress, key)
+	if t.inProgress[key] {
+		delete(t.inProgress, key)
+		namespace := keyNamespace(key)
+		t.namespaceInProgress[namespace]--
+		if t.namespaceInProgress[namespace] <= 0 {
+			delete(t.namespaceInProgress, namespace)
+		}
+	}
 	t.pending.remove(key)
 
 	t.queueThrottled()
@@ -79,12 +114,50 @@ func (t *throttler) Remove(key interface{}) {
 
 func (t *throttler) queueThrottled() {
 	for t.pending.Len() > 0 && (t.parallelism < 1 || t.parallelism > len(t.inProgress)) {
-		next := t.pending.pop()
-		t.inProgress[next.key] = true
+		next := t.popNext()
+		if next == nil {
+			return
+		}
+		t.start(next.key)
 		t.queue.Add(next.key)
 	}
 }
 
+// start marks an item as in progress
+func (t *throttler) start(key interface{}) {
+	t.inProgress[key] = true
+	t.namespaceInProgress[keyNamespace(key)]++
+}
+
+// popNext removes and returns the pending item with the highest priority whose namespace is below
+// its parallelism limit, or nil if there is no such item
+func (t *throttler) popNext() *item {
+	if len(t.namespaceParallelism) == 0 {
+		return t.pending.pop()
+	}
+	var next *item
+	for _, candidate := range t.pending.items {
+		namespace := keyNamespace(candidate.key)
+		if limit, ok := t.namespaceParallelism[namespace]; ok && t.namespaceInProgress[namespace] >= limit {
+			continue
+		}
+		if next == nil || t.pending.Less(candidate.index, next.index) {
+			next = candidate
+		}
+	}
+	if next != nil {
+		t.pending.remove(next.key)
+	}
+	return next
+}
+
+// keyNamespace returns the namespace of a namespace/name key
+func keyNamespace(key interface{}) string {
+	k, _ := key.(string)
+	namespace, _, _ := cache.SplitMetaNamespaceKey(k)
+	return namespace
+}
+
 type item struct {
 	key          interface{}
 	creationTime time.Time
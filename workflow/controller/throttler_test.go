@@ -84,3 +84,53 @@ func TestChangeParallelism(t *testing.T) {
 	queued, _ = queue.Get()
 	assert.Equal(t, "b", queued)
 }
+
+func TestNamespaceParallelism(t *testing.T) {
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	throttler := NewThrottler(0, queue)
+	throttler.SetNamespaceParallelism("ns1", 1)
+
+	throttler.Add("ns1/a", 3, time.Now())
+	throttler.Add("ns1/b", 2, time.Now())
+	throttler.Add("ns2/c", 1, time.Now())
+
+	next, ok := throttler.Next("ns1/a")
+	assert.True(t, ok)
+	assert.Equal(t, "ns1/a", next)
+
+	// ns1 is at its limit, so its item waits
+	next, ok = throttler.Next("ns1/b")
+	assert.False(t, ok)
+	assert.Equal(t, "ns1/b", next)
+
+	// the item of ns2 is processed despite its lower priority
+	next, ok = throttler.Next("ns2/c")
+	assert.True(t, ok)
+	assert.Equal(t, "ns2/c", next)
+
+	throttler.Remove("ns1/a")
+	assert.Equal(t, 1, queue.Len())
+	queued, _ := queue.Get()
+	assert.Equal(t, "ns1/b", queued)
+}
+
+func TestChangeNamespaceParallelism(t *testing.T) {
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	throttler := NewThrottler(0, queue)
+	throttler.SetNamespaceParallelism("ns1", 1)
+
+	throttler.Add("ns1/a", 2, time.Now())
+	throttler.Add("ns1/b", 1, time.Now())
+
+	next, ok := throttler.Next("ns1/a")
+	assert.True(t, ok)
+	assert.Equal(t, "ns1/a", next)
+
+	_, ok = throttler.Next("ns1/b")
+	assert.False(t, ok)
+
+	throttler.SetNamespaceParallelism("ns1", 0)
+	assert.Equal(t, 1, queue.Len())
+	queued, _ := queue.Get()
+	assert.Equal(t, "ns1/b", queued)
+}
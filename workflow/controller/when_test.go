@@ -22,6 +22,7 @@ func TestShouldExecute(t *testing.T) {
 		"Error in (Failed, Error)",
 		"!(Succeeded in (Failed, Error))",
 		"true == true",
+		"'{a: {{b}}}' == '{a: {{b}}}'",
 	}
 	for _, trueExp := range trueExpressions {
 		res, err := shouldExecute(trueExp)
@@ -12,6 +12,7 @@ import (
 	"github.com/argoproj/argo/pkg/apis/workflow"
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 	"github.com/argoproj/argo/workflow/common"
+	"github.com/argoproj/argo/workflow/metrics"
 	log "github.com/sirupsen/logrus"
 	"github.com/valyala/fasttemplate"
 	apiv1 "k8s.io/api/core/v1"
@@ -124,6 +125,9 @@ func (woc *wfOperationCtx) createWorkflowPod(nodeName string, mainCtr apiv1.Cont
 	if woc.controller.Config.InstanceID != "" {
 		pod.ObjectMeta.Labels[common.LabelKeyControllerInstanceID] = woc.controller.Config.InstanceID
 	}
+	if woc.controller.sharded() {
+		pod.ObjectMeta.Labels[common.LabelKeyShard] = strconv.Itoa(woc.controller.shard)
+	}
 	if woc.controller.Config.ContainerRuntimeExecutor == common.ContainerRuntimeExecutorPNS {
 		pod.Spec.ShareProcessNamespace = pointer.BoolPtr(true)
 	}
@@ -138,8 +142,8 @@ func (woc *wfOperationCtx) createWorkflowPod(nodeName string, mainCtr apiv1.Cont
 		return nil, err
 	}
 
-	if tmpl.GetType() != wfv1.TemplateTypeResource {
-		// we do not need the wait container for resource templates because
+	if tmpl.GetType() != wfv1.TemplateTypeResource && tmpl.GetType() != wfv1.TemplateTypeData {
+		// we do not need the wait container for resource and data templates because
 		// argoexec runs as the main container and will perform the job of
 		// annotating the outputs or errors, making the wait container redundant.
 		waitCtr, err := woc.newWaitContainer(tmpl)
@@ -221,6 +225,13 @@ func (woc *wfOperationCtx) createWorkflowPod(nodeName string, mainCtr apiv1.Cont
 		}
 	}
 
+	// a pod which was already created does not take a pod creation from the rate limit
+	if existing := woc.getCachedPod(pod.ObjectMeta.Name); existing != nil {
+		return existing, nil
+	}
+	if !woc.acceptPodCreation(nodeName) {
+		return nil, nil
+	}
 	created, err := woc.controller.kubeclientset.CoreV1().Pods(woc.wf.ObjectMeta.Namespace).Create(pod)
 	if err != nil {
 		if apierr.IsAlreadyExists(err) {
@@ -233,10 +244,34 @@ func (woc *wfOperationCtx) createWorkflowPod(nodeName string, mainCtr apiv1.Cont
 		return nil, errors.InternalWrapError(err)
 	}
 	woc.log.Infof("Created pod: %s (%s)", nodeName, created.Name)
+	metrics.PodCreated()
 	woc.activePods++
 	return created, nil
 }
 
+// acceptPodCreation returns whether a pod may be created under the pod creation rate limit. Otherwise
+// this operation creates no more pods, the node is left pending to be retried, and the workflow is
+// requeued once the rate limit allows to create a pod.
+func (woc *wfOperationCtx) acceptPodCreation(nodeName string) bool {
+	if !woc.podCreationThrottled {
+		delay := woc.controller.podCreationLimiter.reserve()
+		if delay <= 0 {
+			return true
+		}
+		woc.log.Infof("Pod creation is rate limited, retrying in %v", delay)
+		woc.podCreationThrottled = true
+		woc.controller.wfQueue.AddAfter(woc.key(), delay)
+	}
+	node := woc.getNodeByName(nodeName)
+	if node != nil && !node.StartedAt.IsZero() {
+		// a node which is not started is not deemed to have lost its pod
+		node.StartedAt = metav1.Time{}
+		woc.wf.Status.Nodes[node.ID] = *node
+		woc.updated = true
+	}
+	return false
+}
+
 // substitutePodParams returns a pod spec with parameter references substituted as well as pod.name
 func substitutePodParams(pod *apiv1.Pod, globalParams map[string]string, tmpl *wfv1.Template) (*apiv1.Pod, error) {
 	podParams := make(map[string]string)
@@ -409,10 +444,12 @@ func (woc *wfOperationCtx) newExecContainer(name string, tmpl *wfv1.Template) *a
 	if woc.controller.Config.Executor != nil {
 		exec.Args = woc.controller.Config.Executor.Args
 	}
-	if isResourcesSpecified(woc.controller.Config.Executor) {
-		exec.Resources = woc.controller.Config.Executor.Resources
-	} else if woc.controller.Config.ExecutorResources != nil {
-		exec.Resources = *woc.controller.Config.ExecutorResources
+	// the resources of the executor may be overridden by the namespace of the workflow
+	nsConfig := woc.controller.configForNamespace(woc.wf.Namespace)
+	if isResourcesSpecified(nsConfig.Executor) {
+		exec.Resources = nsConfig.Executor.Resources
+	} else if nsConfig.ExecutorResources != nil {
+		exec.Resources = *nsConfig.ExecutorResources
 	}
 	if woc.controller.Config.KubeConfig != nil {
 		path := woc.controller.Config.KubeConfig.MountPath
@@ -540,7 +577,7 @@ func addSchedulingConstraints(pod *apiv1.Pod, wfSpec *wfv1.WorkflowSpec, tmpl *w
 // These are either specified in the workflow.spec.volumes or the workflow.spec.volumeClaimTemplate section
 func addVolumeReferences(pod *apiv1.Pod, vols []apiv1.Volume, tmpl *wfv1.Template, pvcs []apiv1.Volume) error {
 	switch tmpl.GetType() {
-	case wfv1.TemplateTypeContainer, wfv1.TemplateTypeScript:
+	case wfv1.TemplateTypeContainer, wfv1.TemplateTypeScript, wfv1.TemplateTypeData:
 	default:
 		return nil
 	}
@@ -621,8 +658,13 @@ func addVolumeReferences(pod *apiv1.Pod, vols []apiv1.Volume, tmpl *wfv1.Templat
 	volumes, volumeMounts := createSecretVolumes(tmpl)
 	pod.Spec.Volumes = append(pod.Spec.Volumes, volumes...)
 
+	// argoexec runs as the main container of data templates, so it is the one that needs the secrets
+	secretCtrName := common.WaitContainerName
+	if tmpl.GetType() == wfv1.TemplateTypeData {
+		secretCtrName = common.MainContainerName
+	}
 	for idx, container := range pod.Spec.Containers {
-		if container.Name == common.WaitContainerName {
+		if container.Name == secretCtrName {
 			pod.Spec.Containers[idx].VolumeMounts = append(pod.Spec.Containers[idx].VolumeMounts, volumeMounts...)
 			break
 		}
@@ -731,7 +773,7 @@ func (woc *wfOperationCtx) addInputArtifactsVolumes(pod *apiv1.Pod, tmpl *wfv1.T
 // them to the wait sidecar. In order for this to work, we mirror all volume mounts in the main
 // container under a well-known path.
 func addOutputArtifactsVolumes(pod *apiv1.Pod, tmpl *wfv1.Template) {
-	if tmpl.GetType() == wfv1.TemplateTypeResource {
+	if tmpl.GetType() == wfv1.TemplateTypeResource || tmpl.GetType() == wfv1.TemplateTypeData {
 		return
 	}
 	mainCtrIndex := -1
@@ -983,6 +1025,9 @@ func createSecretVolumes(tmpl *wfv1.Template) ([]apiv1.Volume, []apiv1.VolumeMou
 	for _, art := range tmpl.Inputs.Artifacts {
 		createSecretVolume(allVolumesMap, art, uniqueKeyMap)
 	}
+	if tmpl.Data != nil && tmpl.Data.Source.ArtifactPaths != nil {
+		createSecretVolume(allVolumesMap, *tmpl.Data.Source.ArtifactPaths, uniqueKeyMap)
+	}
 
 	for volMountName, val := range allVolumesMap {
 		secretVolumes = append(secretVolumes, val)
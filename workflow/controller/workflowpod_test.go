@@ -821,3 +821,46 @@ func TestTmplLevelSecurityContext(t *testing.T) {
 	assert.NotNil(t, pod.Spec.SecurityContext)
 	assert.Equal(t, runAsUser, *pod.Spec.SecurityContext.RunAsUser)
 }
+
+var dataTemplate = `
+name: list-csv
+data:
+  source:
+    artifactPaths:
+      name: files
+      s3:
+        endpoint: s3.amazonaws.com
+        bucket: my-bucket
+        key: data/
+        accessKeySecret:
+          name: my-s3-credentials
+          key: accessKey
+        secretKeySecret:
+          name: my-s3-credentials
+          key: secretKey
+  transformation:
+  - filter: "'{{item}}' =~ '.csv$'"
+`
+
+// TestDataTemplate verifies data templates run argoexec as the main container, with the source's secrets mounted
+func TestDataTemplate(t *testing.T) {
+	tmpl := unmarshalTemplate(dataTemplate)
+	woc := newWoc()
+	err := woc.executeData(tmpl.Name, tmpl, "")
+	assert.NoError(t, err)
+	pods, err := woc.controller.kubeclientset.CoreV1().Pods("").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, pods.Items, 1)
+	pod := pods.Items[0]
+	assert.Len(t, pod.Spec.Containers, 1)
+	mainCtr := pod.Spec.Containers[0]
+	assert.Equal(t, common.MainContainerName, mainCtr.Name)
+	assert.Equal(t, []string{"argoexec", "data"}, mainCtr.Command)
+	found := false
+	for _, mnt := range mainCtr.VolumeMounts {
+		if mnt.MountPath == common.SecretVolMountPath+"/my-s3-credentials" {
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"encoding/json"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/valyala/fasttemplate"
+
+	"github.com/argoproj/argo/errors"
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/workflow/common"
+)
+
+// Data reads the items of the data template source, applies the transformation and annotates the
+// resulting JSON list as the template's outputs.result
+func (we *WorkflowExecutor) Data() error {
+	dataTmpl := we.Template.Data
+	if dataTmpl.Source.ArtifactPaths == nil {
+		return errors.Errorf(errors.CodeBadRequest, "data source unspecified")
+	}
+	art := dataTmpl.Source.ArtifactPaths
+	driver, err := we.InitDriver(*art)
+	if err != nil {
+		return err
+	}
+	items, err := driver.ListObjects(art)
+	if err != nil {
+		return err
+	}
+	log.Infof("Listed %d objects", len(items))
+	items, err = applyTransformation(items, dataTmpl.Transformation)
+	if err != nil {
+		return err
+	}
+	if items == nil {
+		items = []string{}
+	}
+	resultBytes, err := json.Marshal(items)
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
+	result := string(resultBytes)
+	we.Template.Outputs.Result = &result
+	return we.AnnotateOutputs(nil)
+}
+
+// applyTransformation applies the filter and map steps of a transformation, in order, to the items
+func applyTransformation(items []string, transformation []wfv1.TransformationStep) ([]string, error) {
+	for i, step := range transformation {
+		var newItems []string
+		for _, item := range items {
+			switch {
+			case step.Filter != "":
+				keep, err := evaluateFilter(step.Filter, item)
+				if err != nil {
+					return nil, errors.Errorf(errors.CodeBadRequest, "transformation[%d].filter: %v", i, err)
+				}
+				if keep {
+					newItems = append(newItems, item)
+				}
+			case step.Map != "":
+				newItems = append(newItems, substituteItem(step.Map, item))
+			default:
+				return nil, errors.Errorf(errors.CodeBadRequest, "transformation[%d] must specify one of filter or map", i)
+			}
+		}
+		items = newItems
+	}
+	return items, nil
+}
+
+// substituteItem replaces {{item}} in the given string with the item
+func substituteItem(str string, item string) string {
+	fstTmpl := fasttemplate.New(str, "{{", "}}")
+	return fstTmpl.ExecuteFuncString(func(w io.Writer, tag string) (int, error) {
+		if tag == "item" {
+			return w.Write([]byte(item))
+		}
+		return w.Write([]byte("{{" + tag + "}}"))
+	})
+}
+
+// evaluateFilter evaluates the filter expression with the item as {{item}}
+func evaluateFilter(filter string, item string) (bool, error) {
+	return common.EvaluateBoolExpression(filter, map[string]string{"item": item})
+}
@@ -0,0 +1,38 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+)
+
+func TestApplyTransformation(t *testing.T) {
+	items := []string{"data/a.csv", "data/b.json", "data/c.csv"}
+	transformation := []wfv1.TransformationStep{
+		{Filter: "'{{item}}' =~ '.csv$'"},
+		{Map: "s3://my-bucket/{{item}}"},
+	}
+	result, err := applyTransformation(items, transformation)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"s3://my-bucket/data/a.csv", "s3://my-bucket/data/c.csv"}, result)
+
+	result, err = applyTransformation(items, []wfv1.TransformationStep{{Filter: "'{{item}}' == 'none'"}})
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+
+	// items are passed to the filter as values, so quotes in them cannot change the expression
+	result, err = applyTransformation([]string{"it's.csv", "x' || 'a' == 'a"}, []wfv1.TransformationStep{{Filter: "'{{item}}' =~ '.csv$'"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"it's.csv"}, result)
+
+	_, err = applyTransformation(items, []wfv1.TransformationStep{{Filter: "'{{item}}' +"}})
+	assert.Error(t, err)
+
+	_, err = applyTransformation(items, []wfv1.TransformationStep{{Filter: "'{{item}}'"}})
+	assert.Error(t, err)
+
+	_, err = applyTransformation(items, []wfv1.TransformationStep{{}})
+	assert.Error(t, err)
+}
@@ -10,7 +10,6 @@ import (
 	"io/ioutil"
 	"net/url"
 	"os"
-	"os/exec"
 	"os/signal"
 	"path"
 	"path/filepath"
@@ -21,10 +20,12 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/argoproj/argo/errors"
@@ -33,12 +34,6 @@ import (
 	"github.com/argoproj/argo/util/archive"
 	"github.com/argoproj/argo/util/retry"
 	artifact "github.com/argoproj/argo/workflow/artifacts"
-	"github.com/argoproj/argo/workflow/artifacts/artifactory"
-	"github.com/argoproj/argo/workflow/artifacts/git"
-	"github.com/argoproj/argo/workflow/artifacts/hdfs"
-	"github.com/argoproj/argo/workflow/artifacts/http"
-	"github.com/argoproj/argo/workflow/artifacts/raw"
-	"github.com/argoproj/argo/workflow/artifacts/s3"
 	"github.com/argoproj/argo/workflow/common"
 	argofile "github.com/argoproj/pkg/file"
 )
@@ -55,6 +50,8 @@ type WorkflowExecutor struct {
 	PodName            string
 	Template           wfv1.Template
 	ClientSet          kubernetes.Interface
+	DynamicClient      dynamic.Interface
+	RESTMapper         meta.RESTMapper
 	Namespace          string
 	PodAnnotationsPath string
 	ExecutionControl   *common.ExecutionControl
@@ -95,10 +92,11 @@ type ContainerRuntimeExecutor interface {
 }
 
 // NewExecutor instantiates a new workflow executor
-func NewExecutor(clientset kubernetes.Interface, podName, namespace, podAnnotationsPath string, cre ContainerRuntimeExecutor, template wfv1.Template) WorkflowExecutor {
+func NewExecutor(clientset kubernetes.Interface, dynamicClient dynamic.Interface, podName, namespace, podAnnotationsPath string, cre ContainerRuntimeExecutor, template wfv1.Template) WorkflowExecutor {
 	return WorkflowExecutor{
 		PodName:            podName,
 		ClientSet:          clientset,
+		DynamicClient:      dynamicClient,
 		Namespace:          namespace,
 		PodAnnotationsPath: podAnnotationsPath,
 		RuntimeExecutor:    cre,
@@ -169,8 +167,8 @@ func (we *WorkflowExecutor) LoadArtifacts() error {
 		if err != nil {
 			return err
 		}
-		if isTarball(tempArtPath) {
-			err = untar(tempArtPath, artPath)
+		if archive.IsTarGz(tempArtPath) {
+			err = archive.UntarGz(tempArtPath, artPath)
 			_ = os.Remove(tempArtPath)
 		} else {
 			err = os.Rename(tempArtPath, artPath)
@@ -355,7 +353,7 @@ func (we *WorkflowExecutor) stageArchiveFile(mainCtrID string, art *wfv1.Artifac
 	// localArtPath now points to a .tgz file, and the archive strategy is *not* tar. We need to untar it
 	log.Infof("Untaring %s archive before upload", localArtPath)
 	unarchivedArtPath := path.Join(filepath.Dir(localArtPath), art.Name)
-	err = untar(localArtPath, unarchivedArtPath)
+	err = archive.UntarGz(localArtPath, unarchivedArtPath)
 	if err != nil {
 		return "", "", err
 	}
@@ -428,17 +426,19 @@ func (we *WorkflowExecutor) SaveParameters() error {
 		if we.isBaseImagePath(param.ValueFrom.Path) {
 			log.Infof("Copying %s from base image layer", param.ValueFrom.Path)
 			output, err = we.RuntimeExecutor.GetFileContents(mainCtrID, param.ValueFrom.Path)
-			if err != nil {
-				return err
-			}
 		} else {
 			log.Infof("Copying %s from from volume mount", param.ValueFrom.Path)
 			mountedPath := filepath.Join(common.ExecutorMainFilesystemDir, param.ValueFrom.Path)
-			out, err := ioutil.ReadFile(mountedPath)
-			if err != nil {
+			var out []byte
+			out, err = ioutil.ReadFile(mountedPath)
+			output = string(out)
+		}
+		if err != nil {
+			if param.ValueFrom.Default == nil {
 				return err
 			}
-			output = string(out)
+			log.Warnf("Failed to read output parameter %s, using the default: %v", param.Name, err)
+			output = *param.ValueFrom.Default
 		}
 
 		outputLen := len(output)
@@ -474,7 +474,7 @@ func (we *WorkflowExecutor) SaveLogs() (*wfv1.Artifact, error) {
 		return nil, err
 	}
 	art := wfv1.Artifact{
-		Name:             "main-logs",
+		Name:             common.MainLogsArtifactName,
 		ArtifactLocation: *we.Template.ArchiveLocation,
 	}
 	if we.Template.ArchiveLocation.S3 != nil {
@@ -535,88 +535,7 @@ func (we *WorkflowExecutor) saveLogToFile(mainCtrID, path string) error {
 
 // InitDriver initializes an instance of an artifact driver
 func (we *WorkflowExecutor) InitDriver(art wfv1.Artifact) (artifact.ArtifactDriver, error) {
-	if art.S3 != nil {
-		var accessKey string
-		var secretKey string
-
-		if art.S3.AccessKeySecret.Name != "" {
-			accessKeyBytes, err := we.GetSecretFromVolMount(art.S3.AccessKeySecret.Name, art.S3.AccessKeySecret.Key)
-			if err != nil {
-				return nil, err
-			}
-			accessKey = string(accessKeyBytes)
-			secretKeyBytes, err := we.GetSecretFromVolMount(art.S3.SecretKeySecret.Name, art.S3.SecretKeySecret.Key)
-			if err != nil {
-				return nil, err
-			}
-			secretKey = string(secretKeyBytes)
-		}
-
-		driver := s3.S3ArtifactDriver{
-			Endpoint:  art.S3.Endpoint,
-			AccessKey: accessKey,
-			SecretKey: secretKey,
-			Secure:    art.S3.Insecure == nil || !*art.S3.Insecure,
-			Region:    art.S3.Region,
-			RoleARN:   art.S3.RoleARN,
-		}
-		return &driver, nil
-	}
-	if art.HTTP != nil {
-		return &http.HTTPArtifactDriver{}, nil
-	}
-	if art.Git != nil {
-		gitDriver := git.GitArtifactDriver{
-			InsecureIgnoreHostKey: art.Git.InsecureIgnoreHostKey,
-		}
-		if art.Git.UsernameSecret != nil {
-			usernameBytes, err := we.GetSecretFromVolMount(art.Git.UsernameSecret.Name, art.Git.UsernameSecret.Key)
-			if err != nil {
-				return nil, err
-			}
-			gitDriver.Username = string(usernameBytes)
-		}
-		if art.Git.PasswordSecret != nil {
-			passwordBytes, err := we.GetSecretFromVolMount(art.Git.PasswordSecret.Name, art.Git.PasswordSecret.Key)
-			if err != nil {
-				return nil, err
-			}
-			gitDriver.Password = string(passwordBytes)
-		}
-		if art.Git.SSHPrivateKeySecret != nil {
-			sshPrivateKeyBytes, err := we.GetSecretFromVolMount(art.Git.SSHPrivateKeySecret.Name, art.Git.SSHPrivateKeySecret.Key)
-			if err != nil {
-				return nil, err
-			}
-			gitDriver.SSHPrivateKey = string(sshPrivateKeyBytes)
-		}
-
-		return &gitDriver, nil
-	}
-	if art.Artifactory != nil {
-		usernameBytes, err := we.GetSecretFromVolMount(art.Artifactory.UsernameSecret.Name, art.Artifactory.UsernameSecret.Key)
-		if err != nil {
-			return nil, err
-		}
-		passwordBytes, err := we.GetSecretFromVolMount(art.Artifactory.PasswordSecret.Name, art.Artifactory.PasswordSecret.Key)
-		if err != nil {
-			return nil, err
-		}
-		driver := artifactory.ArtifactoryArtifactDriver{
-			Username: string(usernameBytes),
-			Password: string(passwordBytes),
-		}
-		return &driver, nil
-
-	}
-	if art.HDFS != nil {
-		return hdfs.CreateDriver(we, art.HDFS)
-	}
-	if art.Raw != nil {
-		return &raw.RawArtifactDriver{}, nil
-	}
-
-	return nil, errors.Errorf(errors.CodeBadRequest, "Unsupported artifact driver for %s", art.Name)
+	return artifact.NewDriver(art, we)
 }
 
 // getPod is a wrapper around the pod interface to get the current pod from kube API server
@@ -809,56 +728,6 @@ func (we *WorkflowExecutor) AddAnnotation(key, value string) error {
 	return common.AddPodAnnotation(we.ClientSet, we.PodName, we.Namespace, key, value)
 }
 
-// isTarball returns whether or not the file is a tarball
-func isTarball(filePath string) bool {
-	cmd := exec.Command("tar", "-tf", filePath)
-	log.Info(cmd.Args)
-	err := cmd.Run()
-	return err == nil
-}
-
-// untar extracts a tarball to a temporary directory,
-// renaming it to the desired location
-func untar(tarPath string, destPath string) error {
-	// first extract the tar into a temporary dir
-	tmpDir := destPath + ".tmpdir"
-	err := os.MkdirAll(tmpDir, os.ModePerm)
-	if err != nil {
-		return errors.InternalWrapError(err)
-	}
-	err = common.RunCommand("tar", "-xf", tarPath, "-C", tmpDir)
-	if err != nil {
-		return err
-	}
-	// next, decide how we wish to rename the file/dir
-	// to the destination path.
-	files, err := ioutil.ReadDir(tmpDir)
-	if err != nil {
-		return errors.InternalWrapError(err)
-	}
-	if len(files) == 1 {
-		// if the tar is comprised of single file or directory,
-		// rename that file to the desired location
-		filePath := path.Join(tmpDir, files[0].Name())
-		err = os.Rename(filePath, destPath)
-		if err != nil {
-			return errors.InternalWrapError(err)
-		}
-		err = os.Remove(tmpDir)
-		if err != nil {
-			return errors.InternalWrapError(err)
-		}
-	} else {
-		// the tar extracted into multiple files. In this case,
-		// just rename the temp directory to the dest path
-		err = os.Rename(tmpDir, destPath)
-		if err != nil {
-			return errors.InternalWrapError(err)
-		}
-	}
-	return nil
-}
-
 // containerID is a convenience function to strip the 'docker://', 'containerd://' from k8s ContainerID string
 func containerID(ctrID string) string {
 	schemeIndex := strings.Index(ctrID, "://")
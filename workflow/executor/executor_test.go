@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -49,6 +50,33 @@ func TestSaveParameters(t *testing.T) {
 	assert.Equal(t, *we.Template.Outputs.Parameters[0].Value, "has a newline")
 }
 
+// TestSaveParametersDefault verifies the default of an output parameter is used when its path cannot be read
+func TestSaveParametersDefault(t *testing.T) {
+	mockRuntimeExecutor := mocks.ContainerRuntimeExecutor{}
+	defaultValue := "none"
+	we := WorkflowExecutor{
+		PodName: fakePodName,
+		Template: wfv1.Template{
+			Outputs: wfv1.Outputs{
+				Parameters: []wfv1.Parameter{
+					{Name: "my-out", ValueFrom: &wfv1.ValueFrom{Path: "/path", Default: &defaultValue}},
+					{Name: "no-default", ValueFrom: &wfv1.ValueFrom{Path: "/other"}},
+				},
+			},
+		},
+		ClientSet:       fake.NewSimpleClientset(),
+		Namespace:       fakeNamespace,
+		RuntimeExecutor: &mockRuntimeExecutor,
+		mainContainerID: fakeContainerID,
+	}
+	mockRuntimeExecutor.On("GetFileContents", fakeContainerID, "/path").Return("", errors.New("no such file"))
+	mockRuntimeExecutor.On("GetFileContents", fakeContainerID, "/other").Return("", errors.New("no such file"))
+	err := we.SaveParameters()
+	assert.Error(t, err)
+	assert.Equal(t, "none", *we.Template.Outputs.Parameters[0].Value)
+	assert.Nil(t, we.Template.Outputs.Parameters[1].Value)
+}
+
 // TestIsBaseImagePath tests logic of isBaseImagePath which determines if a path is coming from a
 // base image layer versus a shared volumeMount.
 func TestIsBaseImagePath(t *testing.T) {
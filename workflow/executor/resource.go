@@ -1,299 +1,498 @@
 package executor
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-
-	"github.com/argoproj/argo/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+
+	"github.com/argoproj/argo/errors"
+	"github.com/argoproj/argo/workflow/common"
 )
 
-// ExecResource will run kubectl action against a manifest
-func (we *WorkflowExecutor) ExecResource(action string, manifestPath string) (string, string, error) {
-	isDelete := action == "delete"
-	args := []string{
-		action,
+// resourcePollInterval is how often a resource is checked against the success and failure conditions
+var resourcePollInterval = 5 * time.Second
+
+// jsonPathExpr matches the JSONPath templates (e.g. {.status.phase}) of an expression condition
+var jsonPathExpr = regexp.MustCompile(`\{[^{}]+\}`)
+
+// ExecResource performs the action of the resource template against its manifest and returns the
+// resulting resource. Nothing is returned for delete actions.
+func (we *WorkflowExecutor) ExecResource(action string, manifestPath string) (*unstructured.Unstructured, error) {
+	manifest, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, errors.New(errors.CodeBadRequest, err.Error())
 	}
-	output := "json"
-	if isDelete {
-		args = append(args, "--ignore-not-found")
-		output = "name"
+	manifestJSON, err := yaml.YAMLToJSON(manifest)
+	if err != nil {
+		return nil, errors.Errorf(errors.CodeBadRequest, "failed to parse manifest: %v", err)
 	}
+	obj, ri, name, err := we.getResourceTarget(manifestJSON)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("%s %s/%s", action, obj.GetKind(), name)
 
-	if action == "patch" {
-		mergeStrategy := "strategic"
-		if we.Template.Resource.MergeStrategy != "" {
-			mergeStrategy = we.Template.Resource.MergeStrategy
+	switch action {
+	case "get":
+		return ri.Get(name, metav1.GetOptions{})
+	case "create":
+		return ri.Create(obj, metav1.CreateOptions{})
+	case "apply":
+		return applyResource(ri, obj)
+	case "replace":
+		existing, err := ri.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
 		}
-
-		args = append(args, "--type")
-		args = append(args, mergeStrategy)
-
-		args = append(args, "-p")
-		buff, err := ioutil.ReadFile(manifestPath)
-
+		obj.SetResourceVersion(existing.GetResourceVersion())
+		return ri.Update(obj, metav1.UpdateOptions{})
+	case "patch":
+		patchType, err := we.getPatchType()
 		if err != nil {
-			return "", "", errors.New(errors.CodeBadRequest, err.Error())
+			return nil, err
 		}
-
-		args = append(args, string(buff))
+		return ri.Patch(name, patchType, manifestJSON, metav1.PatchOptions{})
+	case "delete":
+		propagation := metav1.DeletePropagationBackground
+		err = ri.Delete(name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !apierr.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, nil
 	}
+	return nil, errors.Errorf(errors.CodeBadRequest, "unsupported resource action '%s'", action)
+}
 
-	args = append(args, "-f")
-	args = append(args, manifestPath)
-	args = append(args, "-o")
-	args = append(args, output)
-	cmd := exec.Command("kubectl", args...)
-	log.Info(strings.Join(cmd.Args, " "))
-	out, err := cmd.Output()
+// applyResource creates or updates a resource like kubectl apply does. The manifest is recorded in the
+// last-applied-configuration annotation, and the resource is patched with a three-way merge of the
+// previously applied manifest, the new one and the live resource, so that fields removed from the
+// manifest are removed from the resource while fields set by others are kept.
+func applyResource(ri dynamic.ResourceInterface, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	modified, err := setLastAppliedConfiguration(obj)
+	if err != nil {
+		return nil, err
+	}
+	current, err := ri.Get(obj.GetName(), metav1.GetOptions{})
+	if apierr.IsNotFound(err) {
+		return ri.Create(obj, metav1.CreateOptions{})
+	}
 	if err != nil {
-		exErr := err.(*exec.ExitError)
-		errMsg := strings.TrimSpace(string(exErr.Stderr))
-		return "", "", errors.New(errors.CodeBadRequest, errMsg)
+		return nil, err
 	}
-	if action == "delete" {
-		return "", "", nil
+	currentJSON, err := current.MarshalJSON()
+	if err != nil {
+		return nil, errors.InternalWrapError(err)
 	}
-	obj := unstructured.Unstructured{}
-	err = json.Unmarshal(out, &obj)
+	original := []byte(current.GetAnnotations()[corev1.LastAppliedConfigAnnotation])
+	patchType, patch, err := createThreeWayMergePatch(obj.GroupVersionKind(), original, modified, currentJSON)
 	if err != nil {
-		return "", "", err
+		return nil, errors.InternalWrapError(err)
 	}
-	resourceName := fmt.Sprintf("%s.%s/%s", obj.GroupVersionKind().Kind, obj.GroupVersionKind().Group, obj.GetName())
-	log.Infof("%s/%s", obj.GetNamespace(), resourceName)
-	return obj.GetNamespace(), resourceName, nil
+	if string(patch) == "{}" {
+		return current, nil
+	}
+	return ri.Patch(obj.GetName(), patchType, patch, metav1.PatchOptions{})
 }
 
-// gjsonLabels is an implementation of labels.Labels interface
-// which allows us to take advantage of k8s labels library
-// for the purposes of evaluating fail and success conditions
-type gjsonLabels struct {
-	json []byte
+// setLastAppliedConfiguration records the object, without the annotation itself, in its
+// last-applied-configuration annotation, and returns the JSON of the annotated object
+func setLastAppliedConfiguration(obj *unstructured.Unstructured) ([]byte, error) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	delete(annotations, corev1.LastAppliedConfigAnnotation)
+	obj.SetAnnotations(annotations)
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+	}
+	lastApplied, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, errors.InternalWrapError(err)
+	}
+	annotations[corev1.LastAppliedConfigAnnotation] = string(lastApplied)
+	obj.SetAnnotations(annotations)
+	return obj.MarshalJSON()
 }
 
-// Has returns whether the provided label exists.
-func (g gjsonLabels) Has(label string) bool {
-	return gjson.GetBytes(g.json, label).Exists()
+// createThreeWayMergePatch creates the patch of an apply. Like kubectl, it is a strategic merge patch
+// for the built-in kinds, and a JSON merge patch for the others, e.g. custom resources.
+func createThreeWayMergePatch(gvk schema.GroupVersionKind, original, modified, current []byte) (types.PatchType, []byte, error) {
+	versioned, err := scheme.Scheme.New(gvk)
+	if runtime.IsNotRegisteredError(err) {
+		patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(original, modified, current)
+		return types.MergePatchType, patch, err
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	lookupPatchMeta, err := strategicpatch.NewPatchMetaFromStruct(versioned)
+	if err != nil {
+		return "", nil, err
+	}
+	patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, current, lookupPatchMeta, true)
+	return types.StrategicMergePatchType, patch, err
 }
 
-// Get returns the value for the provided label.
-func (g gjsonLabels) Get(label string) string {
-	return gjson.GetBytes(g.json, label).String()
+// getPatchType returns the patch type of the template's merge strategy
+func (we *WorkflowExecutor) getPatchType() (types.PatchType, error) {
+	switch we.Template.Resource.MergeStrategy {
+	case "", "strategic":
+		return types.StrategicMergePatchType, nil
+	case "merge":
+		return types.MergePatchType, nil
+	case "json":
+		return types.JSONPatchType, nil
+	}
+	return "", errors.Errorf(errors.CodeBadRequest, "unsupported merge strategy '%s'", we.Template.Resource.MergeStrategy)
 }
 
-// WaitResource waits for a specific resource to satisfy either the success or failure condition
-func (we *WorkflowExecutor) WaitResource(resourceNamespace string, resourceName string) error {
-	if we.Template.Resource.SuccessCondition == "" && we.Template.Resource.FailureCondition == "" {
-		return nil
-	}
-	var successReqs labels.Requirements
-	if we.Template.Resource.SuccessCondition != "" {
-		successSelector, err := labels.Parse(we.Template.Resource.SuccessCondition)
+// getResourceTarget returns the object of the manifest, along with the client and name of the resource
+// it targets. If the template has flags, they identify the resource instead of the manifest, which is
+// required when the manifest is a JSON patch. Flags are only allowed for the get, patch and delete
+// actions, which do not need the manifest to be the resource.
+func (we *WorkflowExecutor) getResourceTarget(manifestJSON []byte) (*unstructured.Unstructured, dynamic.ResourceInterface, string, error) {
+	mapper := we.getRESTMapper()
+	obj := &unstructured.Unstructured{}
+	if len(we.Template.Resource.Flags) > 0 {
+		resourceType, name, namespace, err := parseResourceFlags(we.Template.Resource.Flags)
 		if err != nil {
-			return errors.Errorf(errors.CodeBadRequest, "success condition '%s' failed to parse: %v", we.Template.Resource.SuccessCondition, err)
+			return nil, nil, "", err
 		}
-		log.Infof("Waiting for conditions: %s", successSelector)
-		successReqs, _ = successSelector.Requirements()
-	}
-
-	var failReqs labels.Requirements
-	if we.Template.Resource.FailureCondition != "" {
-		failSelector, err := labels.Parse(we.Template.Resource.FailureCondition)
+		gvr, err := mapper.ResourceFor(schema.ParseGroupResource(resourceType).WithVersion(""))
+		if err != nil {
+			return nil, nil, "", errors.InternalWrapError(err)
+		}
+		gvk, err := mapper.KindFor(gvr)
+		if err != nil {
+			return nil, nil, "", errors.InternalWrapError(err)
+		}
+		obj.SetGroupVersionKind(gvk)
+		obj.SetName(name)
+		obj.SetNamespace(namespace)
+	} else {
+		err := json.Unmarshal(manifestJSON, &obj.Object)
 		if err != nil {
-			return errors.Errorf(errors.CodeBadRequest, "fail condition '%s' failed to parse: %v", we.Template.Resource.FailureCondition, err)
+			return nil, nil, "", errors.Errorf(errors.CodeBadRequest, "manifest must be a kubernetes object: %v", err)
 		}
-		log.Infof("Failing for conditions: %s", failSelector)
-		failReqs, _ = failSelector.Requirements()
 	}
+	ri, err := we.getResourceInterface(obj)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return obj, ri, obj.GetName(), nil
+}
 
-	// Start the condition result reader using PollImmediateInfinite
-	// Poll intervall of 5 seconds serves as a backoff intervall in case of immediate result reader failure
-	err := wait.PollImmediateInfinite(time.Second*5,
-		func() (bool, error) {
-			isErrRetry, err := checkResourceState(resourceNamespace, resourceName, successReqs, failReqs)
+// getResourceInterface returns the dynamic client of the resource of the given object. The
+// namespace of namespaced objects defaults to the namespace of the pod.
+func (we *WorkflowExecutor) getResourceInterface(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := we.getRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, errors.InternalWrapError(err)
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return we.DynamicClient.Resource(mapping.Resource), nil
+	}
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(we.Namespace)
+	}
+	return we.DynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+}
 
-			if err == nil {
-				log.Infof("Returning from successful wait for resource %s", resourceName)
-				return true, nil
-			}
+// getRESTMapper returns the mapper of kinds to resources, discovering them from the API server on first use
+func (we *WorkflowExecutor) getRESTMapper() meta.RESTMapper {
+	if we.RESTMapper == nil {
+		we.RESTMapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(we.ClientSet.Discovery()))
+	}
+	return we.RESTMapper
+}
 
-			if isErrRetry {
-				log.Infof("Waiting for resource %s resulted in retryable error %v", resourceName, err)
-				return false, nil
+// parseResourceFlags parses flags of the form "TYPE NAME" or "TYPE/NAME", optionally with
+// "-n NAMESPACE" or "--namespace=NAMESPACE"
+func parseResourceFlags(flags []string) (string, string, string, error) {
+	var args []string
+	namespace := ""
+	for i := 0; i < len(flags); i++ {
+		flag := flags[i]
+		switch {
+		case flag == "-n" || flag == "--namespace":
+			if i+1 >= len(flags) {
+				return "", "", "", errors.Errorf(errors.CodeBadRequest, "flag %s requires a value", flag)
 			}
-
-			log.Warnf("Waiting for resource %s resulted in non-retryable error %v", resourceName, err)
-			return false, err
-		})
-
-	if err != nil {
-		if err == wait.ErrWaitTimeout {
-			log.Warnf("Waiting for resource %s resulted in timeout due to repeated errors", resourceName)
-		} else {
-			log.Warnf("Waiting for resource %s resulted in error %v", resourceName, err)
+			i++
+			namespace = flags[i]
+		case strings.HasPrefix(flag, "--namespace="):
+			namespace = strings.TrimPrefix(flag, "--namespace=")
+		case strings.HasPrefix(flag, "-"):
+			return "", "", "", errors.Errorf(errors.CodeBadRequest, "unsupported flag '%s'", flag)
+		default:
+			args = append(args, flag)
 		}
 	}
-
-	return err
+	if len(args) == 1 {
+		args = strings.SplitN(args[0], "/", 2)
+	}
+	if len(args) != 2 || args[0] == "" || args[1] == "" {
+		return "", "", "", errors.Errorf(errors.CodeBadRequest, "flags must identify the resource as TYPE NAME or TYPE/NAME: %v", flags)
+	}
+	return args[0], args[1], namespace, nil
 }
 
-// Function to do the kubectl get -w command and then waiting on json reading.
-func checkResourceState(resourceNamespace string, resourceName string, successReqs labels.Requirements, failReqs labels.Requirements) (bool, error) {
+// resourceCondition is a parsed success or failure condition. A condition is either a label
+// selector over the fields of the resource (e.g. "status.phase == Succeeded"), or an expression
+// over JSONPath templates (e.g. "{.status.conditions[?(@.type=='Ready')].status} == True").
+type resourceCondition struct {
+	condition string
+	reqs      labels.Requirements
+}
 
-	cmd, reader, err := startKubectlWaitCmd(resourceNamespace, resourceName)
+func parseResourceCondition(condition string) (*resourceCondition, error) {
+	if condition == "" {
+		return nil, nil
+	}
+	cond := resourceCondition{condition: condition}
+	if jsonPathExpr.MatchString(condition) {
+		return &cond, nil
+	}
+	selector, err := labels.Parse(condition)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	defer func() {
-		_ = cmd.Process.Kill()
-	}()
-
-	for {
-		jsonBytes, err := readJSON(reader)
+	cond.reqs, _ = selector.Requirements()
+	return &cond, nil
+}
 
+// matches evaluates the condition against the resource. For label selectors, matchAny determines
+// whether any or all of the requirements must match.
+func (c *resourceCondition) matches(obj *unstructured.Unstructured, matchAny bool) (bool, error) {
+	if c.reqs == nil {
+		expr, values, err := resolveJSONPaths(c.condition, obj)
 		if err != nil {
-			resultErr := err
-			log.Warnf("Json reader returned error %v. Calling kill (usually superfluous)", err)
-			// We don't want to write OS specific code so we don't want to call syscall package code. But that means
-			// there is no way to figure out if a process is running or not in an asynchronous manner. exec.Wait will
-			// always block and we need to call that to get the exit code of the process. So we will unconditionally
-			// call exec.Process.Kill and then assume that wait will not block after that. Two things may happen:
-			// 1. Process already exited and kill does nothing (returns error which we ignore) and then we call
-			//    Wait and get the proper return value
-			// 2. Process is running gets, killed with exec.Process.Kill call and Wait returns an error code and we give up
-			//    and don't retry
-			_ = cmd.Process.Kill()
-
-			log.Warnf("Command for kubectl get -w for %s exited. Getting return value using Wait", resourceName)
-			err = cmd.Wait()
-			if err != nil {
-				log.Warnf("cmd.Wait for kubectl get -w command for resource %s returned error %v",
-					resourceName, err)
-				resultErr = err
-			} else {
-				log.Infof("readJSon failed for resource %s but cmd.Wait for kubectl get -w command did not error", resourceName)
-			}
-			return true, resultErr
+			return false, err
 		}
-
-		log.Info(string(jsonBytes))
-		ls := gjsonLabels{json: jsonBytes}
-		for _, req := range failReqs {
-			failed := req.Matches(ls)
-			msg := fmt.Sprintf("failure condition '%s' evaluated %v", req, failed)
-			log.Infof(msg)
-			if failed {
-				// TODO: need a better error code instead of BadRequest
-				return false, errors.Errorf(errors.CodeBadRequest, msg)
+		matched, err := common.EvaluateBoolExpression(expr, values)
+		log.Infof("condition '%s' (%v) evaluated %v", c.condition, values, matched)
+		return matched, err
+	}
+	jsonBytes, err := json.Marshal(obj.Object)
+	if err != nil {
+		return false, errors.InternalWrapError(err)
+	}
+	ls := gjsonLabels{json: jsonBytes}
+	numMatched := 0
+	for _, req := range c.reqs {
+		matched := req.Matches(ls)
+		log.Infof("condition '%s' evaluated %v", req, matched)
+		if matched {
+			if matchAny {
+				return true, nil
 			}
+			numMatched++
 		}
-		numMatched := 0
-		for _, req := range successReqs {
-			matched := req.Matches(ls)
-			log.Infof("success condition '%s' evaluated %v", req, matched)
-			if matched {
-				numMatched++
-			}
+	}
+	return !matchAny && numMatched >= len(c.reqs), nil
+}
+
+// resolveJSONPaths replaces the JSONPath templates of an expression with references to their values in
+// the resource, which are returned along with the expression. Numbers are referenced as is, and anything
+// else as a quoted string.
+func resolveJSONPaths(expr string, obj *unstructured.Unstructured) (string, map[string]string, error) {
+	values := make(map[string]string)
+	var resolveErr error
+	result := jsonPathExpr.ReplaceAllStringFunc(expr, func(tmpl string) string {
+		value, err := evaluateJSONPath(tmpl, obj)
+		if err != nil {
+			resolveErr = err
+			return ""
 		}
-		log.Infof("%d/%d success conditions matched", numMatched, len(successReqs))
-		if numMatched >= len(successReqs) {
-			return false, nil
+		name := fmt.Sprintf("jsonpath%d", len(values))
+		values[name] = value
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return "{{" + name + "}}"
 		}
-	}
+		return "'{{" + name + "}}'"
+	})
+	return result, values, resolveErr
 }
 
-// Start Kubectl command Get with -w return error if unable to start command
-func startKubectlWaitCmd(resourceNamespace string, resourceName string) (*exec.Cmd, *bufio.Reader, error) {
-	args := []string{"get", resourceName, "-w", "-o", "json"}
-	if resourceNamespace != "" {
-		args = append(args, "-n", resourceNamespace)
-	}
-	cmd := exec.Command("kubectl", args...)
-	stdout, err := cmd.StdoutPipe()
+// evaluateJSONPath returns the value of a JSONPath template in the resource. Like kubectl, the
+// braces and leading dot of the template may be omitted.
+func evaluateJSONPath(tmpl string, obj *unstructured.Unstructured) (string, error) {
+	if !strings.HasPrefix(tmpl, "{") {
+		if !strings.HasPrefix(tmpl, ".") {
+			tmpl = "." + tmpl
+		}
+		tmpl = "{" + tmpl + "}"
+	}
+	jp := jsonpath.New("resource").AllowMissingKeys(true)
+	err := jp.Parse(tmpl)
 	if err != nil {
-		return nil, nil, errors.InternalWrapError(err)
+		return "", errors.Errorf(errors.CodeBadRequest, "failed to parse JSONPath '%s': %v", tmpl, err)
 	}
-	reader := bufio.NewReader(stdout)
-	log.Info(strings.Join(cmd.Args, " "))
-	if err := cmd.Start(); err != nil {
-		return nil, nil, errors.InternalWrapError(err)
+	var buf bytes.Buffer
+	err = jp.Execute(&buf, obj.Object)
+	if err != nil {
+		return "", errors.Errorf(errors.CodeBadRequest, "failed to evaluate JSONPath '%s': %v", tmpl, err)
 	}
+	return buf.String(), nil
+}
 
-	return cmd, reader, nil
+// gjsonLabels is an implementation of labels.Labels interface
+// which allows us to take advantage of k8s labels library
+// for the purposes of evaluating fail and success conditions
+type gjsonLabels struct {
+	json []byte
 }
 
-// readJSON reads from a reader line-by-line until it reaches "}\n" indicating end of json
-func readJSON(reader *bufio.Reader) ([]byte, error) {
-	var buffer bytes.Buffer
-	for {
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			return nil, err
-		}
-		isDelimiter := len(line) == 2 && line[0] == byte('}')
-		line = bytes.TrimSpace(line)
-		_, err = buffer.Write(line)
-		if err != nil {
-			return nil, err
-		}
-		if isDelimiter {
-			break
-		}
+// Has returns whether the provided label exists.
+func (g gjsonLabels) Has(label string) bool {
+	return gjson.GetBytes(g.json, label).Exists()
+}
+
+// Get returns the value for the provided label.
+func (g gjsonLabels) Get(label string) string {
+	return gjson.GetBytes(g.json, label).String()
+}
+
+// WaitResource waits for a specific resource to satisfy either the success or failure condition
+func (we *WorkflowExecutor) WaitResource(obj *unstructured.Unstructured) error {
+	if we.Template.Resource.SuccessCondition == "" && we.Template.Resource.FailureCondition == "" {
+		return nil
 	}
-	return buffer.Bytes(), nil
+	successCond, err := parseResourceCondition(we.Template.Resource.SuccessCondition)
+	if err != nil {
+		return errors.Errorf(errors.CodeBadRequest, "success condition '%s' failed to parse: %v", we.Template.Resource.SuccessCondition, err)
+	}
+	failCond, err := parseResourceCondition(we.Template.Resource.FailureCondition)
+	if err != nil {
+		return errors.Errorf(errors.CodeBadRequest, "fail condition '%s' failed to parse: %v", we.Template.Resource.FailureCondition, err)
+	}
+	ri, err := we.getResourceInterface(obj)
+	if err != nil {
+		return err
+	}
+	resourceName := fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())
+	log.Infof("Waiting for resource %s: success condition '%s', failure condition '%s'", resourceName, we.Template.Resource.SuccessCondition, we.Template.Resource.FailureCondition)
+
+	err = wait.PollImmediateInfinite(resourcePollInterval,
+		func() (bool, error) {
+			current, err := ri.Get(obj.GetName(), metav1.GetOptions{})
+			if apierr.IsNotFound(err) {
+				return false, errors.Errorf(errors.CodeNotFound, "resource %s was not found", resourceName)
+			}
+			if err != nil {
+				log.Infof("Waiting for resource %s resulted in retryable error %v", resourceName, err)
+				return false, nil
+			}
+			if failCond != nil {
+				failed, err := failCond.matches(current, true)
+				if err != nil {
+					return false, err
+				}
+				if failed {
+					// TODO: need a better error code instead of BadRequest
+					return false, errors.Errorf(errors.CodeBadRequest, "failure condition '%s' evaluated true", failCond.condition)
+				}
+			}
+			if successCond != nil {
+				succeeded, err := successCond.matches(current, false)
+				if err != nil || !succeeded {
+					return false, err
+				}
+			}
+			log.Infof("Returning from successful wait for resource %s", resourceName)
+			return true, nil
+		})
+	if err != nil {
+		log.Warnf("Waiting for resource %s resulted in error %v", resourceName, err)
+	}
+	return err
 }
 
 // SaveResourceParameters will save any resource output parameters
-func (we *WorkflowExecutor) SaveResourceParameters(resourceNamespace string, resourceName string) error {
+func (we *WorkflowExecutor) SaveResourceParameters(obj *unstructured.Unstructured) error {
 	if len(we.Template.Outputs.Parameters) == 0 {
 		log.Infof("No output parameters")
 		return nil
 	}
 	log.Infof("Saving resource output parameters")
+	ri, err := we.getResourceInterface(obj)
+	if err != nil {
+		return err
+	}
+	current, err := ri.Get(obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return errors.InternalWrapError(err)
+	}
 	for i, param := range we.Template.Outputs.Parameters {
 		if param.ValueFrom == nil {
 			continue
 		}
-		var cmd *exec.Cmd
+		var output string
 		if param.ValueFrom.JSONPath != "" {
-			args := []string{"get", resourceName, "-o", fmt.Sprintf("jsonpath=%s", param.ValueFrom.JSONPath)}
-			if resourceNamespace != "" {
-				args = append(args, "-n", resourceNamespace)
-			}
-			cmd = exec.Command("kubectl", args...)
+			output, err = evaluateJSONPath(param.ValueFrom.JSONPath, current)
 		} else if param.ValueFrom.JQFilter != "" {
-			resArgs := []string{resourceName}
-			if resourceNamespace != "" {
-				resArgs = append(resArgs, "-n", resourceNamespace)
-			}
-			cmdStr := fmt.Sprintf("kubectl get %s -o json | jq -c '%s'", strings.Join(resArgs, " "), param.ValueFrom.JQFilter)
-			cmd = exec.Command("sh", "-c", cmdStr)
+			output, err = evaluateJQFilter(param.ValueFrom.JQFilter, current)
 		} else {
 			continue
 		}
-		log.Info(cmd.Args)
-		out, err := cmd.Output()
 		if err != nil {
-			if exErr, ok := err.(*exec.ExitError); ok {
-				log.Errorf("`%s` stderr:\n%s", cmd.Args, string(exErr.Stderr))
+			if param.ValueFrom.Default == nil {
+				return err
 			}
-			return errors.InternalWrapError(err)
+			log.Warnf("Failed to evaluate output parameter %s, using the default: %v", param.Name, err)
+			output = *param.ValueFrom.Default
 		}
-		output := string(out)
 		we.Template.Outputs.Parameters[i].Value = &output
 		log.Infof("Saved output parameter: %s, value: %s", param.Name, output)
 	}
-	err := we.AnnotateOutputs(nil)
+	err = we.AnnotateOutputs(nil)
 	return err
 }
+
+// evaluateJQFilter runs jq with the given filter against the resource
+func evaluateJQFilter(filter string, obj *unstructured.Unstructured) (string, error) {
+	jsonBytes, err := json.Marshal(obj.Object)
+	if err != nil {
+		return "", errors.InternalWrapError(err)
+	}
+	cmd := exec.Command("jq", "-c", filter)
+	cmd.Stdin = bytes.NewReader(jsonBytes)
+	log.Info(cmd.Args)
+	out, err := cmd.Output()
+	if err != nil {
+		if exErr, ok := err.(*exec.ExitError); ok {
+			log.Errorf("`%s` stderr:\n%s", cmd.Args, string(exErr.Stderr))
+		}
+		return "", errors.InternalWrapError(err)
+	}
+	return string(out), nil
+}
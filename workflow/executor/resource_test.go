@@ -0,0 +1,314 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+)
+
+var configMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+var existingConfigMap = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+  namespace: default
+data:
+  phase: Running
+  count: "3"
+`
+
+func unmarshalUnstructured(yamlStr string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	err := yaml.Unmarshal([]byte(yamlStr), &obj.Object)
+	if err != nil {
+		panic(err)
+	}
+	return obj
+}
+
+// newResourceExecutor returns an executor of the given resource template, backed by a fake dynamic
+// client which knows about config maps and widgets
+func newResourceExecutor(resourceTmpl wfv1.ResourceTemplate, objects ...runtime.Object) *WorkflowExecutor {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(widgetGVR.GroupVersion().WithKind("Widget"), meta.RESTScopeNamespace)
+	return &WorkflowExecutor{
+		PodName:       fakePodName,
+		Namespace:     fakeNamespace,
+		ClientSet:     fake.NewSimpleClientset(),
+		DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...),
+		RESTMapper:    mapper,
+		Template:      wfv1.Template{Resource: &resourceTmpl},
+	}
+}
+
+func writeManifest(t *testing.T, manifest string) string {
+	f, err := ioutil.TempFile("", "manifest")
+	assert.NoError(t, err)
+	_, err = f.WriteString(manifest)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	return f.Name()
+}
+
+func TestExecResourceGet(t *testing.T) {
+	we := newResourceExecutor(wfv1.ResourceTemplate{Action: "get"}, unmarshalUnstructured(existingConfigMap))
+	manifestPath := writeManifest(t, existingConfigMap)
+	defer func() { _ = os.Remove(manifestPath) }()
+
+	obj, err := we.ExecResource("get", manifestPath)
+	assert.NoError(t, err)
+	if assert.NotNil(t, obj) {
+		data, _, _ := unstructured.NestedStringMap(obj.Object, "data")
+		assert.Equal(t, "Running", data["phase"])
+	}
+}
+
+func TestExecResourceCreate(t *testing.T) {
+	we := newResourceExecutor(wfv1.ResourceTemplate{Action: "create"})
+	manifestPath := writeManifest(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: new-config
+data:
+  key: value
+`)
+	defer func() { _ = os.Remove(manifestPath) }()
+
+	obj, err := we.ExecResource("create", manifestPath)
+	assert.NoError(t, err)
+	if assert.NotNil(t, obj) {
+		// namespaced resources default to the namespace of the pod
+		assert.Equal(t, fakeNamespace, obj.GetNamespace())
+	}
+	_, err = we.DynamicClient.Resource(configMapGVR).Namespace(fakeNamespace).Get("new-config", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestExecResourcePatch(t *testing.T) {
+	// merge patch identified by the manifest
+	we := newResourceExecutor(wfv1.ResourceTemplate{Action: "patch", MergeStrategy: "merge"}, unmarshalUnstructured(existingConfigMap))
+	manifestPath := writeManifest(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+data:
+  phase: Succeeded
+`)
+	defer func() { _ = os.Remove(manifestPath) }()
+	obj, err := we.ExecResource("patch", manifestPath)
+	assert.NoError(t, err)
+	if assert.NotNil(t, obj) {
+		data, _, _ := unstructured.NestedStringMap(obj.Object, "data")
+		assert.Equal(t, "Succeeded", data["phase"])
+		assert.Equal(t, "3", data["count"])
+	}
+
+	// json patch identified by the flags
+	we = newResourceExecutor(wfv1.ResourceTemplate{Action: "patch", MergeStrategy: "json", Flags: []string{"configmap/my-config"}}, unmarshalUnstructured(existingConfigMap))
+	jsonPatchPath := writeManifest(t, `
+- op: replace
+  path: /data/count
+  value: "4"
+`)
+	defer func() { _ = os.Remove(jsonPatchPath) }()
+	obj, err = we.ExecResource("patch", jsonPatchPath)
+	assert.NoError(t, err)
+	if assert.NotNil(t, obj) {
+		data, _, _ := unstructured.NestedStringMap(obj.Object, "data")
+		assert.Equal(t, "4", data["count"])
+	}
+}
+
+var widgetGVR = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+var widget = `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-widget
+  namespace: default
+spec:
+  phase: Running
+  count: 3
+`
+
+func TestExecResourceApply(t *testing.T) {
+	we := newResourceExecutor(wfv1.ResourceTemplate{Action: "apply"})
+	manifestPath := writeManifest(t, widget)
+	defer func() { _ = os.Remove(manifestPath) }()
+	obj, err := we.ExecResource("apply", manifestPath)
+	assert.NoError(t, err)
+	if assert.NotNil(t, obj) {
+		assert.Contains(t, obj.GetAnnotations()[corev1.LastAppliedConfigAnnotation], `"count":3`)
+	}
+
+	// a field set by someone else is kept
+	ri := we.DynamicClient.Resource(widgetGVR).Namespace("default")
+	live, err := ri.Get("my-widget", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, unstructured.SetNestedField(live.Object, "other", "spec", "owner"))
+	_, err = ri.Update(live, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	// a field removed from the manifest is removed from the resource
+	reappliedPath := writeManifest(t, `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-widget
+  namespace: default
+spec:
+  phase: Succeeded
+`)
+	defer func() { _ = os.Remove(reappliedPath) }()
+	obj, err = we.ExecResource("apply", reappliedPath)
+	assert.NoError(t, err)
+	if assert.NotNil(t, obj) {
+		spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+		assert.Equal(t, map[string]interface{}{"phase": "Succeeded", "owner": "other"}, spec)
+		assert.NotContains(t, obj.GetAnnotations()[corev1.LastAppliedConfigAnnotation], "count")
+	}
+}
+
+// TestCreateThreeWayMergePatch verifies built-in kinds are applied with a strategic merge patch
+func TestCreateThreeWayMergePatch(t *testing.T) {
+	original := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"my-config"},"data":{"phase":"Running","count":"3"}}`)
+	modified := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"my-config"},"data":{"phase":"Succeeded"}}`)
+	current := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"my-config"},"data":{"phase":"Running","count":"3","owner":"other"}}`)
+	patchType, patch, err := createThreeWayMergePatch(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, original, modified, current)
+	assert.NoError(t, err)
+	assert.Equal(t, types.StrategicMergePatchType, patchType)
+	assert.JSONEq(t, `{"data":{"count":null,"phase":"Succeeded"}}`, string(patch))
+
+	patchType, _, err = createThreeWayMergePatch(widgetGVR.GroupVersion().WithKind("Widget"), original, modified, current)
+	assert.NoError(t, err)
+	assert.Equal(t, types.MergePatchType, patchType)
+}
+
+func TestExecResourceDelete(t *testing.T) {
+	we := newResourceExecutor(wfv1.ResourceTemplate{Action: "delete"}, unmarshalUnstructured(existingConfigMap))
+	manifestPath := writeManifest(t, existingConfigMap)
+	defer func() { _ = os.Remove(manifestPath) }()
+
+	_, err := we.ExecResource("delete", manifestPath)
+	assert.NoError(t, err)
+	// deleting a missing resource is not an error
+	_, err = we.ExecResource("delete", manifestPath)
+	assert.NoError(t, err)
+}
+
+func TestParseResourceFlags(t *testing.T) {
+	resourceType, name, namespace, err := parseResourceFlags([]string{"deployment", "my-app", "-n", "prod"})
+	assert.NoError(t, err)
+	assert.Equal(t, "deployment", resourceType)
+	assert.Equal(t, "my-app", name)
+	assert.Equal(t, "prod", namespace)
+
+	resourceType, name, namespace, err = parseResourceFlags([]string{"deployments.apps/my-app"})
+	assert.NoError(t, err)
+	assert.Equal(t, "deployments.apps", resourceType)
+	assert.Equal(t, "my-app", name)
+	assert.Equal(t, "", namespace)
+
+	_, _, _, err = parseResourceFlags([]string{"deployment"})
+	assert.Error(t, err)
+	_, _, _, err = parseResourceFlags([]string{"deployment/my-app", "--force"})
+	assert.Error(t, err)
+}
+
+var resourceWithConditions = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: my-job
+  annotations:
+    note: it's 'done' || true
+status:
+  succeeded: 1
+  conditions:
+  - type: Complete
+    status: "True"
+  - type: Failed
+    status: "False"
+`
+
+func TestResourceConditions(t *testing.T) {
+	obj := unmarshalUnstructured(resourceWithConditions)
+	tests := []struct {
+		condition string
+		matchAny  bool
+		expected  bool
+	}{
+		{"status.succeeded > 0", false, true},
+		{"status.succeeded > 0,status.failed > 0", false, false},
+		{"status.succeeded > 0,status.failed > 0", true, true},
+		{"{.status.conditions[?(@.type=='Complete')].status} == True", false, true},
+		{"{.status.conditions[?(@.type=='Failed')].status} == True", false, false},
+		{"{.status.succeeded} >= 1 && {.metadata.name} == 'my-job'", false, true},
+		{"{.status.missing} == ''", false, true},
+		{"{.metadata.annotations.note} =~ 'done'", false, true},
+		{"{.metadata.annotations.note} == 'true'", false, false},
+	}
+	for _, test := range tests {
+		cond, err := parseResourceCondition(test.condition)
+		assert.NoError(t, err)
+		matched, err := cond.matches(obj, test.matchAny)
+		assert.NoError(t, err, test.condition)
+		assert.Equal(t, test.expected, matched, test.condition)
+	}
+}
+
+func TestWaitResource(t *testing.T) {
+	we := newResourceExecutor(wfv1.ResourceTemplate{
+		Action:           "get",
+		SuccessCondition: "{.data.phase} == Running",
+	}, unmarshalUnstructured(existingConfigMap))
+	err := we.WaitResource(unmarshalUnstructured(existingConfigMap))
+	assert.NoError(t, err)
+
+	we.Template.Resource.SuccessCondition = ""
+	we.Template.Resource.FailureCondition = "data.phase == Running"
+	err = we.WaitResource(unmarshalUnstructured(existingConfigMap))
+	assert.Error(t, err)
+
+	// a resource which was deleted will never satisfy the success condition
+	we = newResourceExecutor(wfv1.ResourceTemplate{
+		Action:           "create",
+		SuccessCondition: "{.data.phase} == Succeeded",
+	})
+	err = we.WaitResource(unmarshalUnstructured(existingConfigMap))
+	assert.EqualError(t, err, "resource ConfigMap/my-config was not found")
+}
+
+func TestSaveResourceParameters(t *testing.T) {
+	we := newResourceExecutor(wfv1.ResourceTemplate{Action: "get"}, unmarshalUnstructured(existingConfigMap))
+	we.Template.Outputs.Parameters = []wfv1.Parameter{
+		{Name: "phase", ValueFrom: &wfv1.ValueFrom{JSONPath: "{.data.phase}"}},
+		{Name: "count", ValueFrom: &wfv1.ValueFrom{JSONPath: "data.count"}},
+	}
+	_, err := we.ClientSet.CoreV1().Pods(fakeNamespace).Create(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fakePodName}})
+	assert.NoError(t, err)
+	err = we.SaveResourceParameters(unmarshalUnstructured(existingConfigMap))
+	assert.NoError(t, err)
+	assert.Equal(t, "Running", *we.Template.Outputs.Parameters[0].Value)
+	assert.Equal(t, "3", *we.Template.Outputs.Parameters[1].Value)
+}
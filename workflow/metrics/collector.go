@@ -44,6 +44,13 @@ var (
 		append(descWorkflowDefaultLabels, "phase"),
 		nil,
 	)
+
+	descControllerLeader = prometheus.NewDesc(
+		"argo_workflow_controller_leader",
+		"Whether the workflow controller replica is the leader.",
+		nil,
+		nil,
+	)
 )
 
 func boolFloat64(b bool) float64 {
@@ -67,13 +74,32 @@ func NewWorkflowRegistry(informer cache.SharedIndexInformer) *prometheus.Registr
 }
 
 // NewTelemetryRegistry creates a new prometheus registry that collects telemetry
-func NewTelemetryRegistry() *prometheus.Registry {
+func NewTelemetryRegistry(isLeader func() bool) *prometheus.Registry {
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(prometheus.NewProcessCollector(os.Getpid(), ""))
 	registry.MustRegister(prometheus.NewGoCollector())
+	registry.MustRegister(&leaderCollector{isLeader: isLeader})
+	for _, collector := range append(controllerCollectors, workqueueCollectors...) {
+		registry.MustRegister(collector)
+	}
 	return registry
 }
 
+// leaderCollector collects whether the controller replica is the leader
+type leaderCollector struct {
+	isLeader func() bool
+}
+
+// Describe implements the prometheus.Collector interface
+func (lc *leaderCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- descControllerLeader
+}
+
+// Collect implements the prometheus.Collector interface
+func (lc *leaderCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(descControllerLeader, prometheus.GaugeValue, boolFloat64(lc.isLeader()))
+}
+
 // Describe implements the prometheus.Collector interface
 func (wc *workflowCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- descWorkflowInfo
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj/argo/errors"
+)
+
+// gatherTelemetry returns the telemetry metrics by their name and labels
+func gatherTelemetry(t *testing.T, isLeader bool) map[string]float64 {
+	families, err := NewTelemetryRegistry(func() bool { return isLeader }).Gather()
+	assert.NoError(t, err)
+	values := make(map[string]float64)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			name := family.GetName()
+			for _, label := range m.GetLabel() {
+				name += fmt.Sprintf("{%s=%s}", label.GetName(), label.GetValue())
+			}
+			switch {
+			case m.GetGauge() != nil:
+				values[name] = m.GetGauge().GetValue()
+			case m.GetCounter() != nil:
+				values[name] = m.GetCounter().GetValue()
+			case m.GetHistogram() != nil:
+				values[name] = float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return values
+}
+
+// TestTelemetryRegistry verifies the controller internals are collected by the telemetry registry
+func TestTelemetryRegistry(t *testing.T) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test-queue")
+	defer queue.ShutDown()
+	queue.Add("a")
+	queue.Add("b")
+
+	OperationCompleted(time.Second)
+	PersistConflict()
+	PodCreated()
+	PodCreated()
+	PodDeleted()
+	Throttled(ThrottleKindPodCreation)
+	WorkflowRequeued()
+	Error(errors.Errorf(errors.CodeBadRequest, "bad request"))
+	Error(fmt.Errorf("not an argo error"))
+
+	values := gatherTelemetry(t, true)
+	assert.Equal(t, float64(1), values["argo_workflow_controller_leader"])
+	assert.Equal(t, float64(2), values["argo_workflow_controller_queue_depth{queue=test-queue}"])
+	assert.Equal(t, float64(2), values["argo_workflow_controller_queue_adds_total{queue=test-queue}"])
+	assert.Equal(t, float64(1), values["argo_workflow_controller_operation_duration_seconds"])
+	assert.Equal(t, float64(1), values["argo_workflow_controller_persist_conflicts_total"])
+	assert.Equal(t, float64(2), values["argo_workflow_controller_pods_created_total"])
+	assert.Equal(t, float64(1), values["argo_workflow_controller_pods_deleted_total"])
+	assert.Equal(t, float64(1), values["argo_workflow_controller_throttled_total{kind=pod_creation}"])
+	assert.Equal(t, float64(1), values["argo_workflow_controller_workflow_requeues_total"])
+	assert.Equal(t, float64(1), values["argo_workflow_controller_errors_total{code=ERR_BAD_REQUEST}"])
+	assert.Equal(t, float64(1), values["argo_workflow_controller_errors_total{code=UNKNOWN}"])
+
+	values = gatherTelemetry(t, false)
+	assert.Equal(t, float64(0), values["argo_workflow_controller_leader"])
+}
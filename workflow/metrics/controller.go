@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/argoproj/argo/errors"
+)
+
+// errorCodeUnknown is the code counted for errors which are not Argo errors
+const errorCodeUnknown = "UNKNOWN"
+
+const (
+	// ThrottleKindClient is the kind of throttling of requests of the controller to the API server
+	ThrottleKindClient = "k8s_client"
+	// ThrottleKindPodCreation is the kind of throttling of pod creations by the controller
+	ThrottleKindPodCreation = "pod_creation"
+)
+
+var (
+	operationDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "argo_workflow_controller_operation_duration_seconds",
+		Help:    "Duration of a single operation on a workflow.",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	})
+	persistConflicts = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "argo_workflow_controller_persist_conflicts_total",
+		Help: "Number of workflow updates which conflicted with a newer version of the workflow.",
+	})
+	reapplyUpdateRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "argo_workflow_controller_reapply_update_retries_total",
+		Help: "Number of attempts to re-apply a conflicting update to the latest version of a workflow.",
+	})
+	podsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "argo_workflow_controller_pods_created_total",
+		Help: "Number of pods created by the controller.",
+	})
+	podsDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "argo_workflow_controller_pods_deleted_total",
+		Help: "Number of pods deleted by the controller.",
+	})
+	throttled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "argo_workflow_controller_throttled_total",
+		Help: "Number of requests and pod creations which were delayed by a rate limit of the controller, by kind.",
+	}, []string{"kind"})
+	workflowRequeues = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "argo_workflow_controller_workflow_requeues_total",
+		Help: "Number of workflows requeued with backoff after an operation failed to persist.",
+	})
+	errorsByCode = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "argo_workflow_controller_errors_total",
+		Help: "Number of errors workflows and nodes were marked with, by error code.",
+	}, []string{"code"})
+)
+
+// controllerCollectors are the collectors of the controller internals
+var controllerCollectors = []prometheus.Collector{
+	operationDuration,
+	persistConflicts,
+	reapplyUpdateRetries,
+	podsCreated,
+	podsDeleted,
+	throttled,
+	workflowRequeues,
+	errorsByCode,
+}
+
+// OperationCompleted records the duration of an operation on a workflow
+func OperationCompleted(duration time.Duration) {
+	operationDuration.Observe(duration.Seconds())
+}
+
+// PersistConflict records an update of a workflow which conflicted with a newer version
+func PersistConflict() {
+	persistConflicts.Inc()
+}
+
+// ReapplyUpdateRetry records an attempt to re-apply an update to the latest version of a workflow
+func ReapplyUpdateRetry() {
+	reapplyUpdateRetries.Inc()
+}
+
+// PodCreated records the creation of a pod
+func PodCreated() {
+	podsCreated.Inc()
+}
+
+// PodDeleted records the deletion of a pod
+func PodDeleted() {
+	podsDeleted.Inc()
+}
+
+// Throttled records a request or pod creation of the given kind which was delayed by a rate limit
+func Throttled(kind string) {
+	throttled.WithLabelValues(kind).Inc()
+}
+
+// WorkflowRequeued records a workflow which was requeued with backoff
+func WorkflowRequeued() {
+	workflowRequeues.Inc()
+}
+
+// Error records an error by its code
+func Error(err error) {
+	code := errorCodeUnknown
+	if argoErr, ok := err.(errors.ArgoError); ok {
+		code = argoErr.Code()
+	}
+	errorsByCode.WithLabelValues(code).Inc()
+}
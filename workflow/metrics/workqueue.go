@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// The metrics of the named work queues of the controller, labeled by the name of the queue
+var (
+	queueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "argo_workflow_controller_queue_depth",
+		Help: "Current depth of the work queue.",
+	}, []string{"queue"})
+	queueAdds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "argo_workflow_controller_queue_adds_total",
+		Help: "Number of items added to the work queue.",
+	}, []string{"queue"})
+	queueLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "argo_workflow_controller_queue_latency_seconds",
+		Help:    "How long an item stays in the work queue before it is processed.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"queue"})
+	queueWorkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "argo_workflow_controller_queue_work_duration_seconds",
+		Help:    "How long processing an item from the work queue takes.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"queue"})
+	queueUnfinishedWork = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "argo_workflow_controller_queue_unfinished_work_seconds",
+		Help: "How long the items currently being processed have been in progress, in total.",
+	}, []string{"queue"})
+	queueLongestRunningProcessor = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "argo_workflow_controller_queue_longest_running_processor_seconds",
+		Help: "How long the longest running item of the work queue has been in progress.",
+	}, []string{"queue"})
+	queueRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "argo_workflow_controller_queue_retries_total",
+		Help: "Number of items re-added to the work queue with a rate limit.",
+	}, []string{"queue"})
+)
+
+// workqueueCollectors are the collectors of the work queue metrics
+var workqueueCollectors = []prometheus.Collector{
+	queueDepth,
+	queueAdds,
+	queueLatency,
+	queueWorkDuration,
+	queueUnfinishedWork,
+	queueLongestRunningProcessor,
+	queueRetries,
+}
+
+func init() {
+	// the provider only applies to queues created afterwards, so it is set as soon as the package is loaded
+	workqueue.SetProvider(workqueueMetricsProvider{})
+}
+
+// workqueueMetricsProvider implements the workqueue.MetricsProvider interface. The deprecated metrics
+// are not collected.
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return queueDepth.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return queueAdds.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return queueLatency.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return queueWorkDuration.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return queueUnfinishedWork.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return queueLongestRunningProcessor.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return queueRetries.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewDeprecatedDepthMetric(name string) workqueue.GaugeMetric {
+	return noopMetric{}
+}
+
+func (workqueueMetricsProvider) NewDeprecatedAddsMetric(name string) workqueue.CounterMetric {
+	return noopMetric{}
+}
+
+func (workqueueMetricsProvider) NewDeprecatedLatencyMetric(name string) workqueue.SummaryMetric {
+	return noopMetric{}
+}
+
+func (workqueueMetricsProvider) NewDeprecatedWorkDurationMetric(name string) workqueue.SummaryMetric {
+	return noopMetric{}
+}
+
+func (workqueueMetricsProvider) NewDeprecatedUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return noopMetric{}
+}
+
+func (workqueueMetricsProvider) NewDeprecatedLongestRunningProcessorMicrosecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return noopMetric{}
+}
+
+func (workqueueMetricsProvider) NewDeprecatedRetriesMetric(name string) workqueue.CounterMetric {
+	return noopMetric{}
+}
+
+// noopMetric discards the deprecated work queue metrics
+type noopMetric struct{}
+
+func (noopMetric) Inc()            {}
+func (noopMetric) Dec()            {}
+func (noopMetric) Set(float64)     {}
+func (noopMetric) Observe(float64) {}
@@ -33,10 +33,12 @@ type Controller struct {
 	workqueue    workqueue.DelayingInterface
 	resyncPeriod time.Duration
 	clock        clock.Clock
+	// defaultTTLStrategy applies to workflows which do not specify a TTL
+	defaultTTLStrategy *wfv1.TTLStrategy
 }
 
 // NewController returns a new workflow ttl controller
-func NewController(config *rest.Config, wfClientset wfclientset.Interface, namespace, instanceID string) *Controller {
+func NewController(config *rest.Config, wfClientset wfclientset.Interface, namespace, instanceID string, defaultTTLStrategy *wfv1.TTLStrategy) *Controller {
 	filterCompletedWithTTL := func(options *metav1.ListOptions) {
 		// completed equals (true)
 		completedReq, err := labels.NewRequirement(common.LabelKeyCompleted, selection.Equals, []string{"true"})
@@ -51,11 +53,12 @@ func NewController(config *rest.Config, wfClientset wfclientset.Interface, names
 	wfInformer := util.NewWorkflowInformer(config, namespace, workflowTTLResyncPeriod, filterCompletedWithTTL)
 
 	controller := &Controller{
-		wfclientset:  wfClientset,
-		wfInformer:   wfInformer,
-		workqueue:    workqueue.NewNamedDelayingQueue("workflow-ttl"),
-		resyncPeriod: workflowTTLResyncPeriod,
-		clock:        clock.RealClock{},
+		wfclientset:        wfClientset,
+		wfInformer:         wfInformer,
+		workqueue:          workqueue.NewNamedDelayingQueue("workflow-ttl"),
+		resyncPeriod:       workflowTTLResyncPeriod,
+		clock:              clock.RealClock{},
+		defaultTTLStrategy: defaultTTLStrategy,
 	}
 
 	wfInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -141,7 +144,7 @@ func (c *Controller) enqueueWF(obj interface{}) {
 		return
 	}
 	now := c.clock.Now()
-	remaining, expiration := timeLeft(wf, &now)
+	remaining, expiration := c.timeLeft(wf, &now)
 	if remaining == nil || *remaining > c.resyncPeriod {
 		return
 	}
@@ -198,17 +201,43 @@ func (c *Controller) deleteWorkflow(key string) error {
 }
 
 func (c *Controller) ttlExpired(wf *wfv1.Workflow) bool {
+	ttl := c.ttlSeconds(wf)
 	// We don't care about the Workflows that are going to be deleted, or the ones that don't need clean up.
-	if wf.DeletionTimestamp != nil || wf.Spec.TTLSecondsAfterFinished == nil || wf.Status.FinishedAt.IsZero() {
+	if wf.DeletionTimestamp != nil || ttl == nil || wf.Status.FinishedAt.IsZero() {
 		return false
 	}
 	now := c.clock.Now()
-	expiry := wf.Status.FinishedAt.Add(time.Second * time.Duration(*wf.Spec.TTLSecondsAfterFinished))
+	expiry := wf.Status.FinishedAt.Add(time.Second * time.Duration(*ttl))
 	return now.After(expiry)
 }
 
-func timeLeft(wf *wfv1.Workflow, since *time.Time) (*time.Duration, *time.Time) {
-	if wf.DeletionTimestamp != nil || wf.Spec.TTLSecondsAfterFinished == nil || wf.Status.FinishedAt.IsZero() {
+// ttlSeconds returns the number of seconds a workflow lives for once it finished, or nil if it does
+// not expire. The TTL strategy of the workflow takes precedence over ttlSecondsAfterFinished, which
+// in turn takes precedence over the default TTL strategy of the controller.
+func (c *Controller) ttlSeconds(wf *wfv1.Workflow) *int32 {
+	strategy := wf.Spec.TTLStrategy
+	if strategy == nil {
+		if wf.Spec.TTLSecondsAfterFinished != nil {
+			return wf.Spec.TTLSecondsAfterFinished
+		}
+		strategy = c.defaultTTLStrategy
+	}
+	if strategy == nil {
+		return nil
+	}
+	if wf.Status.Successful() {
+		if strategy.SecondsAfterSuccess != nil {
+			return strategy.SecondsAfterSuccess
+		}
+	} else if strategy.SecondsAfterFailure != nil {
+		return strategy.SecondsAfterFailure
+	}
+	return strategy.SecondsAfterCompletion
+}
+
+func (c *Controller) timeLeft(wf *wfv1.Workflow, since *time.Time) (*time.Duration, *time.Time) {
+	ttl := c.ttlSeconds(wf)
+	if wf.DeletionTimestamp != nil || ttl == nil || wf.Status.FinishedAt.IsZero() {
 		return nil, nil
 	}
 	sinceUTC := since.UTC()
@@ -216,7 +245,7 @@ func timeLeft(wf *wfv1.Workflow, since *time.Time) (*time.Duration, *time.Time)
 	if finishAtUTC.After(sinceUTC) {
 		log.Infof("Warning: Found Workflow %s/%s finished in the future. This is likely due to time skew in the cluster. Workflow cleanup will be deferred.", wf.Namespace, wf.Name)
 	}
-	expireAtUTC := finishAtUTC.Add(time.Duration(*wf.Spec.TTLSecondsAfterFinished) * time.Second)
+	expireAtUTC := finishAtUTC.Add(time.Duration(*ttl) * time.Second)
 	remaining := expireAtUTC.Sub(sinceUTC)
 	return &remaining, &expireAtUTC
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 	fakewfclientset "github.com/argoproj/argo/pkg/client/clientset/versioned/fake"
 	"github.com/argoproj/argo/test"
 	"github.com/argoproj/argo/workflow/util"
@@ -86,3 +87,48 @@ func TestEnqueueWF(t *testing.T) {
 	controller.enqueueWF(un)
 	assert.Equal(t, 1, controller.workqueue.Len())
 }
+
+func TestTTLStrategy(t *testing.T) {
+	var ten, hour, week int32 = 10, 3600, 604800
+	controller := newTTLController()
+	wf := test.LoadWorkflowFromBytes([]byte(completedWf))
+	wf.Status.FinishedAt = metav1.Time{Time: controller.clock.Now().Add(-11 * time.Second)}
+
+	// Verify a workflow without a TTL does not expire
+	assert.Nil(t, controller.ttlSeconds(wf))
+	assert.False(t, controller.ttlExpired(wf))
+
+	// Verify the TTL depends on the outcome of the workflow
+	wf.Spec.TTLStrategy = &wfv1.TTLStrategy{SecondsAfterSuccess: &hour, SecondsAfterFailure: &week}
+	wf.Status.Phase = wfv1.NodeSucceeded
+	assert.Equal(t, hour, *controller.ttlSeconds(wf))
+	wf.Status.Phase = wfv1.NodeFailed
+	assert.Equal(t, week, *controller.ttlSeconds(wf))
+	wf.Status.Phase = wfv1.NodeError
+	assert.Equal(t, week, *controller.ttlSeconds(wf))
+	assert.False(t, controller.ttlExpired(wf))
+
+	// Verify secondsAfterCompletion applies unless a more specific TTL is set
+	wf.Spec.TTLStrategy = &wfv1.TTLStrategy{SecondsAfterCompletion: &ten, SecondsAfterSuccess: &hour}
+	wf.Status.Phase = wfv1.NodeFailed
+	assert.Equal(t, ten, *controller.ttlSeconds(wf))
+	assert.True(t, controller.ttlExpired(wf))
+	wf.Status.Phase = wfv1.NodeSucceeded
+	assert.False(t, controller.ttlExpired(wf))
+
+	// Verify the TTL strategy takes precedence over ttlSecondsAfterFinished
+	wf.Spec.TTLSecondsAfterFinished = &ten
+	assert.Equal(t, hour, *controller.ttlSeconds(wf))
+	wf.Spec.TTLStrategy = nil
+	assert.Equal(t, ten, *controller.ttlSeconds(wf))
+
+	// Verify the default TTL strategy of the controller applies to workflows without a TTL
+	controller.defaultTTLStrategy = &wfv1.TTLStrategy{SecondsAfterSuccess: &ten}
+	assert.Equal(t, ten, *controller.ttlSeconds(wf))
+	wf.Spec.TTLSecondsAfterFinished = nil
+	assert.True(t, controller.ttlExpired(wf))
+	un, err := util.ToUnstructured(wf)
+	assert.NoError(t, err)
+	controller.enqueueWF(un)
+	assert.Equal(t, 1, controller.workqueue.Len())
+}
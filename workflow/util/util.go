@@ -16,6 +16,7 @@ import (
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -147,6 +148,7 @@ type SubmitOpts struct {
 	DryRun         bool                   // --dry-run
 	ServerDryRun   bool                   // --server-dry-run
 	Labels         string                 // --labels
+	Priority       *int32                 // --priority
 	OwnerReference *metav1.OwnerReference // useful if your custom controller creates argo workflow resources
 }
 
@@ -161,6 +163,9 @@ func SubmitWorkflow(wfIf v1alpha1.WorkflowInterface, wfClientset wfclientset.Int
 	if opts.ServiceAccount != "" {
 		wf.Spec.ServiceAccountName = opts.ServiceAccount
 	}
+	if opts.Priority != nil {
+		wf.Spec.Priority = opts.Priority
+	}
 	labels := wf.GetLabels()
 	if labels == nil {
 		labels = make(map[string]string)
@@ -268,6 +273,80 @@ func SubmitWorkflow(wfIf v1alpha1.WorkflowInterface, wfClientset wfclientset.Int
 	}
 }
 
+// SubmitWorkflowFromResource submits a workflow which runs a template of a resource, referenced as
+// kind/name, e.g. workflowtemplate/my-template. The entrypoint is the template of the resource named
+// by opts.Entrypoint, or else its first template. The arguments of the resource are the arguments of
+// the workflow, which the parameters of opts override.
+func SubmitWorkflowFromResource(wfIf v1alpha1.WorkflowInterface, wfClientset wfclientset.Interface, namespace string, resource string, opts *SubmitOpts) (*wfv1.Workflow, error) {
+	if opts == nil {
+		opts = &SubmitOpts{}
+	}
+	parts := strings.SplitN(resource, "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, errors.Errorf(errors.CodeBadRequest, "Expected a resource of the form: KIND/NAME. Received: %s", resource)
+	}
+	kind, name := strings.ToLower(parts[0]), parts[1]
+	switch kind {
+	case workflow.WorkflowTemplateSingular, workflow.WorkflowTemplatePlural, workflow.WorkflowTemplateShortName:
+	default:
+		return nil, errors.Errorf(errors.CodeBadRequest, "Cannot submit a workflow from a resource of kind %s, supported kinds are: %s", parts[0], workflow.WorkflowTemplateSingular)
+	}
+	wftmpl, err := wfClientset.ArgoprojV1alpha1().WorkflowTemplates(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	wf, err := NewWorkflowFromWorkflowTemplate(wftmpl, opts.Entrypoint)
+	if err != nil {
+		return nil, err
+	}
+	return SubmitWorkflow(wfIf, wfClientset, namespace, wf, opts)
+}
+
+// NewWorkflowFromWorkflowTemplate returns a workflow in the namespace of a workflow template, whose
+// entrypoint references a template of the workflow template, which is the given entrypoint or else the
+// first template of the workflow template
+func NewWorkflowFromWorkflowTemplate(wftmpl *wfv1.WorkflowTemplate, entrypoint string) (*wfv1.Workflow, error) {
+	if entrypoint == "" {
+		if len(wftmpl.Spec.Templates) == 0 {
+			return nil, errors.Errorf(errors.CodeBadRequest, "WorkflowTemplate '%s' has no templates", wftmpl.Name)
+		}
+		entrypoint = wftmpl.Spec.Templates[0].Name
+	}
+	tmpl := wftmpl.GetTemplateByName(entrypoint)
+	if tmpl == nil {
+		return nil, errors.Errorf(errors.CodeBadRequest, "WorkflowTemplate '%s' has no template '%s'", wftmpl.Name, entrypoint)
+	}
+	// The entrypoint declares the same input parameters as the referred template and passes them
+	// through, so that the workflow arguments reach the referred template
+	entryTmpl := wfv1.Template{
+		Name: entrypoint,
+		TemplateRef: &wfv1.TemplateRef{
+			Name:     wftmpl.Name,
+			Template: entrypoint,
+		},
+	}
+	for _, param := range tmpl.Inputs.Parameters {
+		entryTmpl.Inputs.Parameters = append(entryTmpl.Inputs.Parameters, *param.DeepCopy())
+		value := fmt.Sprintf("{{inputs.parameters.%s}}", param.Name)
+		entryTmpl.Arguments.Parameters = append(entryTmpl.Arguments.Parameters, wfv1.Parameter{Name: param.Name, Value: &value})
+	}
+	return &wfv1.Workflow{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       workflow.WorkflowKind,
+			APIVersion: wfv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: wftmpl.Name + "-",
+			Namespace:    wftmpl.Namespace,
+		},
+		Spec: wfv1.WorkflowSpec{
+			Entrypoint: entrypoint,
+			Arguments:  *wftmpl.Spec.Arguments.DeepCopy(),
+			Templates:  []wfv1.Template{entryTmpl},
+		},
+	}, nil
+}
+
 // CreateServerDryRun fills the workflow struct with the server's representation without creating it and returns an error, if there is any
 func CreateServerDryRun(wf *wfv1.Workflow, wfClientset wfclientset.Interface) (*wfv1.Workflow, error) {
 	// Keep the workflow metadata because it will be overwritten by the Post request
@@ -471,13 +550,28 @@ func convertNodeID(newWf *wfv1.Workflow, regex *regexp.Regexp, oldNodeID string,
 	return newWf.NodeID(newNodeName)
 }
 
-// RetryWorkflow updates a workflow, deleting all failed steps as well as the onExit node (and children)
-func RetryWorkflow(kubeClient kubernetes.Interface, wfClient v1alpha1.WorkflowInterface, wf *wfv1.Workflow) (*wfv1.Workflow, error) {
+// RetryOpts are options used when retrying a workflow
+type RetryOpts struct {
+	NodeFieldSelector string   // --node-field-selector
+	RestartSuccessful bool     // --restart-successful
+	Parameters        []string // --parameter
+}
+
+// RetryWorkflow updates a workflow, deleting all failed steps as well as the onExit node (and children).
+// With a node field selector, only the selected nodes and the nodes run within or after them are
+// retried, including successful ones if requested.
+func RetryWorkflow(kubeClient kubernetes.Interface, wfClient v1alpha1.WorkflowInterface, wf *wfv1.Workflow, opts *RetryOpts) (*wfv1.Workflow, error) {
+	if opts == nil {
+		opts = &RetryOpts{}
+	}
 	switch wf.Status.Phase {
 	case wfv1.NodeFailed, wfv1.NodeError:
 	default:
 		return nil, errors.Errorf(errors.CodeBadRequest, "workflow must be Failed/Error to retry")
 	}
+	if opts.RestartSuccessful && opts.NodeFieldSelector == "" {
+		return nil, errors.Errorf(errors.CodeBadRequest, "restarting successful nodes requires a node field selector")
+	}
 	newWF := wf.DeepCopy()
 	podIf := kubeClient.CoreV1().Pods(wf.ObjectMeta.Namespace)
 
@@ -491,27 +585,46 @@ func RetryWorkflow(kubeClient kubernetes.Interface, wfClient v1alpha1.WorkflowIn
 		// if it was terminated, unset the deadline
 		newWF.Spec.ActiveDeadlineSeconds = nil
 	}
+	if IsWorkflowStopped(newWF) {
+		// if it was stopped, unset the shutdown strategy
+		newWF.Spec.Shutdown = ""
+		delete(newWF.ObjectMeta.Annotations, common.AnnotationKeyStoppedBy)
+	}
+	err := overrideParameters(newWF, opts.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	// A nil nodeIDsToReset means all failed nodes are reset. Otherwise only the selected nodes are reset,
+	// and the nodes containing them have to be run again.
+	nodeIDsToReset, err := getNodeIDsToReset(wf, opts.NodeFieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	ancestorIDs := make(map[string]bool)
+	for nodeID := range nodeIDsToReset {
+		for _, ancestorID := range common.GetNodeAncestry(wf.Status.Nodes, nodeID) {
+			ancestorIDs[ancestorID] = true
+		}
+	}
 
 	// Iterate the previous nodes. If it was successful Pod carry it forward
 	newWF.Status.Nodes = make(map[string]wfv1.NodeStatus)
 	onExitNodeName := wf.ObjectMeta.Name + ".onExit"
 	for _, node := range wf.Status.Nodes {
+		isOnExitNode := strings.HasPrefix(node.Name, onExitNodeName)
+		selected := nodeIDsToReset == nil || nodeIDsToReset[node.ID]
 		switch node.Phase {
 		case wfv1.NodeSucceeded, wfv1.NodeSkipped:
-			if !strings.HasPrefix(node.Name, onExitNodeName) {
+			if !isOnExitNode && !(opts.RestartSuccessful && selected) && !ancestorIDs[node.ID] {
 				newWF.Status.Nodes[node.ID] = node
 				continue
 			}
 		case wfv1.NodeError, wfv1.NodeFailed:
-			if !strings.HasPrefix(node.Name, onExitNodeName) && node.Type == wfv1.NodeTypeDAG {
-				newNode := node.DeepCopy()
-				newNode.Phase = wfv1.NodeRunning
-				newNode.Message = ""
-				newNode.FinishedAt = metav1.Time{}
-				newWF.Status.Nodes[newNode.ID] = *newNode
+			if !isOnExitNode && !selected && !ancestorIDs[node.ID] {
+				newWF.Status.Nodes[node.ID] = node
 				continue
 			}
-			// do not add this status to the node. pretend as if this node never existed.
 		default:
 			// Do not allow retry of workflows with pods in Running/Pending phase
 			return nil, errors.InternalErrorf("Workflow cannot be retried with node %s in %s phase", node, node.Phase)
@@ -522,7 +635,7 @@ func RetryWorkflow(kubeClient kubernetes.Interface, wfClient v1alpha1.WorkflowIn
 			if err != nil && !apierr.IsNotFound(err) {
 				return nil, errors.InternalWrapError(err)
 			}
-		} else if node.Name == wf.ObjectMeta.Name {
+		} else if node.Name == wf.ObjectMeta.Name || (!isOnExitNode && node.Type == wfv1.NodeTypeDAG) {
 			newNode := node.DeepCopy()
 			newNode.Phase = wfv1.NodeRunning
 			newNode.Message = ""
@@ -530,6 +643,7 @@ func RetryWorkflow(kubeClient kubernetes.Interface, wfClient v1alpha1.WorkflowIn
 			newWF.Status.Nodes[newNode.ID] = *newNode
 			continue
 		}
+		// do not add this status to the node. pretend as if this node never existed.
 	}
 
 	newWF.Status.StoredTemplates = make(map[string]wfv1.Template)
@@ -540,6 +654,94 @@ func RetryWorkflow(kubeClient kubernetes.Interface, wfClient v1alpha1.WorkflowIn
 	return wfClient.Update(newWF)
 }
 
+// getNodeIDsToReset returns the IDs of the nodes matching the node field selector, along with the nodes
+// run within or after them. A nil map is returned if no selector was given.
+func getNodeIDsToReset(wf *wfv1.Workflow, nodeFieldSelector string) (map[string]bool, error) {
+	if nodeFieldSelector == "" {
+		return nil, nil
+	}
+	selector, err := fields.ParseSelector(nodeFieldSelector)
+	if err != nil {
+		return nil, errors.Errorf(errors.CodeBadRequest, "invalid node field selector '%s': %v", nodeFieldSelector, err)
+	}
+	nodeIDsToReset := make(map[string]bool)
+	for _, node := range wf.Status.Nodes {
+		if !SelectorMatchesNode(selector, node) {
+			continue
+		}
+		startID := node.ID
+		if parentID := firstNodeAncestor(wf.Status.Nodes, node.ID); parentID != "" && wf.Status.Nodes[parentID].Type == wfv1.NodeTypeRetry {
+			// selecting an attempt of a retry node retries the node as a whole
+			startID = parentID
+		}
+		// the children of a node are the nodes run within it, followed by the ones depending on it
+		queue := []string{startID}
+		for len(queue) > 0 {
+			nodeID := queue[0]
+			queue = queue[1:]
+			if nodeIDsToReset[nodeID] {
+				continue
+			}
+			nodeIDsToReset[nodeID] = true
+			queue = append(queue, wf.Status.Nodes[nodeID].Children...)
+		}
+	}
+	if len(nodeIDsToReset) == 0 {
+		return nil, errors.Errorf(errors.CodeBadRequest, "no nodes match the node field selector '%s'", nodeFieldSelector)
+	}
+	return nodeIDsToReset, nil
+}
+
+// firstNodeAncestor returns the ID of the node directly containing the node
+func firstNodeAncestor(nodes map[string]wfv1.NodeStatus, nodeID string) string {
+	ancestry := common.GetNodeAncestry(nodes, nodeID)
+	if len(ancestry) == 0 {
+		return ""
+	}
+	return ancestry[0]
+}
+
+// SelectorMatchesNode returns whether the node field selector matches the node. Supported fields are
+// id, name, displayName, templateName, phase, type, templateRef.name and templateRef.template
+func SelectorMatchesNode(selector fields.Selector, node wfv1.NodeStatus) bool {
+	nodeFields := fields.Set{
+		"id":           node.ID,
+		"name":         node.Name,
+		"displayName":  node.DisplayName,
+		"templateName": node.TemplateName,
+		"phase":        string(node.Phase),
+		"type":         string(node.Type),
+	}
+	if node.TemplateRef != nil {
+		nodeFields["templateRef.name"] = node.TemplateRef.Name
+		nodeFields["templateRef.template"] = node.TemplateRef.Template
+	}
+	return selector.Matches(nodeFields)
+}
+
+// overrideParameters overrides the values of global parameters of the workflow with ones of the
+// form NAME=VALUE
+func overrideParameters(wf *wfv1.Workflow, parameters []string) error {
+	for _, paramStr := range parameters {
+		parts := strings.SplitN(paramStr, "=", 2)
+		if len(parts) == 1 {
+			return errors.Errorf(errors.CodeBadRequest, "Expected parameter of the form: NAME=VALUE. Received: %s", paramStr)
+		}
+		found := false
+		for i, param := range wf.Spec.Arguments.Parameters {
+			if param.Name == parts[0] {
+				value := parts[1]
+				wf.Spec.Arguments.Parameters[i].Value = &value
+				found = true
+			}
+		}
+		if !found {
+			return errors.Errorf(errors.CodeBadRequest, "workflow has no parameter '%s'", parts[0])
+		}
+	}
+	return nil
+}
+
 var errSuspendedCompletedWorkflow = errors.Errorf(errors.CodeBadRequest, "cannot suspend completed workflows")
 
 // IsWorkflowSuspended returns whether or not a workflow is considered suspended
@@ -570,6 +772,43 @@ func TerminateWorkflow(wfClient v1alpha1.WorkflowInterface, name string) error {
 			"activeDeadlineSeconds": 0,
 		},
 	}
+	return patchWorkflow(wfClient, name, patchObj)
+}
+
+// IsWorkflowStopped returns whether or not a workflow was stopped
+func IsWorkflowStopped(wf *wfv1.Workflow) bool {
+	return wf.Spec.Shutdown == wfv1.ShutdownStrategyStop
+}
+
+// StopMessage returns the message of a stopped workflow, which says who stopped it
+func StopMessage(wf *wfv1.Workflow) string {
+	if stoppedBy := wf.ObjectMeta.Annotations[common.AnnotationKeyStoppedBy]; stoppedBy != "" {
+		return fmt.Sprintf("Stopped by %s", stoppedBy)
+	}
+	return "Stopped"
+}
+
+// StopWorkflow stops a workflow by setting its shutdown strategy to Stop. Running pods are signaled
+// and no new nodes are scheduled, but unlike TerminateWorkflow, the exit handler is still run.
+// stoppedBy is recorded on the workflow and used in its message
+func StopWorkflow(wfClient v1alpha1.WorkflowInterface, name string, stoppedBy string) error {
+	patchObj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"shutdown": wfv1.ShutdownStrategyStop,
+		},
+	}
+	if stoppedBy != "" {
+		patchObj["metadata"] = map[string]interface{}{
+			"annotations": map[string]interface{}{
+				common.AnnotationKeyStoppedBy: stoppedBy,
+			},
+		}
+	}
+	return patchWorkflow(wfClient, name, patchObj)
+}
+
+// patchWorkflow applies a merge patch to a workflow, retrying on conflicts
+func patchWorkflow(wfClient v1alpha1.WorkflowInterface, name string, patchObj map[string]interface{}) error {
 	var err error
 	patch, err := json.Marshal(patchObj)
 	if err != nil {
@@ -1,7 +1,10 @@
 package util
 
 import (
+	"encoding/json"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -9,9 +12,12 @@ import (
 	"github.com/ghodss/yaml"
 
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/argo/pkg/client/clientset/versioned"
 	fakeClientset "github.com/argoproj/argo/pkg/client/clientset/versioned/fake"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 )
 
 // TestSubmitDryRun
@@ -168,3 +174,285 @@ func unmarshalWF(yamlStr string) *wfv1.Workflow {
 	}
 	return &wf
 }
+
+var workflowTemplateToSubmit = `
+apiVersion: argoproj.io/v1alpha1
+kind: WorkflowTemplate
+metadata:
+  name: greeting
+  namespace: test-namespace
+spec:
+  arguments:
+    parameters:
+    - name: message
+      value: hello
+  templates:
+  - name: whalesay
+    inputs:
+      parameters:
+      - name: message
+    container:
+      image: docker/whalesay:latest
+      command: [cowsay]
+      args: ["{{inputs.parameters.message}}"]
+  - name: goodbye
+    container:
+      image: docker/whalesay:latest
+      command: [cowsay]
+      args: ["goodbye"]
+`
+
+// TestSubmitWorkflowFromResource verifies a workflow template is submitted with its arguments, which
+// the submit options override
+func TestSubmitWorkflowFromResource(t *testing.T) {
+	var wftmpl wfv1.WorkflowTemplate
+	err := yaml.Unmarshal([]byte(workflowTemplateToSubmit), &wftmpl)
+	assert.NoError(t, err)
+	wfClientSet := fakeClientset.NewSimpleClientset(&wftmpl)
+	wfClient := wfClientSet.ArgoprojV1alpha1().Workflows("test-namespace")
+
+	wf, err := SubmitWorkflowFromResource(wfClient, wfClientSet, "test-namespace", "workflowtemplate/greeting", &SubmitOpts{
+		Parameters: []string{"message=hi"},
+		Labels:     "team=a",
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "greeting-", wf.GenerateName)
+		assert.Equal(t, "test-namespace", wf.Namespace)
+		assert.Equal(t, "whalesay", wf.Spec.Entrypoint)
+		assert.Equal(t, "greeting", wf.Spec.Templates[0].TemplateRef.Name)
+		assert.Equal(t, "whalesay", wf.Spec.Templates[0].TemplateRef.Template)
+		assert.Equal(t, "hi", *wf.Spec.Arguments.Parameters[0].Value)
+		assert.Equal(t, "a", wf.Labels["team"])
+	}
+
+	wf, err = SubmitWorkflowFromResource(wfClient, wfClientSet, "test-namespace", "wftmpl/greeting", &SubmitOpts{Entrypoint: "goodbye", DryRun: true})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "goodbye", wf.Spec.Templates[0].TemplateRef.Template)
+		assert.Equal(t, "test-namespace", wf.Namespace)
+	}
+
+	// a server dry run posts the workflow to the namespace of the workflow template
+	var dryRunPath, dryRunQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode(&wftmpl)
+			return
+		}
+		dryRunPath, dryRunQuery = r.URL.Path, r.URL.RawQuery
+		body, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	restClientSet, err := versioned.NewForConfig(&rest.Config{Host: server.URL})
+	assert.NoError(t, err)
+	wf, err = SubmitWorkflowFromResource(wfClient, restClientSet, "test-namespace", "wftmpl/greeting", &SubmitOpts{ServerDryRun: true})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/apis/argoproj.io/v1alpha1/namespaces/test-namespace/workflows", dryRunPath)
+		assert.Equal(t, "dryRun=All", dryRunQuery)
+		assert.Equal(t, "test-namespace", wf.Namespace)
+		assert.Equal(t, "whalesay", wf.Spec.Templates[0].TemplateRef.Template)
+	}
+
+	_, err = SubmitWorkflowFromResource(wfClient, wfClientSet, "test-namespace", "wftmpl/greeting", &SubmitOpts{Entrypoint: "missing"})
+	assert.EqualError(t, err, "WorkflowTemplate 'greeting' has no template 'missing'")
+
+	_, err = SubmitWorkflowFromResource(wfClient, wfClientSet, "test-namespace", "cronworkflow/greeting", nil)
+	assert.Error(t, err)
+
+	_, err = SubmitWorkflowFromResource(wfClient, wfClientSet, "test-namespace", "greeting", nil)
+	assert.Error(t, err)
+}
+
+var failedStepsWorkflow = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: steps-retry
+  labels:
+    workflows.argoproj.io/completed: "true"
+    workflows.argoproj.io/phase: Failed
+spec:
+  entrypoint: steps
+  arguments:
+    parameters:
+    - name: epochs
+      value: "1"
+status:
+  phase: Failed
+  nodes:
+    steps-retry:
+      id: steps-retry
+      name: steps-retry
+      displayName: steps-retry
+      type: Steps
+      phase: Failed
+      children: [steps-retry-0]
+    steps-retry-0:
+      id: steps-retry-0
+      name: steps-retry[0]
+      displayName: "[0]"
+      type: StepGroup
+      phase: Succeeded
+      boundaryID: steps-retry
+      children: [steps-retry-prep]
+    steps-retry-prep:
+      id: steps-retry-prep
+      name: steps-retry[0].prep
+      displayName: prep
+      type: Pod
+      phase: Succeeded
+      boundaryID: steps-retry
+      children: [steps-retry-1]
+    steps-retry-1:
+      id: steps-retry-1
+      name: steps-retry[1]
+      displayName: "[1]"
+      type: StepGroup
+      phase: Failed
+      boundaryID: steps-retry
+      children: [steps-retry-train, steps-retry-lint]
+    steps-retry-train:
+      id: steps-retry-train
+      name: steps-retry[1].train
+      displayName: train
+      type: Pod
+      phase: Failed
+      boundaryID: steps-retry
+    steps-retry-lint:
+      id: steps-retry-lint
+      name: steps-retry[1].lint
+      displayName: lint
+      type: Pod
+      phase: Failed
+      boundaryID: steps-retry
+`
+
+var failedDAGWorkflow = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  name: dag-retry
+  labels:
+    workflows.argoproj.io/completed: "true"
+    workflows.argoproj.io/phase: Failed
+spec:
+  entrypoint: dag
+status:
+  phase: Failed
+  nodes:
+    dag-retry:
+      id: dag-retry
+      name: dag-retry
+      displayName: dag-retry
+      type: DAG
+      phase: Failed
+      children: [dag-retry-a]
+    dag-retry-a:
+      id: dag-retry-a
+      name: dag-retry.A
+      displayName: A
+      type: Pod
+      phase: Succeeded
+      boundaryID: dag-retry
+      children: [dag-retry-b, dag-retry-c]
+    dag-retry-b:
+      id: dag-retry-b
+      name: dag-retry.B
+      displayName: B
+      type: Pod
+      phase: Succeeded
+      boundaryID: dag-retry
+      children: [dag-retry-d]
+    dag-retry-c:
+      id: dag-retry-c
+      name: dag-retry.C
+      displayName: C
+      type: Pod
+      phase: Failed
+      boundaryID: dag-retry
+    dag-retry-d:
+      id: dag-retry-d
+      name: dag-retry.D
+      displayName: D
+      type: Pod
+      phase: Succeeded
+      boundaryID: dag-retry
+`
+
+// retryWorkflow creates the workflow and retries it, returning the phases of the nodes after the retry
+func retryWorkflow(t *testing.T, wfStr string, opts *RetryOpts) (*wfv1.Workflow, map[string]wfv1.NodePhase, error) {
+	wf := unmarshalWF(wfStr)
+	wfClient := fakeClientset.NewSimpleClientset().ArgoprojV1alpha1().Workflows("")
+	wf, err := wfClient.Create(wf)
+	assert.NoError(t, err)
+	wf, err = RetryWorkflow(kubefake.NewSimpleClientset(), wfClient, wf, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	phases := make(map[string]wfv1.NodePhase)
+	for _, node := range wf.Status.Nodes {
+		phases[node.DisplayName] = node.Phase
+	}
+	return wf, phases, nil
+}
+
+func TestRetryWorkflow(t *testing.T) {
+	wf, phases, err := retryWorkflow(t, failedStepsWorkflow, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, wfv1.NodeRunning, wf.Status.Phase)
+		assert.Equal(t, map[string]wfv1.NodePhase{"steps-retry": wfv1.NodeRunning, "[0]": wfv1.NodeSucceeded, "prep": wfv1.NodeSucceeded}, phases)
+	}
+
+	_, _, err = retryWorkflow(t, failedStepsWorkflow, &RetryOpts{RestartSuccessful: true})
+	assert.Error(t, err)
+}
+
+func TestRetryWorkflowSteps(t *testing.T) {
+	// only the selected failed step is retried
+	_, phases, err := retryWorkflow(t, failedStepsWorkflow, &RetryOpts{NodeFieldSelector: "displayName=train"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]wfv1.NodePhase{"steps-retry": wfv1.NodeRunning, "[0]": wfv1.NodeSucceeded, "prep": wfv1.NodeSucceeded, "lint": wfv1.NodeFailed}, phases)
+	}
+
+	// successful steps are kept unless restarted
+	_, phases, err = retryWorkflow(t, failedStepsWorkflow, &RetryOpts{NodeFieldSelector: "displayName=prep"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]wfv1.NodePhase{"steps-retry": wfv1.NodeRunning, "prep": wfv1.NodeSucceeded}, phases)
+	}
+
+	// restarting a successful step also retries the steps after it
+	_, phases, err = retryWorkflow(t, failedStepsWorkflow, &RetryOpts{NodeFieldSelector: "displayName=prep", RestartSuccessful: true})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]wfv1.NodePhase{"steps-retry": wfv1.NodeRunning}, phases)
+	}
+
+	_, _, err = retryWorkflow(t, failedStepsWorkflow, &RetryOpts{NodeFieldSelector: "displayName=missing"})
+	assert.Error(t, err)
+}
+
+func TestRetryWorkflowDAG(t *testing.T) {
+	// restarting a task also retries the tasks depending on it, but not its dependencies
+	_, phases, err := retryWorkflow(t, failedDAGWorkflow, &RetryOpts{NodeFieldSelector: "displayName=B", RestartSuccessful: true})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]wfv1.NodePhase{"dag-retry": wfv1.NodeRunning, "A": wfv1.NodeSucceeded, "C": wfv1.NodeFailed}, phases)
+	}
+
+	_, phases, err = retryWorkflow(t, failedDAGWorkflow, &RetryOpts{NodeFieldSelector: "phase=Failed"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]wfv1.NodePhase{"dag-retry": wfv1.NodeRunning, "A": wfv1.NodeSucceeded, "B": wfv1.NodeSucceeded, "D": wfv1.NodeSucceeded}, phases)
+	}
+}
+
+func TestRetryWorkflowParameters(t *testing.T) {
+	wf, _, err := retryWorkflow(t, failedStepsWorkflow, &RetryOpts{Parameters: []string{"epochs=10"}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "10", *wf.Spec.Arguments.Parameters[0].Value)
+	}
+
+	_, _, err = retryWorkflow(t, failedStepsWorkflow, &RetryOpts{Parameters: []string{"missing=10"}})
+	assert.Error(t, err)
+
+	_, _, err = retryWorkflow(t, failedStepsWorkflow, &RetryOpts{Parameters: []string{"epochs"}})
+	assert.Error(t, err)
+}
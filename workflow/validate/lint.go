@@ -14,8 +14,11 @@ import (
 	"github.com/argoproj/argo/workflow/common"
 )
 
-// LintWorkflowDir validates all workflow manifests in a directory. Ignores non-workflow manifests
-func LintWorkflowDir(wfClientset wfclientset.Interface, namespace, dirPath string, strict bool) error {
+// LintWorkflowDir validates all workflow manifests in a directory. Ignores non-workflow manifests.
+// If defaults is not nil, it is merged into the workflows before they are validated. Returns the
+// workflows as they were validated.
+func LintWorkflowDir(wfClientset wfclientset.Interface, namespace, dirPath string, strict bool, defaults *wfv1.Workflow) ([]wfv1.Workflow, error) {
+	var workflows []wfv1.Workflow
 	walkFunc := func(path string, info os.FileInfo, err error) error {
 		if info == nil || info.IsDir() {
 			return nil
@@ -26,17 +29,21 @@ func LintWorkflowDir(wfClientset wfclientset.Interface, namespace, dirPath strin
 		default:
 			return nil
 		}
-		return LintWorkflowFile(wfClientset, namespace, path, strict)
+		fileWorkflows, err := LintWorkflowFile(wfClientset, namespace, path, strict, defaults)
+		workflows = append(workflows, fileWorkflows...)
+		return err
 	}
-	return filepath.Walk(dirPath, walkFunc)
+	err := filepath.Walk(dirPath, walkFunc)
+	return workflows, err
 }
 
 // LintWorkflowFile lints a json file, or multiple workflow manifest in a single yaml file. Ignores
-// non-workflow manifests
-func LintWorkflowFile(wfClientset wfclientset.Interface, namespace, filePath string, strict bool) error {
+// non-workflow manifests. If defaults is not nil, it is merged into the workflows before they are validated.
+// Returns the workflows as they were validated.
+func LintWorkflowFile(wfClientset wfclientset.Interface, namespace, filePath string, strict bool, defaults *wfv1.Workflow) ([]wfv1.Workflow, error) {
 	body, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		return errors.Errorf(errors.CodeBadRequest, "Can't read from file: %s, err: %v", filePath, err)
+		return nil, errors.Errorf(errors.CodeBadRequest, "Can't read from file: %s, err: %v", filePath, err)
 	}
 	var workflows []wfv1.Workflow
 	if json.IsJSON(body) {
@@ -52,22 +59,28 @@ func LintWorkflowFile(wfClientset wfclientset.Interface, namespace, filePath str
 			if wf.Kind != "" && wf.Kind != workflow.WorkflowKind {
 				// If we get here, it was a k8s manifest which was not of type 'Workflow'
 				// We ignore these since we only care about validating Workflow manifests.
-				return nil
+				return nil, nil
 			}
 		}
 	} else {
 		workflows, err = common.SplitWorkflowYAMLFile(body, strict)
 	}
 	if err != nil {
-		return errors.Errorf(errors.CodeBadRequest, "%s failed to parse: %v", filePath, err)
+		return nil, errors.Errorf(errors.CodeBadRequest, "%s failed to parse: %v", filePath, err)
 	}
-	for _, wf := range workflows {
-		err = ValidateWorkflow(wfClientset, namespace, &wf, ValidateOpts{Lint: true})
+	for i := range workflows {
+		if defaults != nil {
+			err = common.MergeWorkflowDefaults(&workflows[i], defaults)
+			if err != nil {
+				return nil, errors.Errorf(errors.CodeBadRequest, "%s: %s", filePath, err.Error())
+			}
+		}
+		err = ValidateWorkflow(wfClientset, namespace, &workflows[i], ValidateOpts{Lint: true})
 		if err != nil {
-			return errors.Errorf(errors.CodeBadRequest, "%s: %s", filePath, err.Error())
+			return nil, errors.Errorf(errors.CodeBadRequest, "%s: %s", filePath, err.Error())
 		}
 	}
-	return nil
+	return workflows, nil
 }
 
 // LintWorkflowTemplateDir validates all workflow manifests in a directory. Ignores non-workflow template manifests
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/valyala/fasttemplate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	apivalidation "k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/yaml"
@@ -31,6 +32,9 @@ type ValidateOpts struct {
 	// types of executors. For example, the inability of kubelet/k8s executors to copy artifacts
 	// out of the base image layer. If unspecified, will use docker executor validation
 	ContainerRuntimeExecutor string
+	// WorkflowDefaults are merged into the workflow before it is validated, like the controller does
+	// with the workflow defaults of its configmap
+	WorkflowDefaults *wfv1.Workflow
 }
 
 // templateValidationCtx is the context for validating a workflow spec
@@ -88,6 +92,13 @@ func ValidateWorkflow(wfClientset wfclientset.Interface, namespace string, wf *w
 	if wf.Namespace != "" {
 		namespace = wf.Namespace
 	}
+	if opts.WorkflowDefaults != nil {
+		wf = wf.DeepCopy()
+		err := common.MergeWorkflowDefaults(wf, opts.WorkflowDefaults)
+		if err != nil {
+			return err
+		}
+	}
 
 	ctx := newTemplateValidationCtx(wfClientset, namespace, wf, opts)
 	tmplCtx := templateresolution.NewContextFromClientset(wfClientset.ArgoprojV1alpha1().WorkflowTemplates(namespace), wf)
@@ -145,6 +156,15 @@ func ValidateWorkflow(wfClientset wfclientset.Interface, namespace string, wf *w
 		default:
 			return errors.Errorf(errors.CodeBadRequest, "podGC.strategy unknown strategy '%s'", wf.Spec.PodGC.Strategy)
 		}
+		if wf.Spec.PodGC.LabelSelector != nil {
+			_, err = metav1.LabelSelectorAsSelector(wf.Spec.PodGC.LabelSelector)
+			if err != nil {
+				return errors.Errorf(errors.CodeBadRequest, "podGC.labelSelector %s", err.Error())
+			}
+		}
+		if wf.Spec.PodGC.DeleteDelayDuration != nil && wf.Spec.PodGC.DeleteDelayDuration.Duration < 0 {
+			return errors.Errorf(errors.CodeBadRequest, "podGC.deleteDelayDuration must not be negative")
+		}
 	}
 
 	// Check if all templates can be resolved.
@@ -302,7 +322,7 @@ func (ctx *templateValidationCtx) validateTemplateHolder(tmplHolder wfv1.Templat
 // validateTemplateType validates that only one template type is defined
 func validateTemplateType(tmpl *wfv1.Template) error {
 	numTypes := 0
-	for _, tmplType := range []interface{}{tmpl.TemplateRef, tmpl.Container, tmpl.Steps, tmpl.Script, tmpl.Resource, tmpl.DAG, tmpl.Suspend} {
+	for _, tmplType := range []interface{}{tmpl.TemplateRef, tmpl.Container, tmpl.Steps, tmpl.Script, tmpl.Resource, tmpl.DAG, tmpl.Suspend, tmpl.HTTP, tmpl.Data} {
 		if !reflect.ValueOf(tmplType).IsNil() {
 			numTypes++
 		}
@@ -312,10 +332,10 @@ func validateTemplateType(tmpl *wfv1.Template) error {
 	}
 	switch numTypes {
 	case 0:
-		return errors.Errorf(errors.CodeBadRequest, "templates.%s template type unspecified. choose one of: container, steps, script, resource, dag, suspend, template, template ref", tmpl.Name)
+		return errors.Errorf(errors.CodeBadRequest, "templates.%s template type unspecified. choose one of: container, steps, script, resource, dag, suspend, http, data, template, template ref", tmpl.Name)
 	case 1:
 	default:
-		return errors.Errorf(errors.CodeBadRequest, "templates.%s multiple template types specified. choose one of: container, steps, script, resource, dag, suspend, template, template ref", tmpl.Name)
+		return errors.Errorf(errors.CodeBadRequest, "templates.%s multiple template types specified. choose one of: container, steps, script, resource, dag, suspend, http, data, template, template ref", tmpl.Name)
 	}
 	return nil
 }
@@ -460,11 +480,86 @@ func (ctx *templateValidationCtx) validateLeaf(scope map[string]interface{}, tmp
 		default:
 			return errors.Errorf(errors.CodeBadRequest, "templates.%s.resource.action must be one of: get, create, apply, delete, replace, patch", tmpl.Name)
 		}
-		// Try to unmarshal the given manifest.
-		obj := unstructured.Unstructured{}
-		err := yaml.Unmarshal([]byte(tmpl.Resource.Manifest), &obj)
+		switch tmpl.Resource.MergeStrategy {
+		case "", "strategic", "merge", "json":
+			// OK
+		default:
+			return errors.Errorf(errors.CodeBadRequest, "templates.%s.resource.mergeStrategy must be one of: strategic, merge, json", tmpl.Name)
+		}
+		if len(tmpl.Resource.Flags) > 0 {
+			switch tmpl.Resource.Action {
+			case "get", "patch", "delete":
+				// OK
+			default:
+				return errors.Errorf(errors.CodeBadRequest, "templates.%s.resource.flags are only valid for the get, patch and delete actions", tmpl.Name)
+			}
+		}
+		if tmpl.Resource.Action == "patch" && tmpl.Resource.MergeStrategy == "json" {
+			if tmpl.Resource.SetOwnerReference {
+				return errors.Errorf(errors.CodeBadRequest, "templates.%s.resource.setOwnerReference is not supported for json patches", tmpl.Name)
+			}
+			// A json patch is a list of operations, so the resource must be identified by the flags.
+			if len(tmpl.Resource.Flags) == 0 {
+				return errors.Errorf(errors.CodeBadRequest, "templates.%s.resource.flags must identify the resource of a json patch", tmpl.Name)
+			}
+			var patch []interface{}
+			err := yaml.Unmarshal([]byte(tmpl.Resource.Manifest), &patch)
+			if err != nil {
+				return errors.Errorf(errors.CodeBadRequest, "templates.%s.resource.manifest must be a list of json patch operations", tmpl.Name)
+			}
+		} else {
+			// Try to unmarshal the given manifest.
+			obj := unstructured.Unstructured{}
+			err := yaml.Unmarshal([]byte(tmpl.Resource.Manifest), &obj)
+			if err != nil {
+				return errors.Errorf(errors.CodeBadRequest, "templates.%s.resource.manifest must be a valid yaml", tmpl.Name)
+			}
+		}
+	}
+	if tmpl.HTTP != nil {
+		if tmpl.HTTP.URL == "" {
+			return errors.Errorf(errors.CodeBadRequest, "templates.%s.http.url is required", tmpl.Name)
+		}
+		switch tmpl.HTTP.Method {
+		case "", "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+			// OK
+		default:
+			return errors.Errorf(errors.CodeBadRequest, "templates.%s.http.method must be one of: GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS", tmpl.Name)
+		}
+		if tmpl.HTTP.TimeoutSeconds != nil && *tmpl.HTTP.TimeoutSeconds <= 0 {
+			return errors.Errorf(errors.CodeBadRequest, "templates.%s.http.timeoutSeconds must be a positive integer > 0", tmpl.Name)
+		}
+		if tmpl.HTTP.TimeoutSeconds != nil && *tmpl.HTTP.TimeoutSeconds > common.MaxHTTPTimeoutSeconds {
+			return errors.Errorf(errors.CodeBadRequest, "templates.%s.http.timeoutSeconds must be at most %d", tmpl.Name, common.MaxHTTPTimeoutSeconds)
+		}
+		for i, header := range tmpl.HTTP.Headers {
+			if header.Name == "" {
+				return errors.Errorf(errors.CodeBadRequest, "templates.%s.http.headers[%d].name is required", tmpl.Name, i)
+			}
+			if header.ValueFrom != nil && header.ValueFrom.SecretKeyRef != nil && (header.ValueFrom.SecretKeyRef.Name == "" || header.ValueFrom.SecretKeyRef.Key == "") {
+				return errors.Errorf(errors.CodeBadRequest, "templates.%s.http.headers[%d].valueFrom.secretKeyRef name and key are required", tmpl.Name, i)
+			}
+		}
+	}
+	if tmpl.Data != nil {
+		if tmpl.Data.Source.ArtifactPaths == nil {
+			return errors.Errorf(errors.CodeBadRequest, "templates.%s.data.source.artifactPaths is required", tmpl.Name)
+		}
+		if !tmpl.Data.Source.ArtifactPaths.HasLocation() {
+			return errors.Errorf(errors.CodeBadRequest, "templates.%s.data.source.artifactPaths location is required", tmpl.Name)
+		}
+		// only the drivers of these locations support listing
+		if tmpl.Data.Source.ArtifactPaths.S3 == nil && tmpl.Data.Source.ArtifactPaths.HDFS == nil {
+			return errors.Errorf(errors.CodeBadRequest, "templates.%s.data.source.artifactPaths must be an s3 or hdfs location", tmpl.Name)
+		}
+		err = validateArtifactLocation(fmt.Sprintf("templates.%s.data.source.artifactPaths", tmpl.Name), tmpl.Data.Source.ArtifactPaths.ArtifactLocation)
 		if err != nil {
-			return errors.Errorf(errors.CodeBadRequest, "templates.%s.resource.manifest must be a valid yaml", tmpl.Name)
+			return err
+		}
+		for i, step := range tmpl.Data.Transformation {
+			if (step.Filter == "") == (step.Map == "") {
+				return errors.Errorf(errors.CodeBadRequest, "templates.%s.data.transformation[%d] must specify exactly one of filter or map", tmpl.Name, i)
+			}
 		}
 	}
 	if tmpl.ActiveDeadlineSeconds != nil {
@@ -555,6 +650,10 @@ func (ctx *templateValidationCtx) validateSteps(scope map[string]interface{}, tm
 			if err != nil {
 				return errors.Errorf(errors.CodeBadRequest, "templates.%s.steps[%d].%s %s", tmpl.Name, i, step.Name, err.Error())
 			}
+			err = validateMapStrategy(step.MapStrategy, step.WithItems, step.WithParam, step.WithSequence)
+			if err != nil {
+				return errors.Errorf(errors.CodeBadRequest, "templates.%s.steps[%d].%s %s", tmpl.Name, i, step.Name, err.Error())
+			}
 			stepBytes, err := json.Marshal(stepGroup)
 			if err != nil {
 				return errors.InternalWrapError(err)
@@ -576,7 +675,10 @@ func (ctx *templateValidationCtx) validateSteps(scope map[string]interface{}, tm
 		for i, step := range stepGroup {
 			aggregate := len(step.WithItems) > 0 || step.WithParam != ""
 			resolvedTmpl := resolvedTemplates[step.Name]
-			ctx.addOutputsToScope(resolvedTmpl, fmt.Sprintf("steps.%s", step.Name), scope, aggregate)
+			if step.MapStrategy == nil {
+				// the outputs of the items of a map are not kept, so they cannot be referenced
+				ctx.addOutputsToScope(resolvedTmpl, fmt.Sprintf("steps.%s", step.Name), scope, aggregate)
+			}
 
 			// Validate the template again with actual arguments.
 			_, err = ctx.validateTemplateHolder(&step, tmplCtx, &step.Arguments, scope)
@@ -629,11 +731,25 @@ func addItemsToScope(prefix string, withItems []wfv1.Item, withParam string, wit
 	return nil
 }
 
+// validateMapStrategy validates the map strategy of a step or task, which requires items to map over
+func validateMapStrategy(strategy *wfv1.MapStrategy, withItems []wfv1.Item, withParam string, withSequence *wfv1.Sequence) error {
+	if strategy == nil {
+		return nil
+	}
+	if len(withItems) == 0 && withParam == "" && withSequence == nil {
+		return errors.New(errors.CodeBadRequest, "mapStrategy requires one of withItems, withParam, withSequence")
+	}
+	if strategy.Parallelism != nil && *strategy.Parallelism < 1 {
+		return errors.New(errors.CodeBadRequest, "mapStrategy.parallelism must be greater than zero")
+	}
+	return nil
+}
+
 func (ctx *templateValidationCtx) addOutputsToScope(tmpl *wfv1.Template, prefix string, scope map[string]interface{}, aggregate bool) {
 	if tmpl.Daemon != nil && *tmpl.Daemon {
 		scope[fmt.Sprintf("%s.ip", prefix)] = true
 	}
-	if tmpl.Script != nil {
+	if tmpl.Script != nil || tmpl.HTTP != nil || tmpl.Data != nil {
 		scope[fmt.Sprintf("%s.outputs.result", prefix)] = true
 	}
 	for _, param := range tmpl.Outputs.Parameters {
@@ -654,7 +770,7 @@ func (ctx *templateValidationCtx) addOutputsToScope(tmpl *wfv1.Template, prefix
 	}
 	if aggregate {
 		switch tmpl.GetType() {
-		case wfv1.TemplateTypeScript:
+		case wfv1.TemplateTypeScript, wfv1.TemplateTypeHTTP, wfv1.TemplateTypeData:
 			scope[fmt.Sprintf("%s.outputs.result", prefix)] = true
 		default:
 			scope[fmt.Sprintf("%s.outputs.parameters", prefix)] = true
@@ -706,6 +822,9 @@ func validateOutputs(scope map[string]interface{}, tmpl *wfv1.Template) error {
 		}
 		if param.ValueFrom != nil {
 			tmplType := tmpl.GetType()
+			if param.ValueFrom.Expression != "" && tmplType != wfv1.TemplateTypeDAG && tmplType != wfv1.TemplateTypeSteps {
+				return errors.Errorf(errors.CodeBadRequest, "%s.expression is only valid in steps and dag templates", paramRef)
+			}
 			switch tmplType {
 			case wfv1.TemplateTypeContainer, wfv1.TemplateTypeScript:
 				if param.ValueFrom.Path == "" {
@@ -716,8 +835,8 @@ func validateOutputs(scope map[string]interface{}, tmpl *wfv1.Template) error {
 					return errors.Errorf(errors.CodeBadRequest, "%s .jqFilter or jsonPath must be specified for %s templates", paramRef, tmplType)
 				}
 			case wfv1.TemplateTypeDAG, wfv1.TemplateTypeSteps:
-				if param.ValueFrom.Parameter == "" {
-					return errors.Errorf(errors.CodeBadRequest, "%s.parameter must be specified for %s templates", paramRef, tmplType)
+				if param.ValueFrom.Parameter == "" && param.ValueFrom.Expression == "" {
+					return errors.Errorf(errors.CodeBadRequest, "%s.parameter must be specified for %s templates, unless an expression is given", paramRef, tmplType)
 				}
 			}
 		}
@@ -788,17 +907,17 @@ func validateOutputParameter(paramRef string, param *wfv1.Parameter) error {
 		return errors.Errorf(errors.CodeBadRequest, "%s does not have valueFrom or value specified", paramRef)
 	}
 	paramTypes := 0
-	for _, value := range []string{param.ValueFrom.Path, param.ValueFrom.JQFilter, param.ValueFrom.JSONPath, param.ValueFrom.Parameter} {
+	for _, value := range []string{param.ValueFrom.Path, param.ValueFrom.JQFilter, param.ValueFrom.JSONPath, param.ValueFrom.Parameter, param.ValueFrom.Expression} {
 		if value != "" {
 			paramTypes++
 		}
 	}
 	switch paramTypes {
 	case 0:
-		return errors.New(errors.CodeBadRequest, "valueFrom type unspecified. choose one of: path, jqFilter, jsonPath, parameter")
+		return errors.New(errors.CodeBadRequest, "valueFrom type unspecified. choose one of: path, jqFilter, jsonPath, parameter, expression")
 	case 1:
 	default:
-		return errors.New(errors.CodeBadRequest, "multiple valueFrom types specified. choose one of: path, jqFilter, jsonPath, parameter")
+		return errors.New(errors.CodeBadRequest, "multiple valueFrom types specified. choose one of: path, jqFilter, jsonPath, parameter, expression")
 	}
 	return nil
 }
@@ -924,6 +1043,10 @@ func (ctx *templateValidationCtx) validateDAG(scope map[string]interface{}, tmpl
 			ancestorTask := nameToTask[ancestor]
 			resolvedTmpl := resolvedTemplates[ancestor]
 			ancestorPrefix := fmt.Sprintf("tasks.%s", ancestor)
+			if ancestorTask.MapStrategy != nil {
+				// the outputs of the items of a map are not kept, so they cannot be referenced
+				continue
+			}
 			aggregate := len(ancestorTask.WithItems) > 0 || ancestorTask.WithParam != ""
 			ctx.addOutputsToScope(resolvedTmpl, ancestorPrefix, taskScope, aggregate)
 		}
@@ -931,6 +1054,10 @@ func (ctx *templateValidationCtx) validateDAG(scope map[string]interface{}, tmpl
 		if err != nil {
 			return errors.Errorf(errors.CodeBadRequest, "templates.%s.tasks.%s %s", tmpl.Name, task.Name, err.Error())
 		}
+		err = validateMapStrategy(task.MapStrategy, task.WithItems, task.WithParam, task.WithSequence)
+		if err != nil {
+			return errors.Errorf(errors.CodeBadRequest, "templates.%s.tasks.%s %s", tmpl.Name, task.Name, err.Error())
+		}
 		err = resolveAllVariables(taskScope, string(taskBytes))
 		if err != nil {
 			return errors.Errorf(errors.CodeBadRequest, "templates.%s.tasks.%s %s", tmpl.Name, task.Name, err.Error())
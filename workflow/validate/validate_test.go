@@ -2,11 +2,13 @@ package validate
 
 import (
 	"testing"
+	"time"
 
 	"sigs.k8s.io/yaml"
 	"github.com/stretchr/testify/assert"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
 	wfv1 "github.com/argoproj/argo/pkg/apis/workflow/v1alpha1"
 	fakewfclientset "github.com/argoproj/argo/pkg/client/clientset/versioned/fake"
@@ -1686,6 +1688,53 @@ func TestInvalidResourceWorkflow(t *testing.T) {
 	wf = unmarshalWf(invalidActionResourceWorkflow)
 	err = ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
 	assert.EqualError(t, err, "templates.whalesay.resource.action must be one of: get, create, apply, delete, replace, patch")
+
+	wf = unmarshalWf(jsonPatchResourceWorkflow)
+	wf.Spec.Templates[0].Resource.Flags = nil
+	err = ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	assert.EqualError(t, err, "templates.whalesay.resource.flags must identify the resource of a json patch")
+
+	wf = unmarshalWf(jsonPatchResourceWorkflow)
+	wf.Spec.Templates[0].Resource.MergeStrategy = "foo"
+	err = ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	assert.EqualError(t, err, "templates.whalesay.resource.mergeStrategy must be one of: strategic, merge, json")
+
+	wf = unmarshalWf(jsonPatchResourceWorkflow)
+	wf.Spec.Templates[0].Resource.SetOwnerReference = true
+	err = ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	assert.EqualError(t, err, "templates.whalesay.resource.setOwnerReference is not supported for json patches")
+
+	// flags replace the manifest, so they are only valid for actions which do not need it
+	wf = unmarshalWf(validResourceWorkflow)
+	wf.Spec.Templates[0].Resource.Flags = []string{"configmap", "whalesay-cm"}
+	err = ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	assert.EqualError(t, err, "templates.whalesay.resource.flags are only valid for the get, patch and delete actions")
+}
+
+var jsonPatchResourceWorkflow = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: json-patch-resource-
+spec:
+  entrypoint: whalesay
+  templates:
+  - name: whalesay
+    resource:
+      action: patch
+      mergeStrategy: json
+      flags: [configmap, whalesay-cm]
+      manifest: |
+        - op: replace
+          path: /data/message
+          value: hello
+`
+
+// TestJSONPatchResourceWorkflow verifies a workflow of a json patch of a resource identified by flags.
+func TestJSONPatchResourceWorkflow(t *testing.T) {
+	wf := unmarshalWf(jsonPatchResourceWorkflow)
+	err := ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	assert.NoError(t, err)
 }
 
 var invalidPodGC = `
@@ -1808,3 +1857,391 @@ func TestAutomountServiceAccountTokenUse(t *testing.T) {
 		assert.EqualError(t, err, "templates.whalesay.executor.serviceAccountName must not be empty if automountServiceAccountToken is false")
 	}
 }
+
+var validHTTPTemplate = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: http-
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    steps:
+    - - name: request
+        template: request
+    - - name: print
+        template: whalesay
+        arguments:
+          parameters:
+          - name: message
+            value: "{{steps.request.outputs.result}}"
+  - name: request
+    http:
+      url: http://example.com
+      successCondition: "{{response.statusCode}} == 200"
+  - name: whalesay
+    inputs:
+      parameters:
+      - name: message
+    container:
+      image: alpine:latest
+      args: ["{{inputs.parameters.message}}"]
+`
+
+var invalidHTTPTemplate = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: http-
+spec:
+  entrypoint: request
+  templates:
+  - name: request
+    http:
+      url: http://example.com
+      method: FETCH
+`
+
+var missingURLHTTPTemplate = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: http-
+spec:
+  entrypoint: request
+  templates:
+  - name: request
+    http:
+      method: GET
+`
+
+var longTimeoutHTTPTemplate = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: http-
+spec:
+  entrypoint: request
+  templates:
+  - name: request
+    http:
+      url: http://example.com
+      timeoutSeconds: 600
+`
+
+func TestHTTPTemplate(t *testing.T) {
+	err := validate(validHTTPTemplate)
+	assert.NoError(t, err)
+	err = validate(invalidHTTPTemplate)
+	assert.EqualError(t, err, "templates.request.http.method must be one of: GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+	err = validate(missingURLHTTPTemplate)
+	assert.EqualError(t, err, "templates.request.http.url is required")
+	err = validate(longTimeoutHTTPTemplate)
+	assert.EqualError(t, err, "templates.request.http.timeoutSeconds must be at most 120")
+}
+
+var validDataTemplate = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: data-
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    steps:
+    - - name: list
+        template: list
+    - - name: process
+        template: whalesay
+        arguments:
+          parameters:
+          - name: file
+            value: "{{item}}"
+        withParam: "{{steps.list.outputs.result}}"
+  - name: list
+    data:
+      source:
+        artifactPaths:
+          name: files
+          s3:
+            endpoint: s3.amazonaws.com
+            bucket: my-bucket
+            key: data/
+      transformation:
+      - filter: "'{{item}}' =~ '.csv$'"
+  - name: whalesay
+    inputs:
+      parameters:
+      - name: file
+    container:
+      image: alpine:latest
+      args: ["{{inputs.parameters.file}}"]
+`
+
+var invalidDataTemplateTransformation = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: data-
+spec:
+  entrypoint: list
+  templates:
+  - name: list
+    data:
+      source:
+        artifactPaths:
+          name: files
+          s3:
+            endpoint: s3.amazonaws.com
+            bucket: my-bucket
+            key: data/
+      transformation:
+      - filter: "'{{item}}' =~ '.csv$'"
+        map: "s3://my-bucket/{{item}}"
+`
+
+var invalidDataTemplateSource = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: data-
+spec:
+  entrypoint: list
+  templates:
+  - name: list
+    data:
+      source: {}
+`
+
+var unlistableDataTemplateSource = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: data-
+spec:
+  entrypoint: list
+  templates:
+  - name: list
+    data:
+      source:
+        artifactPaths:
+          name: files
+          git:
+            repo: https://github.com/argoproj/argo.git
+`
+
+func TestDataTemplate(t *testing.T) {
+	err := validate(validDataTemplate)
+	assert.NoError(t, err)
+	err = validate(invalidDataTemplateTransformation)
+	assert.EqualError(t, err, "templates.list.data.transformation[0] must specify exactly one of filter or map")
+	err = validate(invalidDataTemplateSource)
+	assert.EqualError(t, err, "templates.list.data.source.artifactPaths is required")
+	err = validate(unlistableDataTemplateSource)
+	assert.EqualError(t, err, "templates.list.data.source.artifactPaths must be an s3 or hdfs location")
+}
+
+var mapStrategyWithArtifactParam = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: map-
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    steps:
+    - - name: generate
+        template: generate
+    - - name: process
+        template: echo
+        arguments:
+          parameters:
+          - name: message
+            value: "{{item}}"
+        withParam: "{{steps.generate.outputs.artifacts.items}}"
+        mapStrategy:
+          parallelism: 10
+  - name: generate
+    container:
+      image: alpine:latest
+      command: [sh, -c, 'echo "[1, 2, 3]" > /tmp/items.json']
+    outputs:
+      artifacts:
+      - name: items
+        path: /tmp/items.json
+  - name: echo
+    inputs:
+      parameters:
+      - name: message
+    container:
+      image: alpine:latest
+      command: [echo, "{{inputs.parameters.message}}"]
+    outputs:
+      parameters:
+      - name: message
+        value: "{{inputs.parameters.message}}"
+`
+
+func TestMapStrategy(t *testing.T) {
+	err := validate(mapStrategyWithArtifactParam)
+	assert.NoError(t, err)
+
+	wf := unmarshalWf(mapStrategyWithArtifactParam)
+	wf.Spec.Templates[0].Steps[1][0].WithParam = ""
+	err = ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	assert.EqualError(t, err, "templates.main.steps[1].process mapStrategy requires one of withItems, withParam, withSequence")
+
+	wf = unmarshalWf(mapStrategyWithArtifactParam)
+	wf.Spec.Templates[0].Steps[1][0].MapStrategy.Parallelism = pointer.Int64Ptr(0)
+	err = ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	assert.EqualError(t, err, "templates.main.steps[1].process mapStrategy.parallelism must be greater than zero")
+
+	// the outputs of the items of a map are not kept
+	wf = unmarshalWf(mapStrategyWithArtifactParam)
+	wf.Spec.Templates[0].Steps = append(wf.Spec.Templates[0].Steps, []wfv1.WorkflowStep{{
+		Name:     "print",
+		Template: "echo",
+		Arguments: wfv1.Arguments{Parameters: []wfv1.Parameter{{
+			Name:  "message",
+			Value: pointer.StringPtr("{{steps.process.outputs.parameters}}"),
+		}}},
+	}})
+	err = ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to resolve {{steps.process.outputs.parameters}}")
+	}
+}
+
+var outputExpressionInSteps = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: output-expression-
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    steps:
+    - - name: flip
+        template: flip
+    - - name: heads
+        template: flip
+        when: "{{steps.flip.outputs.result}} == heads"
+    outputs:
+      parameters:
+      - name: result
+        valueFrom:
+          expression: "'{{steps.heads.outputs.result}}' != '' ? 'heads' : 'tails'"
+      - name: heads
+        valueFrom:
+          parameter: "{{steps.heads.outputs.result}}"
+          default: none
+  - name: flip
+    script:
+      image: alpine:latest
+      command: [sh]
+      source: echo heads
+`
+
+var outputExpressionInContainer = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: output-expression-
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    container:
+      image: alpine:latest
+      command: [echo, hello]
+    outputs:
+      parameters:
+      - name: message
+        valueFrom:
+          expression: "1 + 1"
+`
+
+func TestOutputParameterExpression(t *testing.T) {
+	err := validate(outputExpressionInSteps)
+	assert.NoError(t, err)
+	err = validate(outputExpressionInContainer)
+	assert.EqualError(t, err, "templates.main.outputs.parameters.message.expression is only valid in steps and dag templates")
+}
+
+var invalidPodGCSelector = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: pod-gc-invalid-selector-
+spec:
+  podGC:
+    strategy: OnPodCompletion
+    labelSelector:
+      matchExpressions:
+      - key: evict
+        operator: Foo
+    deleteDelayDuration: 1h
+  entrypoint: whalesay
+  templates:
+  - name: whalesay
+    container:
+      image: docker/whalesay:latest
+`
+
+// TestPodGCLabelSelector verifies the pod gc label selector and delay are validated
+func TestPodGCLabelSelector(t *testing.T) {
+	wf := unmarshalWf(invalidPodGCSelector)
+	err := ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "podGC.labelSelector")
+
+	wf.Spec.PodGC.LabelSelector.MatchExpressions[0].Operator = metav1.LabelSelectorOpExists
+	err = ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	assert.NoError(t, err)
+
+	wf.Spec.PodGC.DeleteDelayDuration.Duration = -time.Hour
+	err = ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	assert.EqualError(t, err, "podGC.deleteDelayDuration must not be negative")
+}
+
+var workflowWithoutDefaults = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+metadata:
+  generateName: defaults-
+spec:
+  entrypoint: main
+  templates:
+  - name: main
+    steps:
+    - - name: hello
+        template: whalesay
+`
+
+var workflowDefaults = `
+apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+spec:
+  serviceAccountName: workflow
+  templates:
+  - name: whalesay
+    container:
+      image: docker/whalesay:latest
+`
+
+// TestWorkflowDefaults verifies the workflow defaults are merged into the workflow before validation
+func TestWorkflowDefaults(t *testing.T) {
+	wf := unmarshalWf(workflowWithoutDefaults)
+	err := ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{})
+	assert.Error(t, err)
+
+	err = ValidateWorkflow(wfClientset, metav1.NamespaceDefault, wf, ValidateOpts{WorkflowDefaults: unmarshalWf(workflowDefaults)})
+	assert.NoError(t, err)
+	// the workflow itself is left unchanged
+	assert.Len(t, wf.Spec.Templates, 1)
+	assert.Empty(t, wf.Spec.ServiceAccountName)
+}